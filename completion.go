@@ -0,0 +1,251 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	"github.com/fusion/gogo/pkg/gogo"
+)
+
+// completionShells are the shells gogo completion writes scripts for.
+var completionShells = []string{"bash", "fish", "zsh"}
+
+// completionCommand describes a gogo command to shell completion scripts.
+type completionCommand struct {
+	name        string
+	description string
+	flags       *flag.FlagSet
+	// args are the words its first argument may be, configuredCommands for
+	// the configured commands
+	args []string
+}
+
+// configuredCommands stands for the configured commands, listed when
+// completing rather than when the script is written.
+const configuredCommands = "<configured>"
+
+// configuredList lists the configured commands, whatever their tags, one per
+// line. Being the first argument, they come before any -config.
+const configuredList = "gogo list -plain -tags all -show-disabled 2>/dev/null | cut -f1"
+
+// fileFlags take a path.
+var fileFlags = []string{"config", "o", "target"}
+
+// flagValues lists the values some flags take.
+func flagValues(name string) []string {
+	switch name {
+	case "libc":
+		return []string{"glibc", "musl"}
+	case "output":
+		return []string{"text", "json"}
+	case "sort":
+		return []string{"name", "tag", "installed", "updated"}
+	case "os":
+		return gogo.KnownOSes
+	case "arch":
+		return gogo.KnownArchs
+	}
+	return nil
+}
+
+func isBoolFlag(f *flag.Flag) bool {
+	boolFlag, ok := f.Value.(interface{ IsBoolFlag() bool })
+	return ok && boolFlag.IsBoolFlag()
+}
+
+// doCompletion writes the completion script of shell, completing the
+// commands, their flags and, for those taking one, configured commands.
+func doCompletion(out io.Writer, shell string, commands []completionCommand) {
+	switch shell {
+	case "bash":
+		writeBashCompletion(out, commands)
+	case "fish":
+		writeFishCompletion(out, commands)
+	case "zsh":
+		writeZshCompletion(out, commands)
+	}
+}
+
+// flagNames lists the flags of a command, dash included.
+func flagNames(command completionCommand) []string {
+	var names []string
+	if command.flags != nil {
+		command.flags.VisitAll(func(f *flag.Flag) { names = append(names, "-"+f.Name) })
+	}
+	return names
+}
+
+func writeBashCompletion(out io.Writer, commands []completionCommand) {
+	var names, valueFlags []string
+	for _, command := range commands {
+		names = append(names, command.name)
+		if command.flags != nil {
+			command.flags.VisitAll(func(f *flag.Flag) {
+				if !isBoolFlag(f) && !slices.Contains(fileFlags, f.Name) && flagValues(f.Name) == nil && !slices.Contains(valueFlags, "-"+f.Name) {
+					valueFlags = append(valueFlags, "-"+f.Name)
+				}
+			})
+		}
+	}
+	fmt.Fprintln(out, "# bash completion for gogo, written by gogo completion bash")
+	fmt.Fprintln(out, "_gogo() {")
+	fmt.Fprintln(out, `    local cur=${COMP_WORDS[COMP_CWORD]} prev=${COMP_WORDS[COMP_CWORD-1]}`)
+	fmt.Fprintln(out, "    if ((COMP_CWORD == 1)); then")
+	fmt.Fprintf(out, "        COMPREPLY=($(compgen -W %q -- \"$cur\"))\n", strings.Join(names, " "))
+	fmt.Fprintln(out, "        return")
+	fmt.Fprintln(out, "    fi")
+	fmt.Fprintln(out, "    case $prev in")
+	fmt.Fprintf(out, "    %s)\n", strings.Join(prefixed("-", fileFlags), "|"))
+	fmt.Fprintln(out, `        COMPREPLY=($(compgen -f -- "$cur"))`)
+	fmt.Fprintln(out, "        return ;;")
+	for _, name := range []string{"libc", "output", "sort", "os", "arch"} {
+		fmt.Fprintf(out, "    -%s)\n", name)
+		fmt.Fprintf(out, "        COMPREPLY=($(compgen -W %q -- \"$cur\"))\n", strings.Join(flagValues(name), " "))
+		fmt.Fprintln(out, "        return ;;")
+	}
+	if len(valueFlags) > 0 {
+		fmt.Fprintf(out, "    %s)\n", strings.Join(valueFlags, "|"))
+		fmt.Fprintln(out, "        return ;;")
+	}
+	fmt.Fprintln(out, "    esac")
+	fmt.Fprintln(out, "    local flags args")
+	fmt.Fprintln(out, "    case ${COMP_WORDS[1]} in")
+	for _, command := range commands {
+		fmt.Fprintf(out, "    %s)\n", command.name)
+		fmt.Fprintf(out, "        flags=%q\n", strings.Join(flagNames(command), " "))
+		switch {
+		case slices.Contains(command.args, configuredCommands):
+			fmt.Fprintln(out, `        ((COMP_CWORD == 2)) && args=$(`+configuredList+`) ;;`)
+		case len(command.args) > 0:
+			fmt.Fprintf(out, "        ((COMP_CWORD == 2)) && args=%q ;;\n", strings.Join(command.args, " "))
+		default:
+			fmt.Fprintln(out, "        ;;")
+		}
+	}
+	fmt.Fprintln(out, "    esac")
+	fmt.Fprintln(out, "    if [[ $cur == -* ]]; then")
+	fmt.Fprintln(out, `        COMPREPLY=($(compgen -W "$flags" -- "$cur"))`)
+	fmt.Fprintln(out, "    else")
+	fmt.Fprintln(out, `        COMPREPLY=($(compgen -W "$args" -- "$cur"))`)
+	fmt.Fprintln(out, "    fi")
+	fmt.Fprintln(out, "}")
+	fmt.Fprintln(out)
+	fmt.Fprintln(out, "complete -F _gogo gogo")
+}
+
+func writeFishCompletion(out io.Writer, commands []completionCommand) {
+	fmt.Fprintln(out, "# fish completion for gogo, written by gogo completion fish")
+	fmt.Fprintln(out, "complete -c gogo -f")
+	for _, command := range commands {
+		fmt.Fprintf(out, "complete -c gogo -n __fish_use_subcommand -a %s -d %s\n", command.name, fishQuote(command.description))
+	}
+	for _, command := range commands {
+		condition := fishQuote("__fish_seen_subcommand_from " + command.name)
+		switch {
+		case slices.Contains(command.args, configuredCommands):
+			fmt.Fprintf(out, "complete -c gogo -n %s -a '(%s)'\n", condition, configuredList)
+		case len(command.args) > 0:
+			fmt.Fprintf(out, "complete -c gogo -n %s -a %s\n", condition, fishQuote(strings.Join(command.args, " ")))
+		}
+		if command.flags == nil {
+			continue
+		}
+		command.flags.VisitAll(func(f *flag.Flag) {
+			spec := fmt.Sprintf("complete -c gogo -n %s -o %s", condition, f.Name)
+			switch {
+			case isBoolFlag(f):
+			case slices.Contains(fileFlags, f.Name):
+				spec += " -r -F"
+			case flagValues(f.Name) != nil:
+				spec += " -x -a " + fishQuote(strings.Join(flagValues(f.Name), " "))
+			default:
+				spec += " -x"
+			}
+			fmt.Fprintf(out, "%s -d %s\n", spec, fishQuote(f.Usage))
+		})
+	}
+}
+
+func writeZshCompletion(out io.Writer, commands []completionCommand) {
+	fmt.Fprintln(out, "#compdef gogo")
+	fmt.Fprintln(out, "# zsh completion for gogo, written by gogo completion zsh")
+	fmt.Fprintln(out)
+	fmt.Fprintln(out, "_gogo_configured() {")
+	fmt.Fprintln(out, "    local -a configured")
+	fmt.Fprintf(out, "    configured=(${(f)\"$(%s)\"})\n", configuredList)
+	fmt.Fprintln(out, "    _describe -t commands 'configured command' configured")
+	fmt.Fprintln(out, "}")
+	fmt.Fprintln(out)
+	fmt.Fprintln(out, "_gogo() {")
+	fmt.Fprintln(out, "    local -a commands")
+	fmt.Fprintln(out, "    commands=(")
+	for _, command := range commands {
+		fmt.Fprintf(out, "        %s\n", zshQuote(command.name+":"+command.description))
+	}
+	fmt.Fprintln(out, "    )")
+	fmt.Fprintln(out, "    if ((CURRENT == 2)); then")
+	fmt.Fprintln(out, "        _describe -t commands 'gogo command' commands")
+	fmt.Fprintln(out, "        return")
+	fmt.Fprintln(out, "    fi")
+	fmt.Fprintln(out, "    local command=$words[2]")
+	fmt.Fprintln(out, "    shift words")
+	fmt.Fprintln(out, "    ((CURRENT--))")
+	fmt.Fprintln(out, "    case $command in")
+	for _, command := range commands {
+		fmt.Fprintf(out, "    %s)\n", command.name)
+		fmt.Fprint(out, "        _arguments -S")
+		switch {
+		case slices.Contains(command.args, configuredCommands):
+			fmt.Fprint(out, " '1::command:_gogo_configured'")
+		case len(command.args) > 0:
+			fmt.Fprintf(out, " %s", zshQuote("1::argument:("+strings.Join(command.args, " ")+")"))
+		}
+		if command.flags != nil {
+			command.flags.VisitAll(func(f *flag.Flag) {
+				spec := "-" + f.Name + "[" + zshEscape(f.Usage) + "]"
+				switch {
+				case isBoolFlag(f):
+				case slices.Contains(fileFlags, f.Name):
+					spec += ":path:_files"
+				case flagValues(f.Name) != nil:
+					spec += ":" + f.Name + ":(" + strings.Join(flagValues(f.Name), " ") + ")"
+				default:
+					spec += ":" + f.Name + ": "
+				}
+				fmt.Fprintf(out, " \\\n            %s", zshQuote(spec))
+			})
+		}
+		fmt.Fprintln(out, " ;;")
+	}
+	fmt.Fprintln(out, "    esac")
+	fmt.Fprintln(out, "}")
+	fmt.Fprintln(out)
+	fmt.Fprintln(out, `_gogo "$@"`)
+}
+
+func prefixed(prefix string, names []string) []string {
+	var result []string
+	for _, name := range names {
+		result = append(result, prefix+name)
+	}
+	return result
+}
+
+// fishQuote single-quotes s for fish.
+func fishQuote(s string) string {
+	return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(s) + "'"
+}
+
+// zshQuote single-quotes s for zsh.
+func zshQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// zshEscape escapes what is special in the description of an _arguments
+// option.
+func zshEscape(s string) string {
+	return strings.NewReplacer(`[`, `\[`, `]`, `\]`, `:`, `\:`).Replace(s)
+}
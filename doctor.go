@@ -0,0 +1,155 @@
+package main
+
+import (
+	"cmp"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/lipgloss/table"
+	"github.com/fusion/gogo/pkg/gogo"
+)
+
+// checkStatus is the outcome of one of doctor's checks.
+type checkStatus int
+
+const (
+	checkPass checkStatus = iota
+	checkWarn
+	checkFail
+)
+
+func (s checkStatus) String() string {
+	switch s {
+	case checkWarn:
+		return warningStyle.Render("warn")
+	case checkFail:
+		return errorStyle.Render("fail")
+	}
+	return okStyle.Render("pass")
+}
+
+type checkResult struct {
+	name    string
+	status  checkStatus
+	details string
+}
+
+// doDoctor checks what gogo depends on: the configuration, the token, the
+// target directory and the GitHub API. It exits with an error if any check
+// fails, warnings being left to the user's judgement.
+func doDoctor(configPath string, proxy string) {
+	var results []checkResult
+	check := func(name string, status checkStatus, format string, a ...any) {
+		results = append(results, checkResult{name, status, fmt.Sprintf(format, a...)})
+	}
+	defer func() {
+		t := table.New().
+			Border(lipgloss.NormalBorder()).
+			StyleFunc(func(_, col int) lipgloss.Style {
+				if col == 2 {
+					return lipgloss.NewStyle().Width(64).Padding(0, 1)
+				}
+				return lipgloss.NewStyle().Padding(0, 1)
+			}).
+			BorderStyle(lipgloss.NewStyle().Foreground(lipgloss.Color("99")))
+		t.Headers("Check", "Status", "Details")
+		for _, result := range results {
+			t.Row(result.name, result.status.String(), result.details)
+		}
+		fmt.Fprintln(stdout, t)
+		if slices.ContainsFunc(results, func(result checkResult) bool { return result.status == checkFail }) {
+			os.Exit(1)
+		}
+	}()
+
+	config, err := gogo.ReadConfig(configPath)
+	if err != nil {
+		check("configuration", checkFail, "cannot read %s: %v", configPath, err)
+		return
+	}
+	problems := config.Validate()
+	if len(problems) == 0 {
+		check("configuration", checkPass, "%s, %d repositories", configPath, len(config.Repositories))
+	}
+	for _, problem := range problems {
+		check("configuration", checkFail, "%v", problem)
+	}
+	for _, warning := range config.Warnings() {
+		check("configuration", checkWarn, "%s", warning)
+	}
+
+	token, source := gogo.ResolveToken(config.Auth, config.Network)
+	switch {
+	case token == "" && gogo.IsPlaceholderToken(config.Auth.Token):
+		check("token", checkWarn, "auth.token is still the placeholder %q, requests are anonymous", config.Auth.Token)
+	case token == "":
+		check("token", checkWarn, "none, requests are anonymous and limited to 60 an hour")
+	default:
+		if err := gogo.CheckToken(token); err != nil {
+			check("token", checkFail, "from %s: %v", source, err)
+		} else {
+			check("token", checkPass, "from %s", source)
+		}
+	}
+
+	doctorTargetDir(config.Paths.TargetDir, check)
+
+	if proxy != "" {
+		config.Network.Proxy = proxy
+	}
+	client, err := gogo.NewClient(config.Network, token)
+	if err != nil {
+		check("GitHub API", checkFail, "cannot configure network: %v", err)
+		return
+	}
+	quota, err := client.GetRateQuota()
+	if err != nil {
+		check("GitHub API", checkFail, "%s unreachable: %v", client.APIURL, err)
+		return
+	}
+	check("GitHub API", checkPass, "%s reachable", client.APIURL)
+	left := quotaLeft(quota)
+	switch {
+	case quota.Remaining == 0:
+		check("rate limit", checkFail, "%s", left)
+	case quota.Remaining < quota.Limit/10:
+		check("rate limit", checkWarn, "%s", left)
+	default:
+		check("rate limit", checkPass, "%s", left)
+	}
+}
+
+// doctorTargetDir checks that commands can be installed to the target
+// directory, and then run without giving their path.
+func doctorTargetDir(targetDir string, check func(name string, status checkStatus, format string, a ...any)) {
+	targetDir, err := gogo.ExpandPath(cmp.Or(targetDir, "."))
+	if err != nil {
+		check("target directory", checkFail, "%v", err)
+		return
+	}
+	if _, err := os.Stat(targetDir); os.IsNotExist(err) {
+		check("target directory", checkWarn, "%s does not exist, gogo fetch will create it", targetDir)
+	} else if err := checkTargetDir(targetDir, false); err != nil {
+		check("target directory", checkFail, "%v", err)
+	} else {
+		check("target directory", checkPass, "%s is writable", targetDir)
+	}
+
+	absDir, err := filepath.Abs(targetDir)
+	if err != nil {
+		check("PATH", checkWarn, "%v", err)
+		return
+	}
+	onPath := slices.ContainsFunc(filepath.SplitList(os.Getenv("PATH")), func(dir string) bool {
+		absPathDir, err := filepath.Abs(dir)
+		return err == nil && dir != "" && absPathDir == absDir
+	})
+	if onPath {
+		check("PATH", checkPass, "%s is on PATH", targetDir)
+	} else {
+		check("PATH", checkWarn, "%s is not on PATH, commands installed there need their full path", targetDir)
+	}
+}
@@ -1,130 +1,168 @@
 package main
 
 import (
-	"archive/tar"
-	"archive/zip"
 	"bufio"
-	"compress/gzip"
+	"cmp"
+	"context"
 	"encoding/json"
 	"flag"
 	"fmt"
 	"io"
-	"net/http"
 	"os"
-	"os/user"
+	"os/signal"
 	"path/filepath"
-	"runtime"
+	"slices"
 	"sort"
+	"strconv"
 	"strings"
+	"sync"
+	"syscall"
+	"time"
 
-	"dario.cat/mergo"
 	"github.com/BurntSushi/toml"
 	"github.com/charmbracelet/lipgloss"
 	"github.com/charmbracelet/lipgloss/table"
+	"github.com/fusion/gogo/pkg/gogo"
+	"github.com/mattn/go-isatty"
 )
 
-type Auth struct {
-	Token string `toml:"token"`
-}
-
-type Paths struct {
-	TargetDir string `toml:"targetdir"`
-}
-
-type Repository struct {
-	Name    string   `toml:"name"`
-	File    string   `toml:"file"`
-	Command string   `toml:"command"`
-	Utils   []string `toml:"utils"`
-	Comment string   `toml:"comment"`
-	Tags    []string `toml:"tags"`
-}
-
-type Repositories []Repository
-
-func (p Repositories) Len() int           { return len(p) }
-func (p Repositories) Less(i, j int) bool { return p[i].File < p[j].File }
-func (p Repositories) Swap(i, j int)      { p[i], p[j] = p[j], p[i] }
-
-type Config struct {
-	Auth         Auth         `toml:"auth"`
-	Paths        Paths        `toml:"paths"`
-	Repositories Repositories `toml:"repositories"`
-}
-
-type ReleaseAsset struct {
-	BrowserDownloadURL string `json:"browser_download_url"`
-	Name               string `json:"name"`
-}
-
-type ERepoStatus int
-
-const (
-	RepoOK ERepoStatus = iota
-	RepoKO
-	RepoExist
-)
-
-type EAssetFormat int
-
-const (
-	BinaryFormat EAssetFormat = iota
-	TarballFormat
-	TargzipFormat
-	ZipFormat
-)
-
-type RepoStatus struct {
-	Repo   *Repository
-	Status ERepoStatus
-	Format EAssetFormat
-	Asset  string
-	Url    string
-}
-
-type ArchInfo struct {
-	desired   *[]string
-	undesired []*[]string
+type FetchOptions struct {
+	Update  bool
+	Tags    []string
+	Verbose bool
+	DryRun  bool
+	// Check makes a dry run check that selected assets can be downloaded
+	Check       bool
+	Libc        string
+	Force       bool
+	Proxy       string
+	Concurrency int
+	TargetDir   string
+	Yes         bool
+	As          string
+	Since       time.Duration
+	MaxSize     int64
+	Reselect    bool
+	Output      string
+	Quiet       bool
+	// Only one of them may be set
+	OnlyMissing   bool
+	OnlyInstalled bool
+	NoCreate      bool
+	Porcelain     bool
+	// JSON prints what became of each repository as JSON, other output
+	// going to stderr
+	JSON          bool
+	Verify        bool
+	Prerelease    bool
+	ReleaseOffset int
+	AllowAppImage bool
+	NoChecksum    bool
+	// Locked installs the assets recorded in the lockfile, rather than
+	// resolve them
+	Locked bool
+	// Only one of them may be set
+	UtilsOnly bool
+	NoUtils   bool
+	Asset     string
+	RateLimit int64
+	// Platform to fetch for, rather than the host's
+	OS   string
+	Arch string
+	// Interactive lets the user pick the commands to fetch
+	Interactive bool
 }
 
 var (
 	VERSION = "0.0.9"
 
-	// This list is sorted from least desirable to most desirable
-	Amd64Arch = []string{"", "amd64", "x86_64", "musl"}
-	Arm64Arch = []string{"", "arm", "arm64", "aarch64"}
-	ArchEquiv = map[string]ArchInfo{
-		"amd64": ArchInfo{desired: &Amd64Arch, undesired: []*[]string{&Arm64Arch}},
-		"arm64": ArchInfo{desired: &Arm64Arch, undesired: []*[]string{&Amd64Arch}},
-	}
-	OSEquiv = map[string][]string{
-		"darwin": {"darwin", "macos", "osx"},
-	}
 	errorStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("#FF0000"))
 	warningStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#FFFF00"))
 	okStyle      = lipgloss.NewStyle().Foreground(lipgloss.Color("#00FF00"))
 )
 
 func main() {
+	gogo.UserAgent = "gogo/" + VERSION
 	if len(os.Args) < 2 {
-		fmt.Printf("gogo v%s (https://github.com/fusion/gogo)\n\n", VERSION)
-		fmt.Printf("Usage: %s <action> [-config <config-file>] [-update]\n\nAvailable actions:\n", os.Args[0])
-		fmt.Println("  list                  list available commands")
-		fmt.Println("  refresh               refresh list of available commands")
-		fmt.Println("  tags                  display all tags")
-		fmt.Println("  fetch <argument>      fetch one or some or all commands")
-		fmt.Println("                        (can be author/repo or full GitHub URL)")
-		fmt.Println("\nFlags:")
-		fmt.Println("  -config <config-file> path to a configuration file or directory")
-		fmt.Println("  -update               update commands if already installed")
-		fmt.Println("  -tags                 filter by tags")
-		fmt.Println("  -verbose              detailed output")
-		fmt.Println("  -dry-run              do not actually install commands")
-		fmt.Println("\nFetch argument syntax:")
-		fmt.Println("  <command>             fetch command from repository")
-		fmt.Println("  <repo>                fetch command from repository")
-		fmt.Println("  <https://repo_path>   fetch command from repository")
-		fmt.Println("  @<file>               fetch commands listed in file")
+		fmt.Fprintf(stdout, "gogo v%s (https://github.com/fusion/gogo)\n\n", VERSION)
+		fmt.Fprintf(stdout, "Usage: %s <action> [-config <config-file>] [-update]\n\nAvailable actions:\n", os.Args[0])
+		fmt.Fprintln(stdout, "  list                  list available commands")
+		fmt.Fprintln(stdout, "  refresh               refresh list of available commands")
+		fmt.Fprintln(stdout, "  tags                  display all tags")
+		fmt.Fprintln(stdout, "  export                list installed commands, in the @<file> format")
+		fmt.Fprintln(stdout, "  rollback <command>    go back to the previous version of a command")
+		fmt.Fprintln(stdout, "  uninstall <command>   remove a command and everything installed with it")
+		fmt.Fprintln(stdout, "  outdated              list installed commands with a newer release")
+		fmt.Fprintln(stdout, "  search <keywords>     look for GitHub repositories with a release for this host")
+		fmt.Fprintln(stdout, "  add <owner/repo>      configure a repository from its latest release")
+		fmt.Fprintln(stdout, "  clean                 remove temporary files left by interrupted runs")
+		fmt.Fprintln(stdout, "  ratelimit             show how many GitHub API requests are left")
+		fmt.Fprintln(stdout, "  doctor                check the configuration, target directory and GitHub access")
+		fmt.Fprintln(stdout, "  config validate       check the configuration for mistakes")
+		fmt.Fprintln(stdout, "  config init           write a commented example configuration")
+		fmt.Fprintln(stdout, "  completion <shell>    write the completion script of bash, fish or zsh")
+		fmt.Fprintln(stdout, "  fetch <argument>      fetch one or some or all commands")
+		fmt.Fprintln(stdout, "                        (can be author/repo or full GitHub URL)")
+		fmt.Fprintln(stdout, "\nFlags:")
+		fmt.Fprintln(stdout, "  -config <config-file> path to a configuration file or directory")
+		fmt.Fprintln(stdout, "                        (or several, separated by commas)")
+		fmt.Fprintln(stdout, "  -update               update commands if already installed")
+		fmt.Fprintln(stdout, "  -tags                 filter by tags (with tags: show tags used along with them)")
+		fmt.Fprintln(stdout, "  -plain                tab-separated output for list and tags")
+		fmt.Fprintln(stdout, "  -output <format>      with list, tags and fetch: text (default) or json")
+		fmt.Fprintln(stdout, "  -verbose              detailed output")
+		fmt.Fprintln(stdout, "  -dry-run              do not actually install commands")
+		fmt.Fprintln(stdout, "  -check                dry run, checking that selected assets can be downloaded")
+		fmt.Fprintln(stdout, "  -libc <glibc|musl>    preferred libc for linux assets (default: detected)")
+		fmt.Fprintln(stdout, "  -force                install assets even if built for another platform,")
+		fmt.Fprintln(stdout, "                        or over the running gogo (with config init: overwrite)")
+		fmt.Fprintln(stdout, "  -proxy <url>          proxy to use instead of HTTP(S)_PROXY")
+		fmt.Fprintln(stdout, "  -concurrency <n>      number of simultaneous downloads (default: 4)")
+		fmt.Fprintln(stdout, "  -target <dir>         install to this directory instead of the configured one")
+		fmt.Fprintln(stdout, "  -yes                  overwrite existing commands with -update without asking")
+		fmt.Fprintln(stdout, "  -as <command>         name of the command fetched from a file URL")
+		fmt.Fprintln(stdout, "  -all                  fetch all commands (same as no fetch argument)")
+		fmt.Fprintln(stdout, "  -since <duration>     only fetch commands released recently (e.g. 24h, 7d)")
+		fmt.Fprintln(stdout, "  -max-size <size>      refuse to install larger files (default: 2GiB)")
+		fmt.Fprintln(stdout, "  -reselect             select assets again rather than reuse previous choices")
+		fmt.Fprintln(stdout, "  -o <path>             write a single fetched command to this file")
+		fmt.Fprintln(stdout, "  -i                    pick the commands to fetch from a list, filtered by typing")
+		fmt.Fprintln(stdout, "  -quiet                no live progress while checking repositories and downloading")
+		fmt.Fprintln(stdout, "  -only-missing         only fetch commands that are not installed yet")
+		fmt.Fprintln(stdout, "  -only-installed       only fetch commands that are already installed")
+		fmt.Fprintln(stdout, "  -no-create            fail rather than create a missing target directory")
+		fmt.Fprintln(stdout, "  -porcelain            print tab-separated fetch events, other output going to stderr")
+		fmt.Fprintln(stdout, "  -verify               run installed commands once to check they work on this host")
+		fmt.Fprintln(stdout, "  -prerelease           install from the most recent release, even a prerelease")
+		fmt.Fprintln(stdout, "  -asset <name>         install this asset of the release rather than select one")
+		fmt.Fprintln(stdout, "  -release-offset <n>   install the release n releases older than the newest")
+		fmt.Fprintln(stdout, "  -allow-appimage       install an AppImage when no other asset fits")
+		fmt.Fprintln(stdout, "  -no-checksum          install assets without verifying their published checksum")
+		fmt.Fprintln(stdout, "  -locked               install exactly the assets recorded in gogo.lock")
+		fmt.Fprintln(stdout, "  -utils-only           only install utils and completions, leaving commands alone")
+		fmt.Fprintln(stdout, "  -no-utils             only install commands, without their utils and completions")
+		fmt.Fprintln(stdout, "  -rate-limit <size>    overall download rate limit per second (e.g. 2MiB)")
+		fmt.Fprintln(stdout, "  -os <os>              fetch commands for this OS rather than the host's")
+		fmt.Fprintln(stdout, "  -arch <arch>          fetch commands for this architecture rather than the host's")
+		fmt.Fprintln(stdout, "  -sort <order>         with list, sort by name, tag, installed or updated")
+		fmt.Fprintln(stdout, "  -reverse              with list, reverse the order")
+		fmt.Fprintln(stdout, "  -show-disabled        with list, also list disabled commands")
+		fmt.Fprintln(stdout, "  -from <owner/repo>    with refresh, refresh from this catalog only")
+		fmt.Fprintln(stdout, "  -asset <name>         with refresh -from, the catalog's asset (default: config.tgz)")
+		fmt.Fprintln(stdout, "  -older-than <age>     with clean, only remove older files (default: 1h)")
+		fmt.Fprintln(stdout, "  -limit <n>            with search, how many repositories to look at (default: 10)")
+		fmt.Fprintln(stdout, "  -new-file             with add, write a new file in the configuration directory")
+		fmt.Fprintln(stdout, "  -configured           export all configured commands rather than installed ones")
+		fmt.Fprintln(stdout, "  -versions             export the installed version of each command")
+		fmt.Fprintln(stdout, "\nFetch argument syntax:")
+		fmt.Fprintln(stdout, "  <command>             fetch command from repository")
+		fmt.Fprintln(stdout, "  <repo>                fetch command from repository")
+		fmt.Fprintln(stdout, "  <https://repo_path>   fetch command from repository")
+		fmt.Fprintln(stdout, "  <https://file> -as <command>")
+		fmt.Fprintln(stdout, "                        fetch command from a file, archived or not")
+		fmt.Fprintln(stdout, "  @<file>               fetch commands listed in file")
+		fmt.Fprintln(stdout, "  @<https://list>       fetch commands listed in a file served over HTTP(S)")
+		fmt.Fprintln(stdout, "  - or @-               fetch commands listed on standard input")
 		os.Exit(1)
 	}
 	command := os.Args[1]
@@ -132,75 +170,346 @@ func main() {
 
 	listCmd := flag.NewFlagSet("list", flag.ExitOnError)
 	listConfigPath := listCmd.String("config", "", "Path to the TOML configuration file")
-	listTags := listCmd.String("tags", "", "Filter by tags")
+	listTags := listCmd.String("tags", "", "Filter by tags, overriding filter.default_tags (\"all\" shows everything)")
+	listPlain := listCmd.Bool("plain", false, "Tab-separated output, for scripts")
+	listOutput := listCmd.String("output", "text", "Output format: text or json")
+	listSort := listCmd.String("sort", "name", "Sort by name, tag, installed (installed first) or updated (latest release first)")
+	listReverse := listCmd.Bool("reverse", false, "Reverse the order")
+	listShowDisabled := listCmd.Bool("show-disabled", false, "Also list disabled commands")
 	refreshCmd := flag.NewFlagSet("refresh", flag.ExitOnError)
 	refreshConfigPath := refreshCmd.String("config", "", "Path to the TOML configuration file")
+	refreshProxy := refreshCmd.String("proxy", "", "Proxy URL, overriding HTTP(S)_PROXY")
+	refreshFrom := refreshCmd.String("from", "", "Refresh from this catalog repository only, as owner/repo")
+	refreshAsset := refreshCmd.String("asset", "", "With -from, the catalog's release asset (default: config.tgz)")
 	tagsCmd := flag.NewFlagSet("tags", flag.ExitOnError)
 	tagsConfigPath := tagsCmd.String("config", "", "Path to the TOML configuration file")
+	tagsTags := tagsCmd.String("tags", "", "Only show tags used along with these tags")
+	tagsPlain := tagsCmd.Bool("plain", false, "Tab-separated output, for scripts")
+	tagsOutput := tagsCmd.String("output", "text", "Output format: text or json")
+	exportCmd := flag.NewFlagSet("export", flag.ExitOnError)
+	exportConfigPath := exportCmd.String("config", "", "Path to the TOML configuration file")
+	exportConfigured := exportCmd.Bool("configured", false, "Export all configured commands, installed or not")
+	exportVersions := exportCmd.Bool("versions", false, "Pin commands to their installed version")
+	ratelimitCmd := flag.NewFlagSet("ratelimit", flag.ExitOnError)
+	ratelimitConfigPath := ratelimitCmd.String("config", "", "Path to the TOML configuration file")
+	ratelimitProxy := ratelimitCmd.String("proxy", "", "Proxy URL, overriding HTTP(S)_PROXY")
+	doctorCmd := flag.NewFlagSet("doctor", flag.ExitOnError)
+	doctorConfigPath := doctorCmd.String("config", "", "Path to the TOML configuration file")
+	doctorProxy := doctorCmd.String("proxy", "", "Proxy URL, overriding HTTP(S)_PROXY")
+	cleanCmd := flag.NewFlagSet("clean", flag.ExitOnError)
+	cleanOlderThan := cleanCmd.String("older-than", "1h", "Only remove directories older than this (e.g. 1h, 7d)")
+	outdatedCmd := flag.NewFlagSet("outdated", flag.ExitOnError)
+	outdatedConfigPath := outdatedCmd.String("config", "", "Path to the TOML configuration file")
+	outdatedProxy := outdatedCmd.String("proxy", "", "Proxy URL, overriding HTTP(S)_PROXY")
+	outdatedPlain := outdatedCmd.Bool("plain", false, "Tab-separated output, for scripts")
+	rollbackCmd := flag.NewFlagSet("rollback", flag.ExitOnError)
+	rollbackConfigPath := rollbackCmd.String("config", "", "Path to the TOML configuration file")
+	searchCmd := flag.NewFlagSet("search", flag.ExitOnError)
+	searchConfigPath := searchCmd.String("config", "", "Path to the TOML configuration file")
+	searchProxy := searchCmd.String("proxy", "", "Proxy URL, overriding HTTP(S)_PROXY")
+	searchPlain := searchCmd.Bool("plain", false, "Tab-separated output, for scripts")
+	searchLimit := searchCmd.Int("limit", 10, "How many repositories to look at, most starred first")
+	addCmd := flag.NewFlagSet("add", flag.ExitOnError)
+	addConfigPath := addCmd.String("config", "", "Path to the TOML configuration file or directory to add to")
+	addProxy := addCmd.String("proxy", "", "Proxy URL, overriding HTTP(S)_PROXY")
+	addTags := addCmd.String("tags", "", "Tags of the command")
+	addDryRun := addCmd.Bool("dry-run", false, "Print the configuration rather than add it")
+	addNewFile := addCmd.Bool("new-file", false, "Write the configuration to a new file in the configuration directory")
+	uninstallCmd := flag.NewFlagSet("uninstall", flag.ExitOnError)
+	uninstallConfigPath := uninstallCmd.String("config", "", "Path to the TOML configuration file")
+	configCmd := flag.NewFlagSet("config", flag.ExitOnError)
+	configConfigPath := configCmd.String("config", "", "Path to the TOML configuration file")
+	configForce := configCmd.Bool("force", false, "With init, overwrite an existing configuration file")
 	fetchCmd := flag.NewFlagSet("fetch", flag.ExitOnError)
 	fetchConfigPath := fetchCmd.String("config", "", "Path to the TOML configuration file")
 	fetchUpdate := fetchCmd.Bool("update", false, "Update commands if already installed")
-	fetchTags := fetchCmd.String("tags", "", "Filter by tags")
+	fetchTags := fetchCmd.String("tags", "", "Filter by tags, overriding filter.default_tags (\"all\" fetches everything)")
 	fetchVerbose := fetchCmd.Bool("verbose", false, "Detailed output")
 	fetchDryRun := fetchCmd.Bool("dry-run", false, "Do not actually install commands")
+	fetchCheck := fetchCmd.Bool("check", false, "Dry run, checking that selected assets can be downloaded")
+	fetchLibc := fetchCmd.String("libc", "", "Preferred libc for linux assets (glibc or musl)")
+	fetchForce := fetchCmd.Bool("force", false, "Install assets even if built for another platform, or over the running gogo")
+	fetchProxy := fetchCmd.String("proxy", "", "Proxy URL, overriding HTTP(S)_PROXY")
+	fetchConcurrency := fetchCmd.Int("concurrency", 4, "Number of simultaneous downloads")
+	fetchTarget := fetchCmd.String("target", "", "Install directory, overriding paths.targetdir")
+	fetchYes := fetchCmd.Bool("yes", false, "Overwrite existing commands without asking")
+	fetchAs := fetchCmd.String("as", "", "Command name when fetching from a file URL")
+	fetchAll := fetchCmd.Bool("all", false, "Fetch all configured commands")
+	fetchSince := fetchCmd.String("since", "", "Only fetch commands released within this duration (e.g. 24h, 7d)")
+	fetchReselect := fetchCmd.Bool("reselect", false, "Select assets again rather than reuse the previous choice")
+	fetchMaxSize := fetchCmd.String("max-size", "2GiB", "Largest file to install (e.g. 500MiB, 2GiB)")
+	fetchOutput := fetchCmd.String("o", "", "Write the command to this file, rather than to the target directory")
+	fetchQuiet := fetchCmd.Bool("quiet", false, "No live progress while checking repositories and downloading")
+	fetchOnlyMissing := fetchCmd.Bool("only-missing", false, "Only fetch commands that are not installed")
+	fetchOnlyInstalled := fetchCmd.Bool("only-installed", false, "Only fetch commands that are already installed")
+	fetchNoCreate := fetchCmd.Bool("no-create", false, "Fail rather than create a missing target directory")
+	fetchOS := fetchCmd.String("os", "", "Fetch commands for this OS rather than the host's (e.g. linux, darwin, windows)")
+	fetchArch := fetchCmd.String("arch", "", "Fetch commands for this architecture rather than the host's (e.g. amd64, arm64)")
+	fetchRateLimit := fetchCmd.String("rate-limit", "", "Overall download rate limit per second (e.g. 2MiB)")
+	fetchAsset := fetchCmd.String("asset", "", "Install this asset of the release, rather than select one")
+	fetchReleaseOffset := fetchCmd.Int("release-offset", 0, "Install the release this many releases older than the newest (1: the previous one)")
+	fetchAllowAppImage := fetchCmd.Bool("allow-appimage", false, "Install an AppImage when no other asset fits")
+	fetchNoChecksum := fetchCmd.Bool("no-checksum", false, "Install assets without verifying their published checksum")
+	fetchLocked := fetchCmd.Bool("locked", false, "Install exactly the assets recorded in gogo.lock, along with the configuration")
+	fetchUtilsOnly := fetchCmd.Bool("utils-only", false, "Only install utils and completions, leaving installed commands alone")
+	fetchNoUtils := fetchCmd.Bool("no-utils", false, "Only install commands, without their utils and completions")
+	fetchPrerelease := fetchCmd.Bool("prerelease", false, "Install from the most recent release, even if it is a prerelease")
+	fetchVerify := fetchCmd.Bool("verify", false, "Run installed commands once to make sure they work on this host")
+	fetchPorcelain := fetchCmd.Bool("porcelain", false, "Print tab-separated events on stdout, other output going to stderr")
+	fetchOutputFormat := fetchCmd.String("output", "text", "Output format: text, or json to print results on stdout, other output going to stderr")
+	fetchInteractive := fetchCmd.Bool("i", false, "Pick the commands to fetch from a list, filtered by typing")
 
 	switch command {
 	case "list":
 		listCmd.Parse(args)
-		doList(configPath(*listConfigPath), expandTags(*listTags))
+		doList(configPath(*listConfigPath), flagTags(listCmd, *listTags), *listPlain, jsonOutput(*listOutput, *listPlain), *listSort, *listReverse, *listShowDisabled)
 	case "refresh":
 		refreshCmd.Parse(args)
-		doRefresh(configPath(*refreshConfigPath))
+		if *refreshAsset != "" && *refreshFrom == "" {
+			fmt.Fprintf(stdout, "-asset can only be used with -from\n")
+			os.Exit(1)
+		}
+		doRefresh(configPath(*refreshConfigPath), *refreshProxy, *refreshFrom, *refreshAsset)
 	case "tags":
 		tagsCmd.Parse(args)
-		doTags(configPath(*tagsConfigPath))
+		doTags(configPath(*tagsConfigPath), expandTags(*tagsTags), *tagsPlain, jsonOutput(*tagsOutput, *tagsPlain))
+	case "export":
+		exportCmd.Parse(args)
+		doExport(configPath(*exportConfigPath), *exportConfigured, *exportVersions)
+	case "outdated":
+		outdatedCmd.Parse(args)
+		doOutdated(configPath(*outdatedConfigPath), *outdatedProxy, *outdatedPlain)
+	case "ratelimit":
+		ratelimitCmd.Parse(args)
+		doRateLimit(configPath(*ratelimitConfigPath), *ratelimitProxy)
+	case "doctor":
+		doctorCmd.Parse(args)
+		doDoctor(configPath(*doctorConfigPath), *doctorProxy)
+	case "clean":
+		cleanCmd.Parse(args)
+		olderThan, err := parseSince(*cleanOlderThan)
+		if err != nil {
+			fmt.Fprintf(stdout, "Invalid -older-than: %v\n", err)
+			os.Exit(1)
+		}
+		doClean(olderThan)
+	case "rollback":
+		if len(args) == 0 || strings.HasPrefix(args[0], "-") {
+			fmt.Fprintf(stdout, "Usage: %s rollback <command> [-config <config-file>]\n", os.Args[0])
+			os.Exit(1)
+		}
+		rollbackCmd.Parse(args[1:])
+		doRollback(configPath(*rollbackConfigPath), args[0])
+	case "search":
+		// Keywords come first, flags after them
+		i := slices.IndexFunc(args, func(arg string) bool { return strings.HasPrefix(arg, "-") })
+		if i < 0 {
+			i = len(args)
+		}
+		searchCmd.Parse(args[i:])
+		keywords := append(args[:i:i], searchCmd.Args()...)
+		if len(keywords) == 0 {
+			fmt.Fprintf(stdout, "Usage: %s search <keywords> [-config <config-file>] [-limit <n>] [-plain]\n", os.Args[0])
+			os.Exit(1)
+		}
+		if *searchLimit < 1 || *searchLimit > 100 {
+			fmt.Fprintf(stdout, "Invalid -limit: %d (expected 1 to 100)\n", *searchLimit)
+			os.Exit(1)
+		}
+		doSearch(configPath(*searchConfigPath), keywords, *searchProxy, *searchLimit, *searchPlain)
+	case "add":
+		if len(args) == 0 || strings.HasPrefix(args[0], "-") {
+			fmt.Fprintf(stdout, "Usage: %s add <owner/repo> [-config <config-file>] [-tags <tags>] [-dry-run] [-new-file]\n", os.Args[0])
+			os.Exit(1)
+		}
+		addCmd.Parse(args[1:])
+		doAdd(configPath(*addConfigPath), args[0], *addProxy, expandTags(*addTags), *addDryRun, *addNewFile)
+	case "completion":
+		if len(args) != 1 || !slices.Contains(completionShells, args[0]) {
+			fmt.Fprintf(stdout, "Usage: %s completion %s\n", os.Args[0], strings.Join(completionShells, "|"))
+			os.Exit(1)
+		}
+		doCompletion(os.Stdout, args[0], []completionCommand{
+			{"list", "list available commands", listCmd, nil},
+			{"refresh", "refresh list of available commands", refreshCmd, nil},
+			{"tags", "display all tags", tagsCmd, nil},
+			{"export", "list installed commands, in the @<file> format", exportCmd, nil},
+			{"rollback", "go back to the previous version of a command", rollbackCmd, []string{configuredCommands}},
+			{"uninstall", "remove a command and everything installed with it", uninstallCmd, []string{configuredCommands}},
+			{"outdated", "list installed commands with a newer release", outdatedCmd, nil},
+			{"search", "look for GitHub repositories with a release for this host", searchCmd, nil},
+			{"add", "configure a repository from its latest release", addCmd, nil},
+			{"clean", "remove temporary files left by interrupted runs", cleanCmd, nil},
+			{"ratelimit", "show how many GitHub API requests are left", ratelimitCmd, nil},
+			{"doctor", "check the configuration, target directory and GitHub access", doctorCmd, nil},
+			{"config", "check the configuration, or write an example one", configCmd, []string{"validate", "init"}},
+			{"completion", "write the completion script of a shell", nil, completionShells},
+			{"fetch", "fetch one or some or all commands", fetchCmd, []string{configuredCommands}},
+		})
+	case "uninstall":
+		if len(args) == 0 || strings.HasPrefix(args[0], "-") {
+			fmt.Fprintf(stdout, "Usage: %s uninstall <command> [-config <config-file>]\n", os.Args[0])
+			os.Exit(1)
+		}
+		uninstallCmd.Parse(args[1:])
+		doUninstall(configPath(*uninstallConfigPath), args[0])
+	case "config":
+		if len(args) == 0 || args[0] != "validate" && args[0] != "init" {
+			fmt.Fprintf(stdout, "Usage: %s config validate|init [-config <config-file>] [-force]\n", os.Args[0])
+			os.Exit(1)
+		}
+		configCmd.Parse(args[1:])
+		if args[0] == "init" {
+			doConfigInit(*configConfigPath, *configForce)
+		} else {
+			doValidate(configPath(*configConfigPath))
+		}
 	case "fetch":
-		if strings.HasPrefix(args[0], "-") {
+		var fetchCommand *string
+		if len(args) == 0 || strings.HasPrefix(args[0], "-") && args[0] != "-" {
 			fetchCmd.Parse(args)
-			doFetch(configPath(*fetchConfigPath), *fetchUpdate, nil, expandTags(*fetchTags), *fetchVerbose, *fetchDryRun)
 		} else {
 			fetchCmd.Parse(args[1:])
-			doFetch(configPath(*fetchConfigPath), *fetchUpdate, &args[0], expandTags(*fetchTags), *fetchVerbose, *fetchDryRun)
+			fetchCommand = &args[0]
+		}
+		if *fetchAll && fetchCommand != nil {
+			fmt.Fprintf(stdout, "-all cannot be combined with a fetch argument\n")
+			os.Exit(1)
+		}
+		if *fetchOutput != "" && (fetchCommand == nil || strings.HasPrefix(*fetchCommand, "@") || *fetchCommand == "-") {
+			fmt.Fprintf(stdout, "-o can only be used to fetch a single command\n")
+			os.Exit(1)
+		}
+		if *fetchAsset != "" && (fetchCommand == nil || strings.HasPrefix(*fetchCommand, "@") || *fetchCommand == "-") {
+			fmt.Fprintf(stdout, "-asset can only be used to fetch a single command\n")
+			os.Exit(1)
+		}
+		if *fetchUtilsOnly && *fetchNoUtils {
+			fmt.Fprintf(stdout, "-utils-only and -no-utils cannot be combined\n")
+			os.Exit(1)
+		}
+		if *fetchUtilsOnly && *fetchOutput != "" {
+			fmt.Fprintf(stdout, "-utils-only cannot be combined with -o, which only installs the command\n")
+			os.Exit(1)
+		}
+		if *fetchOnlyMissing && *fetchOnlyInstalled {
+			fmt.Fprintf(stdout, "-only-missing and -only-installed cannot be combined\n")
+			os.Exit(1)
+		}
+		if *fetchPorcelain && *fetchOutputFormat == "json" {
+			fmt.Fprintf(stdout, "-porcelain and -output json cannot be combined\n")
+			os.Exit(1)
+		}
+		if *fetchInteractive && (fetchCommand != nil || *fetchAll || *fetchPorcelain || *fetchOutputFormat == "json" || *fetchOutput != "") {
+			fmt.Fprintf(stdout, "-i cannot be combined with a fetch argument, -all, -porcelain, -output json or -o\n")
+			os.Exit(1)
+		}
+		if *fetchLocked && (*fetchAsset != "" || *fetchPrerelease || *fetchReleaseOffset != 0 || *fetchReselect) {
+			fmt.Fprintf(stdout, "-locked cannot be combined with -asset, -prerelease, -release-offset or -reselect\n")
+			os.Exit(1)
+		}
+		if *fetchReleaseOffset < 0 {
+			fmt.Fprintf(stdout, "Invalid -release-offset: %d is negative\n", *fetchReleaseOffset)
+			os.Exit(1)
 		}
+		since, err := parseSince(*fetchSince)
+		if err != nil {
+			fmt.Fprintf(stdout, "Invalid -since: %v\n", err)
+			os.Exit(1)
+		}
+		maxSize, err := parseSize(*fetchMaxSize)
+		if err != nil {
+			fmt.Fprintf(stdout, "Invalid -max-size: %v\n", err)
+			os.Exit(1)
+		}
+		var rateLimit int64
+		if *fetchRateLimit != "" {
+			if rateLimit, err = parseSize(*fetchRateLimit); err != nil {
+				fmt.Fprintf(stdout, "Invalid -rate-limit: %v\n", err)
+				os.Exit(1)
+			}
+		}
+		doFetch(configPath(*fetchConfigPath), fetchCommand, FetchOptions{
+			Update:        *fetchUpdate,
+			Tags:          flagTags(fetchCmd, *fetchTags),
+			Verbose:       *fetchVerbose,
+			DryRun:        *fetchDryRun || *fetchCheck,
+			Check:         *fetchCheck,
+			Libc:          *fetchLibc,
+			Force:         *fetchForce,
+			Proxy:         *fetchProxy,
+			Concurrency:   *fetchConcurrency,
+			TargetDir:     *fetchTarget,
+			Yes:           *fetchYes,
+			As:            *fetchAs,
+			Since:         since,
+			MaxSize:       maxSize,
+			Reselect:      *fetchReselect,
+			Output:        *fetchOutput,
+			Quiet:         *fetchQuiet,
+			OnlyMissing:   *fetchOnlyMissing,
+			OnlyInstalled: *fetchOnlyInstalled,
+			NoCreate:      *fetchNoCreate,
+			Porcelain:     *fetchPorcelain,
+			JSON:          jsonOutput(*fetchOutputFormat, false),
+			Verify:        *fetchVerify,
+			Prerelease:    *fetchPrerelease,
+			ReleaseOffset: *fetchReleaseOffset,
+			AllowAppImage: *fetchAllowAppImage,
+			NoChecksum:    *fetchNoChecksum,
+			Locked:        *fetchLocked,
+			UtilsOnly:     *fetchUtilsOnly,
+			NoUtils:       *fetchNoUtils,
+			Asset:         *fetchAsset,
+			Interactive:   *fetchInteractive,
+			RateLimit:     rateLimit,
+			OS:            *fetchOS,
+			Arch:          *fetchArch,
+		})
 	default:
-		fmt.Printf("Unknown command: %s\n", command)
+		fmt.Fprintf(stdout, "Unknown command: %s\n", command)
+		os.Exit(1)
+	}
+}
+
+// userConfigPath returns the default configuration directory.
+func userConfigPath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		fmt.Fprintf(stdout, "Error getting user config directory: %v\n", err)
 		os.Exit(1)
 	}
+	return filepath.Join(dir, "gogo")
 }
 
 func configPath(configPath string) string {
 	if configPath == "" {
-		dir, dirErr := os.UserConfigDir()
-		if dirErr != nil {
-			fmt.Printf("Error getting user config directory: %v\n", dirErr)
-			os.Exit(1)
-		}
-		userPath := filepath.Join(dir, "gogo")
+		userPath := userConfigPath()
 		if _, err := os.Stat(userPath); os.IsNotExist(err) {
 			if err := os.MkdirAll(userPath, 0755); err != nil {
-				fmt.Printf("Error creating config directory: %v\n", err)
+				fmt.Fprintf(stdout, "Error creating config directory: %v\n", err)
 				os.Exit(1)
 			}
 		}
-		configFile := filepath.Join(dir, "gogo", "config.toml")
+		configFile := filepath.Join(userPath, "config.toml")
 		if _, err := os.Stat(configFile); os.IsNotExist(err) {
 			f, err := os.Create(configFile)
 			if err != nil {
-				fmt.Printf("Error creating config file: %v\n", err)
+				fmt.Fprintf(stdout, "Error creating config file: %v\n", err)
 				os.Exit(1)
 			}
 			defer f.Close()
 
-			defaultConfig := Config{Auth: Auth{Token: "github_<your-token>"}, Paths: Paths{TargetDir: "~/.local/bin"}}
+			defaultConfig := gogo.Config{Auth: gogo.Auth{Token: gogo.PlaceholderToken}, Paths: gogo.Paths{TargetDir: "~/.local/bin"}}
 			encoder := toml.NewEncoder(f)
 			if err := encoder.Encode(defaultConfig); err != nil {
-				fmt.Printf("Error writing default config: %v\n", err)
+				fmt.Fprintf(stdout, "Error writing default config: %v\n", err)
 				os.Exit(1)
 			}
-			fmt.Printf(okStyle.Render("Created default configuration in %s (binaries stored in ~/.local/bin)"), userPath)
-			fmt.Printf("\nIf you wish to use a github token, add the following to config.toml:\n\n")
-			fmt.Printf("[auth]\n")
-			fmt.Printf("token = \"<your-token>\"\n\n")
+			fmt.Fprintf(stdout, okStyle.Render("Created default configuration in %s (binaries stored in ~/.local/bin)"), userPath)
+			fmt.Fprintf(stdout, "\nIf you wish to use a github token, add the following to config.toml:\n\n")
+			fmt.Fprintf(stdout, "[auth]\n")
+			fmt.Fprintf(stdout, "token = \"<your-token>\"\n\n")
 		}
 		return userPath
 	}
@@ -208,18 +517,108 @@ func configPath(configPath string) string {
 }
 
 func expandTags(tags string) []string {
-	if tags == "" {
-		return []string{}
+	normalized, _ := gogo.NormalizeTags(strings.Split(tags, ","))
+	return append([]string{}, normalized...)
+}
+
+// flagTags expands the -tags flag, or returns nil if it was not given so
+// that the configured default tags apply.
+func flagTags(flagSet *flag.FlagSet, tags string) []string {
+	given := false
+	flagSet.Visit(func(f *flag.Flag) {
+		given = given || f.Name == "tags"
+	})
+	if !given {
+		return nil
+	}
+	return expandTags(tags)
+}
+
+// jsonOutput tells whether an -output flag asks for JSON, exiting if it asks
+// for an unknown format, or for JSON along with -plain.
+func jsonOutput(format string, plain bool) bool {
+	switch format {
+	case "text":
+		return false
+	case "json":
+		if plain {
+			fmt.Fprintf(stdout, "-plain and -output json cannot be combined\n")
+			os.Exit(1)
+		}
+		return true
+	}
+	fmt.Fprintf(stdout, "Invalid -output: %s (expected text or json)\n", format)
+	os.Exit(1)
+	return false
+}
+
+// printJSON prints v to out as indented JSON.
+func printJSON(out io.Writer, v any) {
+	encoder := json.NewEncoder(out)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(v); err != nil {
+		fmt.Fprintf(os.Stderr, "Error encoding JSON: %v\n", err)
+		os.Exit(1)
 	}
-	return strings.Split(tags, ",")
 }
 
-func doList(configPath string, tags []string) {
-	config, err := readConfig(configPath)
+// listEntry is a repository, as listed with -output json.
+type listEntry struct {
+	File        string   `json:"file"`
+	Repository  string   `json:"repository"`
+	Description string   `json:"description"`
+	Tags        []string `json:"tags"`
+	Disabled    bool     `json:"disabled"`
+}
+
+func doList(configPath string, tags []string, plain bool, asJSON bool, sortKey string, reverse bool, showDisabled bool) {
+	config, err := gogo.ReadConfig(configPath)
 	if err != nil {
-		fmt.Printf("Error reading config: %v\n", err)
+		fmt.Fprintf(stdout, "Error reading config: %v\n", err)
 		os.Exit(1)
 	}
+	if !showDisabled {
+		config.Repositories = slices.DeleteFunc(config.Repositories, func(repo gogo.Repository) bool {
+			return repo.Disabled
+		})
+	}
+	tags = config.FilterTags(tags)
+	order, err := listOrder(&config, sortKey)
+	if err != nil {
+		fmt.Fprintf(stdout, "Invalid -sort: %v\n", err)
+		os.Exit(1)
+	}
+	if order != nil {
+		config.Repositories.SortBy(order)
+	}
+	if reverse {
+		slices.Reverse(config.Repositories)
+	}
+
+	if plain {
+		for _, repo := range config.Repositories {
+			if len(tags) > 0 && !containsTag(repo.Tags, tags) {
+				continue
+			}
+			fmt.Fprintf(stdout, "%s\t%s\t%s\n", repo.File, repo.Comment, strings.Join(repo.Tags, ","))
+		}
+		return
+	}
+	if asJSON {
+		entries := []listEntry{}
+		for _, repo := range config.Repositories {
+			if len(tags) > 0 && !containsTag(repo.Tags, tags) {
+				continue
+			}
+			entry := listEntry{File: repo.File, Repository: repo.Name, Description: repo.Comment, Tags: repo.Tags, Disabled: repo.Disabled}
+			if entry.Tags == nil {
+				entry.Tags = []string{}
+			}
+			entries = append(entries, entry)
+		}
+		printJSON(stdout, entries)
+		return
+	}
 
 	t := table.New().
 		Border(lipgloss.NormalBorder()).
@@ -240,76 +639,102 @@ func doList(configPath string, tags []string) {
 		if len(tags) > 0 && !containsTag(repo.Tags, tags) {
 			continue
 		}
-		t.Row(repo.File, repo.Comment, strings.Join(repo.Tags, ", "))
+		file := repo.File
+		if repo.Disabled {
+			file += " (disabled)"
+		}
+		t.Row(file, repo.Comment, strings.Join(repo.Tags, ", "))
 	}
-	fmt.Println(t)
+	fmt.Fprintln(stdout, t)
 }
 
-func doRefresh(configPath string) {
-	config, err := readConfig(configPath)
+func doRefresh(configPath string, proxy string, from string, asset string) {
+	config, err := gogo.ReadConfig(configPath)
 	if err != nil {
-		fmt.Printf("Error reading config: %v\n", err)
+		fmt.Fprintf(stdout, "Error reading config: %v\n", err)
 		os.Exit(1)
 	}
-	url := fmt.Sprintf("https://api.github.com/repos/fusion/gogo/releases/latest")
-	req, _ := http.NewRequest("GET", url, nil)
-	req.Header.Set("Accept", "application/vnd.github+json")
-	req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
-	if config.Auth.Token != "" {
-		req.Header.Set("Authorization", fmt.Sprintf("token %s", config.Auth.Token))
+	if proxy != "" {
+		config.Network.Proxy = proxy
 	}
-	client := &http.Client{}
-	resp, err := client.Do(req)
+	resolveToken(&config.Auth, config.Network)
+	client, err := gogo.NewClient(config.Network, config.Auth.Token)
 	if err != nil {
-		fmt.Printf("  - Error fetching gogo releases: %v\n", err)
-		os.Exit(1)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		fmt.Printf("  - Non-OK HTTP status: %s\n", resp.Status)
+		fmt.Fprintf(stdout, "Error configuring network: %v\n", err)
 		os.Exit(1)
 	}
-
-	var release struct {
-		Assets []ReleaseAsset `json:"assets"`
+	catalogs := append([]gogo.Catalog{gogo.DefaultCatalog}, config.Catalogs...)
+	if from != "" {
+		catalogs = []gogo.Catalog{{Repo: gogo.NormalizeName(from), Asset: asset}}
+	}
+	// With several configuration paths, the first one holds the packages list
+	targetPath, _, _ := strings.Cut(configPath, ",")
+	failed := false
+	for _, catalog := range catalogs {
+		fmt.Fprintf(stdout, "Refreshing from %s\n", catalog.Repo)
+		extracted, err := client.RefreshCatalog(catalog, targetPath)
+		for _, path := range extracted {
+			fmt.Fprintf(stdout, "  - Extracting to %s\n", path)
+		}
+		if err != nil {
+			fmt.Fprintf(stdout, "  - Error refreshing %s: %v\n", catalog.Repo, err)
+			failed = true
+		}
 	}
-	if err := json.NewDecoder(resp.Body).Decode(&release); err != nil {
-		fmt.Printf("  - Error decoding JSON: %v\n", err)
+	if failed {
 		os.Exit(1)
 	}
+}
 
-	for _, asset := range release.Assets {
-		if asset.Name != "config.tgz" {
-			continue
-		}
-		fmt.Printf("Downloading from %s\n", asset.BrowserDownloadURL)
-		resp, err := http.Get(asset.BrowserDownloadURL)
+// listOrder returns how to compare repositories to list them by sortKey.
+// They already are sorted by name.
+func listOrder(config *gogo.Config, sortKey string) (func(a, b *gogo.Repository) int, error) {
+	switch sortKey {
+	case "name":
+		return nil, nil
+	case "tag":
+		return gogo.ByTag, nil
+	case "installed":
+		targetDir, err := gogo.ExpandPath(cmp.Or(config.Paths.TargetDir, "."))
 		if err != nil {
-			fmt.Printf("  - Error fetching gogo update: %v\n", err)
-			os.Exit(1)
+			return nil, fmt.Errorf("cannot expand target directory: %v", err)
 		}
-
-		if resp.StatusCode != http.StatusOK {
-			fmt.Printf("  - Non-OK HTTP status: %s\n", resp.Status)
-			os.Exit(1)
-		}
-		if err := writeTargzipContent(configPath, resp.Body); err != nil {
-			fmt.Printf("  - Error writing extracted file: %v\n", err)
-			os.Exit(1)
+		installed := map[string]bool{}
+		for _, repo := range config.Repositories {
+			existing, checkFiles := existingFiles(&repo, targetDir)
+			installed[repo.File] = len(existing) == len(checkFiles)
 		}
-	}
+		return func(a, b *gogo.Repository) int {
+			switch {
+			case installed[a.File] && !installed[b.File]:
+				return -1
+			case installed[b.File] && !installed[a.File]:
+				return 1
+			}
+			return 0
+		}, nil
+	case "updated":
+		// Release dates are only known for repositories installed before
+		_, state := loadState()
+		return func(a, b *gogo.Repository) int {
+			return state.Repositories[b.Name].PublishedAt.Compare(state.Repositories[a.Name].PublishedAt)
+		}, nil
+	}
+	return nil, fmt.Errorf("unknown order %s (expected name, tag, installed or updated)", sortKey)
 }
 
-func doTags(configPath string) {
-	config, err := readConfig(configPath)
+func doTags(configPath string, tags []string, plain bool, asJSON bool) {
+	config, err := gogo.ReadConfig(configPath)
 	if err != nil {
-		fmt.Printf("Error reading config: %v\n", err)
+		fmt.Fprintf(stdout, "Error reading config: %v\n", err)
 		os.Exit(1)
 	}
 
 	tagSet := make(map[string]int)
 	for _, repo := range config.Repositories {
+		if len(tags) > 0 && !containsTag(repo.Tags, tags) {
+			continue
+		}
 		for _, tag := range repo.Tags {
 			if _, ok := tagSet[tag]; !ok {
 				tagSet[tag] = 0
@@ -319,10 +744,10 @@ func doTags(configPath string) {
 	}
 
 	type tagcnt struct {
-		Tag string
-		Cnt int
+		Tag string `json:"tag"`
+		Cnt int    `json:"repos"`
 	}
-	var tagSlice []tagcnt
+	tagSlice := []tagcnt{}
 	for tag, cnt := range tagSet {
 		tagSlice = append(tagSlice, tagcnt{Tag: tag, Cnt: cnt})
 	}
@@ -330,6 +755,17 @@ func doTags(configPath string) {
 		return tagSlice[i].Tag < tagSlice[j].Tag
 	})
 
+	if plain {
+		for _, tc := range tagSlice {
+			fmt.Fprintf(stdout, "%s\t%d\n", tc.Tag, tc.Cnt)
+		}
+		return
+	}
+	if asJSON {
+		printJSON(stdout, tagSlice)
+		return
+	}
+
 	t := table.New().
 		Border(lipgloss.NormalBorder()).
 		StyleFunc(
@@ -348,627 +784,1549 @@ func doTags(configPath string) {
 	for _, tc := range tagSlice {
 		t.Row(tc.Tag, fmt.Sprintf("%d", tc.Cnt))
 	}
-	fmt.Println(t)
+	fmt.Fprintln(stdout, t)
 }
 
-func doFetch(configPath string, update bool, command *string, tags []string, verbose bool, dryRun bool) {
-	hostArch := strings.ToLower(runtime.GOARCH)
-	hostOS := strings.ToLower(runtime.GOOS)
-
-	if verbose {
-		verbosePrintf("  - Host architecture: %s\n", hostArch)
-		verbosePrintf("  - Host OS: %s\n", hostOS)
-		verbosePrintf("  - Config path: %s\n", configPath)
-	}
-	config, err := readConfig(configPath)
+// doOutdated compares the release installed from each repository, as
+// recorded in the state, with its latest release.
+func doOutdated(configPath string, proxy string, plain bool) {
+	config, err := gogo.ReadConfig(configPath)
 	if err != nil {
-		fmt.Printf("Error reading config: %v\n", err)
+		fmt.Fprintf(stdout, "Error reading config: %v\n", err)
 		os.Exit(1)
 	}
-
-	if config.Paths.TargetDir == "" {
-		fmt.Printf("Target directory not set, using current directory\n")
-		config.Paths.TargetDir = "."
+	if proxy != "" {
+		config.Network.Proxy = proxy
 	}
-	config.Paths.TargetDir, err = expandPath(config.Paths.TargetDir)
+	resolveToken(&config.Auth, config.Network)
+	client, err := gogo.NewClient(config.Network, config.Auth.Token)
 	if err != nil {
-		fmt.Printf("Error expanding target directory: %v\n", err)
+		fmt.Fprintf(stdout, "Error configuring network: %v\n", err)
 		os.Exit(1)
 	}
-	if verbose {
-		verbosePrintf("  - Target dir: %s\n", config.Paths.TargetDir)
-	}
-	if err := checkTargetDir(config.Paths.TargetDir); err != nil {
-		fmt.Printf("Error checking target directory: %v\n", err)
-		os.Exit(1)
-	}
-
-	var checkedRepos *Repositories
+	_, state := loadState()
 
-	var commands []string
-	var bits []string
-	useCommandList := false
-	if command != nil {
-		if strings.HasPrefix(*command, "@") {
-			useCommandList = true
-			checkedRepos = &config.Repositories
-			filePath := strings.TrimPrefix(*command, "@")
-			if verbose {
-				verbosePrintf("  - Command list file: %s\n", filePath)
-			}
-			if file, err := os.Open(filePath); err != nil {
-				fmt.Printf("Error opening file %s: %v\n", filePath, err)
-				os.Exit(1)
-			} else {
-				defer file.Close()
-				scanner := bufio.NewScanner(file)
-				for scanner.Scan() {
-					line := strings.TrimSpace(scanner.Text())
-					if line == "" {
-						continue
-					}
-					commands = append(commands, line)
-				}
-			}
-		} else {
-			bits = strings.Split(*command, "/")
-		}
-		if !useCommandList {
-			if len(bits) > 1 {
-				// This is a repo
-				var directRepo Repository
-				if bits[0] == "https:" {
-					directRepo.Name = strings.Join(bits[3:5], "/")
-					directRepo.File = bits[4]
-				} else {
-					directRepo.Name = strings.Join(bits[0:2], "/")
-					directRepo.File = bits[1]
-				}
-				*command = directRepo.File
-				checkedRepos = &Repositories{directRepo}
-			} else {
-				checkedRepos = &config.Repositories
-			}
-			commands = append(commands, *command)
+	var names []string
+	for name, recorded := range state.Repositories {
+		// Files downloaded from a URL have no release to compare with
+		if recorded.Tag != "" {
+			names = append(names, name)
 		}
-	} else {
-		checkedRepos = &config.Repositories
-	}
-
-	if verbose {
-		verbosePrintf("  - Commands: %v\n", commands)
-		verbosePrintf("  - Tags: %v\n", tags)
 	}
-	repoStatusList := []RepoStatus{}
-
-	fmt.Printf("[Preflight]\n")
-	for _, repo := range *checkedRepos {
-		if len(commands) > 0 {
-			found := false
-			for _, v := range commands {
-				if v == repo.File {
-					found = true
-					break
-				}
-			}
-			if !found {
-				continue
-			}
-		}
-		if len(tags) > 0 && !containsTag(repo.Tags, tags) {
-			continue
-		}
-		repoStatus := RepoStatus{Repo: &repo, Status: RepoKO}
-		if !update {
-			var checkFile *string
-			if repo.Command != "" {
-				checkFile = &repo.Command
-			} else {
-				checkFile = &repo.File
-			}
-			if existFile(filepath.Join(config.Paths.TargetDir, *checkFile)) {
-				fmt.Printf("  - ignoring existing command %s (%s)\n", repo.File, *checkFile)
-				repoStatus.Status = RepoExist
-				repoStatusList = append(repoStatusList, repoStatus)
-				continue
-			}
-		}
+	sort.Slice(names, func(i, j int) bool {
+		return state.Repositories[names[i]].File < state.Repositories[names[j]].File
+	})
 
-		url := fmt.Sprintf("https://api.github.com/repos/%s/releases/latest", repo.Name)
-		req, _ := http.NewRequest("GET", url, nil)
-		req.Header.Set("Accept", "application/vnd.github+json")
-		req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
-		if config.Auth.Token != "" {
-			req.Header.Set("Authorization", fmt.Sprintf("token %s", config.Auth.Token))
+	var rows [][]string
+	var failed []string
+	for _, name := range names {
+		recorded := state.Repositories[name]
+		repo := &gogo.Repository{Name: name, File: recorded.File}
+		if i := slices.IndexFunc(config.Repositories, func(repo gogo.Repository) bool {
+			return repo.Name == name
+		}); i >= 0 {
+			repo = &config.Repositories[i]
 		}
-		client := &http.Client{}
-		resp, err := client.Do(req)
+		latest, publishedAt, err := client.LatestTag(repo)
 		if err != nil {
-			fmt.Printf("  - Error fetching releases for %s: %v\n", repo.Name, err)
-			continue
-		}
-		defer resp.Body.Close()
-
-		if resp.StatusCode != http.StatusOK {
-			fmt.Printf("  - Non-OK HTTP status: %s for %s\n", resp.Status, repo.Name)
+			failed = append(failed, fmt.Sprintf("%s: %v", recorded.File, err))
 			continue
 		}
-
-		var release struct {
-			Assets []ReleaseAsset `json:"assets"`
-		}
-		if err := json.NewDecoder(resp.Body).Decode(&release); err != nil {
-			fmt.Printf("  - Error decoding JSON for %s: %v\n", repo.Name, err)
+		if latest == recorded.Tag {
 			continue
 		}
-
-		archList, ok := ArchEquiv[hostArch]
-		if !ok {
-			archList = ArchInfo{desired: &[]string{hostArch}}
-		}
-		osList, ok := OSEquiv[hostOS]
-		if !ok {
-			osList = []string{hostOS}
-		}
-
-		var candidateAsset *ReleaseAsset
-		var candidateStrength uint8
-	assetLoop:
-		for _, asset := range release.Assets {
-			assetName := strings.ToLower(asset.Name)
-			if verbose {
-				verbosePrintf("  - Matching Asset: %s\n", assetName)
-			}
-			// following a common convention, we ignore SHA files, signatures, etc.
-			for _, ignore := range []string{".sha", ".sig", ".asc"} {
-				if strings.Contains(assetName, ignore) {
-					if verbose {
-						verbosePrintf("  - Ignoring Asset due to suffix %s\n", ignore)
-					}
-					continue assetLoop
-				}
-			}
-			for archIdx, archName := range *archList.desired {
-				if !strings.Contains(assetName, archName) {
-					if verbose {
-						verbosePrintf("  - Ignoring Asset due to not matching architecture %s\n", archName)
-					}
-					continue
-				}
-				for _, undesired := range archList.undesired {
-					for _, undesiredArch := range *undesired {
-						if undesiredArch == "" {
-							continue
-						}
-						if strings.Contains(assetName, undesiredArch) {
-							if verbose {
-								verbosePrintf("  - Ignoring Asset due to matching undesired architecture %s\n", undesiredArch)
-							}
-							continue assetLoop
-						}
-					}
-				}
-				for osIdx, os := range osList {
-					if !strings.Contains(assetName, os) {
-						if verbose {
-							verbosePrintf("  - Ignoring Asset for not matching OS %s\n", os)
-						}
-						continue
-					}
-					strength := uint8(osIdx<<4 + archIdx)
-					if strength > candidateStrength {
-						// Look for contradicting information
-						candidateStrength = strength
-						candidateAsset = &asset
-					}
-				}
-			}
-		}
-		if candidateAsset != nil {
-			fmt.Printf("  + identified Asset: %s\n", candidateAsset.Name)
-			repoStatus.Status = RepoOK
-			repoStatus.Asset = candidateAsset.Name
-			repoStatus.Url = candidateAsset.BrowserDownloadURL
-			repoStatus.Format = getAssetFormat(candidateAsset.Name)
-		}
-
-		repoStatusList = append(repoStatusList, repoStatus)
+		// How long the newer release has been available
+		days := int(time.Since(publishedAt).Hours() / 24)
+		rows = append(rows, []string{recorded.File, recorded.Tag, latest, strconv.Itoa(days)})
 	}
 
-	fmt.Printf("[Repositories]\n")
-	for _, repoStatus := range repoStatusList {
-		fmt.Printf("    repository: %s ", repoStatus.Repo.Name)
-		switch repoStatus.Status {
-		case RepoOK:
-			fmt.Println(okStyle.Render("[OK]"))
-		case RepoKO:
-			fmt.Println(errorStyle.Render("[XXX]"))
-		case RepoExist:
-			fmt.Println(warningStyle.Render("[Exist]"))
+	if plain {
+		for _, row := range rows {
+			fmt.Fprintln(stdout, strings.Join(row, "\t"))
 		}
+	} else if len(names) == 0 {
+		fmt.Fprintln(stdout, "No command installed from a release yet")
+	} else if len(rows) > 0 {
+		t := table.New().
+			Border(lipgloss.NormalBorder()).
+			StyleFunc(func(_, _ int) lipgloss.Style { return lipgloss.NewStyle().Padding(0, 1) }).
+			BorderStyle(lipgloss.NewStyle().Foreground(lipgloss.Color("99")))
+		t.Headers("Binary", "Installed", "Latest", "Days behind")
+		t.Rows(rows...)
+		fmt.Fprintln(stdout, t)
+	} else {
+		fmt.Fprintln(stdout, okStyle.Render(fmt.Sprintf("All %d commands are up to date", len(names)-len(failed))))
 	}
-	// TODO What happens if not all repositories are OK?
-	fmt.Printf("[Fetching]\n")
-	for _, repoStatus := range repoStatusList {
-		if dryRun {
-			if repoStatus.Status != RepoOK {
-				fmt.Printf("  %s %s\n", repoStatus.Repo.Name, warningStyle.Render("Dry-Run: [Ignored]"))
-				continue
-			}
-			fmt.Printf("  %s %s\n", repoStatus.Repo.Name, okStyle.Render("Dry-Run: [Fetched]"))
-			continue
-		}
-		if repoStatus.Status != RepoOK {
-			fmt.Printf("  %s %s\n", repoStatus.Repo.Name, warningStyle.Render("[Ignored]"))
-			continue
-		}
-		if err := downloadFile(repoStatus.Url, repoStatus.Format, repoStatus.Repo.File, repoStatus.Repo.Utils, config.Paths.TargetDir); err != nil {
-			fmt.Printf("  %s: %s\n", repoStatus.Repo.File, errorStyle.Render(fmt.Sprintf("[%s]", err.Error())))
-			break
-		}
-		fmt.Printf("  %s %s\n", repoStatus.Repo.Name, okStyle.Render("[Fetched]"))
+	for _, failure := range failed {
+		fmt.Fprintln(stdout, warningStyle.Render(fmt.Sprintf("Cannot check %s", failure)))
 	}
-}
-
-func containsTag(repoTags []string, tags []string) bool {
-	for _, tag := range tags {
-		for _, repoTag := range repoTags {
-			if tag == repoTag {
-				return true
-			}
-		}
+	if len(failed) > 0 {
+		os.Exit(1)
 	}
-	return false
 }
 
-func readConfig(configPath string) (Config, error) {
-	var config Config
-	fileInfo, err := os.Stat(configPath)
+// doSearch looks for repositories matching keywords with a release for this
+// host, and tells how to configure them.
+func doSearch(configPath string, keywords []string, proxy string, limit int, plain bool) {
+	config, err := gogo.ReadConfig(configPath)
+	if err != nil {
+		fmt.Fprintf(stdout, "Error reading config: %v\n", err)
+		os.Exit(1)
+	}
+	if proxy != "" {
+		config.Network.Proxy = proxy
+	}
+	resolveToken(&config.Auth, config.Network)
+	client, err := gogo.NewClient(config.Network, config.Auth.Token)
 	if err != nil {
-		return config, err
+		fmt.Fprintf(stdout, "Error configuring network: %v\n", err)
+		os.Exit(1)
+	}
+	client.Prefer = config.Platform.Prefer
+	client.Ignore = config.Platform.Ignore
+	client.Demote = config.Platform.Demote
+	config.AddAliases()
+	host := gogo.DetectHost()
+	if config.Platform.Libc != "" {
+		host.Libc = config.Platform.Libc
 	}
 
-	if fileInfo.IsDir() {
-		entries, err := os.ReadDir(configPath)
-		if err != nil {
-			return config, err
-		}
-		for _, entry := range entries {
-			if entry.IsDir() {
-				continue
-			}
-			if !strings.HasSuffix(entry.Name(), ".toml") {
-				continue
-			}
-			//fmt.Printf("Config merging %s\n", entry.Name())
-			oneConfig, err := readOneConfig(filepath.Join(configPath, entry.Name()))
-			if err != nil {
-				return config, err
-			}
-			if err := mergo.Merge(&config, oneConfig, mergo.WithAppendSlice); err != nil {
-				return config, err
-			}
-		}
-	} else {
-		config, err = readOneConfig(configPath)
-		if err != nil {
-			return config, err
+	results, err := client.Search(keywords, host, limit)
+	if err != nil {
+		fmt.Fprintf(stdout, "Error searching repositories: %v\n", err)
+		os.Exit(1)
+	}
+	if plain {
+		for _, result := range results {
+			fmt.Fprintf(stdout, "%s\t%d\t%s\t%s\n", result.Name, result.Stars, result.Tag, result.Description)
 		}
+		return
+	}
+	if len(results) == 0 {
+		fmt.Fprintf(stdout, "No repository with a release for %s/%s found\n", host.OS, host.Arch)
+		return
+	}
+	t := table.New().
+		Border(lipgloss.NormalBorder()).
+		StyleFunc(
+			func(_, col int) lipgloss.Style {
+				switch col {
+				case 1:
+					return lipgloss.NewStyle().Padding(0, 1).Align(lipgloss.Right)
+				case 3:
+					return lipgloss.NewStyle().Width(48).Padding(0, 1).Align(lipgloss.Left)
+				default:
+					return lipgloss.NewStyle().Padding(0, 1)
+				}
+			},
+		).
+		BorderStyle(lipgloss.NewStyle().Foreground(lipgloss.Color("99")))
+	t.Headers("Repository", "Stars", "Latest", "Description")
+	for _, result := range results {
+		t.Row(result.Name, strconv.Itoa(result.Stars), result.Tag, result.Description)
+	}
+	fmt.Fprintln(stdout, t)
+	fmt.Fprintf(stdout, "\nTo install one of them, add it to %s:\n", configPath)
+	for _, result := range results {
+		fmt.Fprintf(stdout, "\n%s\n", result.Snippet())
 	}
-	sort.Sort(Repositories(config.Repositories))
-
-	return config, nil
 }
 
-func getAssetFormat(assetName string) EAssetFormat {
-	if strings.HasSuffix(assetName, ".tar.gz") {
-		return TargzipFormat
+func doRateLimit(configPath string, proxy string) {
+	config, err := gogo.ReadConfig(configPath)
+	if err != nil {
+		fmt.Fprintf(stdout, "Error reading config: %v\n", err)
+		os.Exit(1)
 	}
-	if strings.HasSuffix(assetName, ".tgz") {
-		return TargzipFormat
+	if proxy != "" {
+		config.Network.Proxy = proxy
 	}
-	if strings.HasSuffix(assetName, ".tar") {
-		return TarballFormat
+	tokenSource := resolveToken(&config.Auth, config.Network)
+	client, err := gogo.NewClient(config.Network, config.Auth.Token)
+	if err != nil {
+		fmt.Fprintf(stdout, "Error configuring network: %v\n", err)
+		os.Exit(1)
 	}
-	if strings.HasSuffix(assetName, ".zip") {
-		return ZipFormat
+	quota, err := client.GetRateQuota()
+	if err != nil {
+		fmt.Fprintf(stdout, "Error getting rate limit: %v\n", err)
+		os.Exit(1)
 	}
-	return BinaryFormat
+	if tokenSource != "" {
+		fmt.Fprintf(stdout, "Using token from %s\n", tokenSource)
+	} else {
+		fmt.Fprintf(stdout, "Anonymous requests, set a token for a higher limit\n")
+	}
+	fmt.Fprintln(stdout, describeQuota(quota))
 }
 
-func expandPath(path string) (string, error) {
-	if strings.HasPrefix(path, "~") {
-		usr, err := user.Current()
-		if err != nil {
-			return "", err
-		}
-		homeDir := usr.HomeDir
-		if path == "~" {
-			return homeDir, nil
-		}
-		return filepath.Join(homeDir, path[2:]), nil
+// describeQuota tells how many API requests are left, e.g. "GitHub API:
+// 4990/5000 requests left, reset at 15:04 (in 42m0s)".
+func describeQuota(quota gogo.RateQuota) string {
+	description := "GitHub API: " + quotaLeft(quota)
+	switch {
+	case quota.Remaining == 0:
+		return errorStyle.Render(description)
+	case quota.Remaining < quota.Limit/10:
+		return warningStyle.Render(description)
+	}
+	return description
+}
+
+// quotaLeft tells how many API requests are left and when the quota resets.
+func quotaLeft(quota gogo.RateQuota) string {
+	return fmt.Sprintf("%d/%d requests left, reset at %s (in %s)", quota.Remaining, quota.Limit,
+		quota.Reset.Format("15:04"), time.Until(quota.Reset).Round(time.Minute))
+}
+
+func doClean(olderThan time.Duration) {
+	removed, reclaimed, err := gogo.CleanWorkDirs(olderThan)
+	if err != nil {
+		fmt.Fprintln(stdout, errorStyle.Render(fmt.Sprintf("Error cleaning up: %v", err)))
+		os.Exit(1)
 	}
-	return path, nil
+	directories := "directories"
+	if removed == 1 {
+		directories = "directory"
+	}
+	fmt.Fprintf(stdout, "Removed %d temporary %s, reclaiming %s\n", removed, directories, gogo.HumanSize(uint64(reclaimed)))
 }
 
-func checkTargetDir(targetDir string) error {
-	info, err := os.Stat(targetDir)
+// doAdd works out how to configure a repository from its latest release, and
+// adds it to the configuration.
+func doAdd(configPath string, name string, proxy string, tags []string, dryRun bool, newFile bool) {
+	config, err := gogo.ReadConfig(configPath)
 	if err != nil {
-		return err
+		fmt.Fprintf(stdout, "Error reading config: %v\n", err)
+		os.Exit(1)
 	}
-	if !info.IsDir() {
-		return fmt.Errorf("target directory %s is not a directory", targetDir)
+	if proxy != "" {
+		config.Network.Proxy = proxy
 	}
-	tmpFile, err := os.CreateTemp(targetDir, "write_test_*")
+	resolveToken(&config.Auth, config.Network)
+	client, err := gogo.NewClient(config.Network, config.Auth.Token)
 	if err != nil {
-		return fmt.Errorf("target directory %s is not writable", targetDir)
+		fmt.Fprintf(stdout, "Error configuring network: %v\n", err)
+		os.Exit(1)
+	}
+	client.Prefer = config.Platform.Prefer
+	client.Ignore = config.Platform.Ignore
+	client.Demote = config.Platform.Demote
+	config.AddAliases()
+	host := gogo.DetectHost()
+	if config.Platform.Libc != "" {
+		host.Libc = config.Platform.Libc
 	}
-	tmpFile.Close()
-	os.Remove(tmpFile.Name())
 
-	return nil
+	name = gogo.NormalizeName(name)
+	if slices.ContainsFunc(config.Repositories, func(repo gogo.Repository) bool { return strings.EqualFold(repo.Name, name) }) {
+		fmt.Fprintf(stdout, "%s is already configured\n", name)
+		os.Exit(1)
+	}
+	repo, err := client.DescribeRepository(name, host)
+	if err != nil {
+		fmt.Fprintln(stdout, errorStyle.Render(fmt.Sprintf("Cannot add %s: %v", name, err)))
+		os.Exit(1)
+	}
+	if slices.ContainsFunc(config.Repositories, func(configured gogo.Repository) bool { return configured.File == repo.File }) {
+		fmt.Fprintf(stdout, "A command named %s is already configured\n", repo.File)
+		os.Exit(1)
+	}
+	if len(tags) > 0 {
+		repo.Tags = tags
+	}
+	if dryRun {
+		fmt.Fprintln(stdout, repo.Snippet())
+		return
+	}
+	configFile, err := gogo.AddToConfig(configPath, repo, newFile)
+	if err != nil {
+		fmt.Fprintf(stdout, "Error adding %s: %v\n", name, err)
+		os.Exit(1)
+	}
+	fmt.Fprintln(stdout, okStyle.Render(fmt.Sprintf("Added %s to %s, install it with: gogo fetch %s", name, configFile, repo.File)))
 }
 
-func readOneConfig(configPath string) (Config, error) {
-	var config Config
-	if _, err := toml.DecodeFile(configPath, &config); err != nil {
-		return config, fmt.Errorf("error reading config file: %v", err)
+func doRollback(configPath string, command string) {
+	config, err := gogo.ReadConfig(configPath)
+	if err != nil {
+		fmt.Fprintf(stdout, "Error reading config: %v\n", err)
+		os.Exit(1)
+	}
+	if config.Paths.TargetDir == "" {
+		config.Paths.TargetDir = "."
+	}
+	targetDir, err := gogo.ExpandPath(config.Paths.TargetDir)
+	if err != nil {
+		fmt.Fprintf(stdout, "Error expanding target directory: %v\n", err)
+		os.Exit(1)
+	}
+	statePath, state := loadState()
+	repo := findCommand(&config, state, command)
+
+	version, err := gogo.Rollback(targetDir, repo)
+	if err != nil {
+		fmt.Fprintln(stdout, errorStyle.Render(fmt.Sprintf("Cannot roll back: %v", err)))
+		os.Exit(1)
 	}
-	return config, nil
+	if recorded, ok := state.Repositories[repo.Name]; ok && statePath != "" {
+		// The asset recorded is that of the newer version
+		state.Repositories[repo.Name] = gogo.RepoState{File: recorded.File, Tag: version, InstalledAt: recorded.InstalledAt, Files: recorded.Files}
+		if err := state.Save(statePath); err != nil {
+			fmt.Fprintf(stdout, "Error saving state: %v\n", err)
+		}
+	}
+	fmt.Fprintln(stdout, okStyle.Render(fmt.Sprintf("%s rolled back to %s", command, version)))
 }
 
-func existFile(fileName string) bool {
-	if _, err := os.Stat(fileName); err != nil {
-		return false
+// doConfigInit writes the example configuration to configPath or, if it is
+// a directory, to its config.toml.
+func doConfigInit(configPath string, force bool) {
+	if configPath == "" {
+		configPath = userConfigPath()
 	}
-	return true
+	if info, err := os.Stat(configPath); err == nil && info.IsDir() || filepath.Ext(configPath) != ".toml" {
+		configPath = filepath.Join(configPath, "config.toml")
+	}
+	if _, err := os.Stat(configPath); err == nil && !force {
+		fmt.Fprintf(stdout, "%s already exists, use -force to overwrite it\n", configPath)
+		os.Exit(1)
+	}
+	if err := os.MkdirAll(filepath.Dir(configPath), 0o755); err != nil {
+		fmt.Fprintf(stdout, "Error creating config directory: %v\n", err)
+		os.Exit(1)
+	}
+	if err := os.WriteFile(configPath, []byte(gogo.ConfigTemplate), 0o644); err != nil {
+		fmt.Fprintf(stdout, "Error writing config: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Fprintln(stdout, okStyle.Render(fmt.Sprintf("Wrote an example configuration to %s", configPath)))
 }
 
-func downloadFile(url string, assetFormat EAssetFormat, fileName string, utils []string, targetDir string) error {
-	resp, err := http.Get(url)
+func doValidate(configPath string) {
+	config, err := gogo.ReadConfig(configPath)
 	if err != nil {
-		return err
+		fmt.Fprintln(stdout, errorStyle.Render(fmt.Sprintf("Error reading config: %v", err)))
+		os.Exit(1)
 	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("non-OK HTTP status: %s", resp.Status)
+	for _, warning := range config.Warnings() {
+		fmt.Fprintln(stdout, warningStyle.Render(fmt.Sprintf("  - Warning: %s", warning)))
 	}
-
-	switch assetFormat {
-	case TarballFormat:
-		return writeTarballFile(fileName, utils, targetDir, resp.Body)
-	case TargzipFormat:
-		return writeTargzipFile(fileName, utils, targetDir, resp.Body)
-	case ZipFormat:
-		return writeZipFile(fileName, utils, targetDir, resp.Body)
-	case BinaryFormat:
-		filePath := filepath.Join(targetDir, fileName)
-		return writeBinaryFile(filePath, resp.Body)
+	problems := config.Validate()
+	if len(problems) == 0 {
+		fmt.Fprintln(stdout, okStyle.Render(fmt.Sprintf("Configuration is valid (%d repositories)", len(config.Repositories))))
+		return
 	}
-	return nil
+	for _, problem := range problems {
+		fmt.Fprintln(stdout, errorStyle.Render(fmt.Sprintf("  - %v", problem)))
+	}
+	fmt.Fprintf(stdout, "%d problem(s) found\n", len(problems))
+	os.Exit(1)
 }
 
-func writeTarballFile(fileName string, utils []string, targetDir string, content io.Reader) error {
-	tmpPath, err := os.MkdirTemp("/tmp", "gogo_work_*")
+func doExport(configPath string, configured bool, versions bool) {
+	config, err := gogo.ReadConfig(configPath)
 	if err != nil {
-		return fmt.Errorf("error creating temp file: %v", err)
+		fmt.Fprintf(stdout, "Error reading config: %v\n", err)
+		os.Exit(1)
 	}
-	tmpFileName := filepath.Join(tmpPath, "asset.tar")
-	if err := writeBinaryFile(tmpFileName, content); err != nil {
-		return err
+	if config.Paths.TargetDir == "" {
+		config.Paths.TargetDir = "."
 	}
-	file, err := os.Open(tmpFileName)
+	targetDir, err := gogo.ExpandPath(config.Paths.TargetDir)
 	if err != nil {
-		return err
+		fmt.Fprintf(stdout, "Error expanding target directory: %v\n", err)
+		os.Exit(1)
 	}
-	defer file.Close()
-	tarReader := tar.NewReader(file)
-	for {
-		header, err := tarReader.Next()
-		if err == io.EOF {
-			break
+	_, state := loadState()
+
+	fmt.Fprintf(stdout, "# Exported by gogo on %s, install with: gogo fetch @<this-file>\n", time.Now().Format(time.DateOnly))
+	exported := map[string]bool{}
+	export := func(entry string, repoName string) {
+		if recorded, ok := state.Repositories[repoName]; versions && ok && recorded.Tag != "" {
+			entry = strings.Replace(entry, " ", "@"+recorded.Tag+" ", 1)
+			if !strings.Contains(entry, " ") {
+				entry += "@" + recorded.Tag
+			}
 		}
-		if err != nil {
-			return err
+		fmt.Fprintln(stdout, entry)
+		exported[repoName] = true
+	}
+	for _, repo := range config.Repositories {
+		if existing, checkFiles := existingFiles(&repo, targetDir); configured || len(existing) == len(checkFiles) {
+			export(repo.File, repo.Name)
 		}
-		if header.Typeflag != tar.TypeReg {
+	}
+	// Commands fetched directly, rather than configured
+	var direct []string
+	for repoName := range state.Repositories {
+		if !exported[repoName] {
+			direct = append(direct, repoName)
+		}
+	}
+	sort.Strings(direct)
+	for _, repoName := range direct {
+		recorded := state.Repositories[repoName]
+		if recorded.File == "" || !gogo.ExistFile(filepath.Join(targetDir, recorded.File)) {
 			continue
 		}
-		var proceed *string
-		if filepath.Base(header.Name) == fileName {
-			proceed = &fileName
+		if recorded.Url == repoName {
+			export(repoName+" "+recorded.File, repoName)
 		} else {
-			for _, util := range utils {
-				if filepath.Base(header.Name) == util {
-					proceed = &util
-					break
-				}
-			}
+			export(repoName, repoName)
 		}
-		if proceed == nil {
-			continue
+	}
+}
+
+func doFetch(configPath string, command *string, opts FetchOptions) {
+	var events *porcelain
+	if opts.Porcelain {
+		events = &porcelain{writer: os.Stdout}
+		stdout.SetWriter(os.Stderr)
+	}
+	if opts.JSON {
+		stdout.SetWriter(os.Stderr)
+	}
+	host := gogo.DetectHost()
+	if opts.OS != "" || opts.Arch != "" {
+		target := gogo.Host{OS: cmp.Or(strings.ToLower(opts.OS), host.OS), Arch: cmp.Or(strings.ToLower(opts.Arch), host.Arch), Libc: host.Libc}
+		if !slices.Contains(gogo.KnownOSes, target.OS) {
+			fmt.Fprintf(stdout, "Unknown OS: %s\n", target.OS)
+			os.Exit(1)
 		}
-		filePath := filepath.Join(targetDir, *proceed)
-		if err := writeBinaryFile(filePath, tarReader); err != nil {
-			return err
+		if !slices.Contains(gogo.KnownArchs, target.Arch) {
+			fmt.Fprintf(stdout, "Unknown architecture: %s\n", target.Arch)
+			os.Exit(1)
 		}
-		if len(utils) == 0 {
-			break
+		if target.OS != host.OS || target.Arch != host.Arch {
+			fmt.Fprintln(stdout, warningStyle.Render(fmt.Sprintf("Warning: fetching commands for %s/%s, they will not run on this host", target.OS, target.Arch)))
+			// The host's libc says nothing of the target's
+			target.Libc = "glibc"
+			if opts.Verify {
+				fmt.Fprintln(stdout, warningStyle.Render("Warning: -verify is ignored when fetching for another platform"))
+				opts.Verify = false
+			}
 		}
+		host = target
+	}
+	update, tags, verbose, dryRun := opts.Update, opts.Tags, opts.Verbose, opts.DryRun
+	if opts.Concurrency < 1 {
+		fmt.Fprintf(stdout, "Concurrency must be at least 1\n")
+		os.Exit(1)
 	}
-	return nil
-}
 
-func writeTargzipFile(fileName string, utils []string, targetDir string, content io.Reader) error {
-	tmpPath, err := os.MkdirTemp("/tmp", "gogo_work_*")
+	if verbose {
+		verbosePrintf("  - Host architecture: %s\n", host.Arch)
+		verbosePrintf("  - Host OS: %s\n", host.OS)
+		verbosePrintf("  - Config path: %s\n", configPath)
+	}
+	config, err := gogo.ReadConfig(configPath)
 	if err != nil {
-		return fmt.Errorf("error creating temp file: %v", err)
+		fmt.Fprintf(stdout, "Error reading config: %v\n", err)
+		os.Exit(1)
 	}
-	tmpFileName := filepath.Join(tmpPath, "asset.tar.gz")
-	if err := writeBinaryFile(tmpFileName, content); err != nil {
-		return err
+	if opts.Proxy != "" {
+		config.Network.Proxy = opts.Proxy
 	}
-	file, err := os.Open(tmpFileName)
+	tokenSource := resolveToken(&config.Auth, config.Network)
+	client, err := gogo.NewClient(config.Network, config.Auth.Token)
 	if err != nil {
-		return err
+		fmt.Fprintf(stdout, "Error configuring network: %v\n", err)
+		os.Exit(1)
+	}
+	// An interrupt cancels the requests and downloads in flight rather than
+	// killing gogo mid-write, so that nothing half installed is left behind.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	client.Context = ctx
+	client.Force = opts.Force
+	client.Prerelease = opts.Prerelease
+	client.ReleaseOffset = opts.ReleaseOffset
+	client.AllowAppImage = opts.AllowAppImage
+	client.SkipChecksum = opts.NoChecksum
+	client.MaxSize = opts.MaxSize
+	client.RateLimiter = gogo.NewRateLimiter(opts.RateLimit)
+	client.Reselect = opts.Reselect
+	if err := gogo.CheckPrefer(config.Platform.Prefer); err != nil {
+		fmt.Fprintf(stdout, "Error in platform.prefer: %v\n", err)
+		os.Exit(1)
 	}
-	defer file.Close()
-	gzipReader, err := gzip.NewReader(file)
+	client.Prefer = config.Platform.Prefer
+	client.Ignore = config.Platform.Ignore
+	client.Demote = config.Platform.Demote
+	config.AddAliases()
+	statePath, state := loadState()
+	client.State = state
+	lockPath := gogo.LockPath(configPath)
+	if opts.Locked && !gogo.ExistFile(lockPath) {
+		fmt.Fprintf(stdout, "No lockfile at %s\n", lockPath)
+		os.Exit(1)
+	}
+	lock, err := gogo.LoadLock(lockPath)
+	if err != nil && opts.Locked {
+		fmt.Fprintf(stdout, "Error reading lockfile: %v\n", err)
+		os.Exit(1)
+	} else if err != nil {
+		// Rather than overwrite it
+		fmt.Fprintln(stdout, warningStyle.Render(fmt.Sprintf("Warning: cannot read lockfile, it will not be updated: %v", err)))
+	}
+	// Leftovers of interrupted runs, old enough not to belong to a running one
+	if removed, reclaimed, err := gogo.CleanWorkDirs(24 * time.Hour); err == nil && removed > 0 && verbose {
+		verbosePrintf("  - Removed %d stale temporary directories (%s)\n", removed, gogo.HumanSize(uint64(reclaimed)))
+	}
+	if verbose {
+		client.Logf = verbosePrintf
+		if tokenSource != "" {
+			verbosePrintf("  - Using token from %s\n", tokenSource)
+		}
+	}
+
+	if opts.TargetDir != "" {
+		config.Paths.TargetDir = opts.TargetDir
+	}
+	if config.Paths.TargetDir == "" {
+		fmt.Fprintf(stdout, "Target directory not set, using current directory\n")
+		config.Paths.TargetDir = "."
+	}
+	config.Paths.TargetDir, err = gogo.ExpandPath(config.Paths.TargetDir)
 	if err != nil {
-		return err
+		fmt.Fprintf(stdout, "Error expanding target directory: %v\n", err)
+		os.Exit(1)
 	}
-	defer gzipReader.Close()
-	tarReader := tar.NewReader(gzipReader)
-	for {
-		header, err := tarReader.Next()
-		if err == io.EOF {
-			break
+	if verbose {
+		verbosePrintf("  - Target dir: %s\n", config.Paths.TargetDir)
+	}
+	if opts.Libc != "" {
+		host.Libc = opts.Libc
+	} else if config.Platform.Libc != "" {
+		host.Libc = config.Platform.Libc
+	}
+	if _, ok := gogo.LibcEquiv[host.Libc]; !ok {
+		fmt.Fprintf(stdout, "Unknown libc: %s (expected glibc or musl)\n", host.Libc)
+		os.Exit(1)
+	}
+	if verbose {
+		verbosePrintf("  - Preferred libc: %s\n", host.Libc)
+	}
+	// With -o, the command is written elsewhere
+	if _, err := os.Stat(config.Paths.TargetDir); opts.Output == "" && os.IsNotExist(err) && dryRun && !opts.NoCreate {
+		fmt.Fprintf(stdout, "Target directory %s does not exist, it would be created\n", config.Paths.TargetDir)
+	} else if opts.Output == "" {
+		if err := checkTargetDir(config.Paths.TargetDir, !opts.NoCreate); err != nil {
+			fmt.Fprintf(stdout, "Error checking target directory: %v\n", err)
+			os.Exit(1)
 		}
-		if err != nil {
-			return err
+	}
+
+	defaultMode := gogo.DefaultMode
+	if config.Paths.Mode != "" {
+		if defaultMode, err = gogo.ParseMode(config.Paths.Mode); err != nil {
+			fmt.Fprintf(stdout, "Error in paths.mode: %v\n", err)
+			os.Exit(1)
 		}
-		if header.Typeflag != tar.TypeReg {
-			continue
+	}
+
+	// Commands asked for by name are fetched whatever their tags
+	if command == nil {
+		tags = config.FilterTags(tags)
+	}
+	checkedRepos := config.Repositories
+	var commands []string
+	if command != nil {
+		if *command == "-" {
+			*command = "@-"
 		}
-		var proceed *string
-		if filepath.Base(header.Name) == fileName {
-			proceed = &fileName
+		var entries [][]string
+		if strings.HasPrefix(*command, "@") {
+			filePath := strings.TrimPrefix(*command, "@")
+			if verbose {
+				verbosePrintf("  - Command list file: %s\n", filePath)
+			}
+			var list io.Reader = os.Stdin
+			switch {
+			case strings.HasPrefix(filePath, "https://") || strings.HasPrefix(filePath, "http://"):
+				body, err := client.FetchText(filePath)
+				if err != nil {
+					fmt.Fprintf(stdout, "Error fetching command list %s: %v\n", filePath, err)
+					os.Exit(1)
+				}
+				defer body.Close()
+				list = body
+			case filePath != "-":
+				file, err := os.Open(filePath)
+				if err != nil {
+					fmt.Fprintf(stdout, "Error opening file %s: %v\n", filePath, err)
+					os.Exit(1)
+				}
+				defer file.Close()
+				list = file
+			}
+			lines, err := readCommandList(list)
+			if err != nil {
+				fmt.Fprintf(stdout, "Error reading command list %s: %v\n", filePath, err)
+				os.Exit(1)
+			}
+			if len(lines) == 0 {
+				// Rather than fetch everything
+				fmt.Fprintf(stdout, "No commands to fetch\n")
+				if opts.JSON {
+					printJSON(os.Stdout, []fetchResult{})
+				}
+				return
+			}
+			for _, line := range lines {
+				entries = append(entries, strings.Fields(line))
+			}
 		} else {
-			for _, util := range utils {
-				if filepath.Base(header.Name) == util {
-					proceed = &util
+			entries = [][]string{{*command, opts.As}}
+		}
+
+		var directRepos gogo.Repositories
+		for _, entry := range entries {
+			name := ""
+			if len(entry) > 1 {
+				name = entry[1]
+			}
+			// A version may be pinned, as in tool@v1.2 or owner/repo@~1.4
+			version := ""
+			if command, pinned, found := strings.Cut(entry[0], "@"); found && !strings.Contains(command, "://") {
+				if opts.Locked {
+					fmt.Fprintf(stdout, "Cannot fetch %s: -locked installs the locked version\n", entry[0])
+					os.Exit(1)
+				}
+				if err := gogo.CheckVersion(pinned); err != nil {
+					fmt.Fprintf(stdout, "Cannot fetch %s: %v\n", entry[0], err)
+					os.Exit(1)
+				}
+				entry[0], version = command, pinned
+			}
+			directRepo, err := directRepository(entry[0], name)
+			if err != nil {
+				fmt.Fprintf(stdout, "Cannot fetch %s: %v\n", entry[0], err)
+				os.Exit(1)
+			}
+			if directRepo == nil {
+				if i := slices.IndexFunc(config.Repositories, func(repo gogo.Repository) bool {
+					return repo.File == entry[0]
+				}); i >= 0 && version != "" {
+					config.Repositories[i].Version = version
+				}
+				commands = append(commands, entry[0])
+				continue
+			}
+			if i := slices.IndexFunc(config.Repositories, func(repo gogo.Repository) bool {
+				return repo.Name == directRepo.Name
+			}); i >= 0 {
+				// Configured repositories know best what to install
+				if version != "" {
+					config.Repositories[i].Version = version
+				}
+				commands = append(commands, config.Repositories[i].File)
+				continue
+			}
+			directRepo.Version = version
+			directRepos = append(directRepos, *directRepo)
+			commands = append(commands, directRepo.File)
+		}
+		if len(directRepos) > 0 {
+			// They replace any configured repository installing the same command
+			checkedRepos = slices.DeleteFunc(slices.Clone(checkedRepos), func(repo gogo.Repository) bool {
+				return slices.ContainsFunc(directRepos, func(directRepo gogo.Repository) bool {
+					return directRepo.File == repo.File
+				})
+			})
+			checkedRepos = append(checkedRepos, directRepos...)
+		}
+	}
+
+	if opts.Interactive {
+		var repos gogo.Repositories
+		for _, repo := range config.Repositories {
+			if !repo.Disabled && (len(tags) == 0 || containsTag(repo.Tags, tags)) {
+				repos = append(repos, repo)
+			}
+		}
+		if commands, err = pickCommands(repos, state); err != nil {
+			fmt.Fprintf(stdout, "Error picking commands: %v\n", err)
+			os.Exit(1)
+		}
+		if len(commands) == 0 {
+			fmt.Fprintf(stdout, "No commands selected\n")
+			return
+		}
+	}
+
+	if verbose {
+		verbosePrintf("  - Commands: %v\n", commands)
+		verbosePrintf("  - Tags: %v\n", tags)
+	}
+	repoStatusList := []gogo.RepoStatus{}
+	stdin := bufio.NewReader(os.Stdin)
+
+	fmt.Fprintf(stdout, "[Preflight]\n")
+	var selectedRepos gogo.Repositories
+	for _, repo := range checkedRepos {
+		if len(commands) > 0 {
+			found := false
+			for _, v := range commands {
+				if v == repo.File {
+					found = true
 					break
 				}
 			}
+			if !found {
+				continue
+			}
 		}
-		if proceed == nil {
+		if len(tags) > 0 && !containsTag(repo.Tags, tags) {
 			continue
 		}
-		filePath := filepath.Join(targetDir, *proceed)
-		if err := writeBinaryFile(filePath, tarReader); err != nil {
-			return err
+		if repo.Disabled && len(commands) == 0 {
+			continue
+		}
+		if opts.OnlyMissing || opts.OnlyInstalled {
+			existing, checkFiles := existingFiles(&repo, config.Paths.TargetDir)
+			if installed := len(existing) == len(checkFiles); installed != opts.OnlyInstalled {
+				continue
+			}
 		}
-		if len(utils) == 0 {
+		selectedRepos = append(selectedRepos, repo)
+	}
+	if opts.Asset != "" {
+		if len(selectedRepos) != 1 {
+			fmt.Fprintf(stdout, "-asset can only be used to fetch a single command, %d matched\n", len(selectedRepos))
+			os.Exit(1)
+		}
+		selectedRepos[0].Asset = opts.Asset
+	}
+	progress := newPreflightProgress(len(selectedRepos), !opts.Quiet && !verbose && stdout.IsTerminal())
+	addStatus := func(repoStatus gogo.RepoStatus) {
+		repoStatusList = append(repoStatusList, repoStatus)
+		events.preflight(&repoStatus)
+	}
+	for i, repo := range selectedRepos {
+		if ctx.Err() != nil {
 			break
 		}
+		if opts.NoUtils {
+			repo = *repo.WithoutUtils()
+		}
+		repo.UtilsOnly = opts.UtilsOnly
+		repoStatus := gogo.RepoStatus{Repo: &repo, Status: gogo.RepoKO, Mode: defaultMode}
+		if repo.UtilsOnly && len(repo.Utils) == 0 && len(repo.Completions) == 0 {
+			repoStatus.Status = gogo.RepoSkipped
+			repoStatus.Message = "no utils nor completions configured"
+			fmt.Fprintf(stdout, "  - %s: %s\n", repo.File, repoStatus.Message)
+			addStatus(repoStatus)
+			continue
+		}
+		if repo.URL == "" {
+			if err := repo.CheckName(); err != nil {
+				repoStatus.Message = err.Error()
+				fmt.Fprintf(stdout, "  - %s: %s\n", repo.File, repoStatus.Message)
+				addStatus(repoStatus)
+				continue
+			}
+		}
+		if repo.Mode != "" {
+			mode, err := gogo.ParseMode(repo.Mode)
+			if err != nil {
+				repoStatus.Message = fmt.Sprintf("invalid mode: %v", err)
+				fmt.Fprintf(stdout, "  - %s: %s\n", repo.Name, repoStatus.Message)
+				addStatus(repoStatus)
+				continue
+			}
+			repoStatus.Mode = mode
+		}
+		if err := gogo.CheckPrefer(repo.Prefer); err != nil {
+			repoStatus.Message = fmt.Sprintf("invalid prefer: %v", err)
+			fmt.Fprintf(stdout, "  - %s: %s\n", repo.Name, repoStatus.Message)
+			addStatus(repoStatus)
+			continue
+		}
+		existing, checkFiles := existingFiles(&repo, config.Paths.TargetDir)
+		if opts.Output != "" {
+			existing = nil
+		}
+		if repo.UtilsOnly {
+			// Installed commands are left alone, whatever their version
+			existing = nil
+		}
+		if !update && len(existing) == len(checkFiles) {
+			fmt.Fprintf(stdout, "  - ignoring existing command %s (%s)\n", repo.File, strings.Join(checkFiles, ", "))
+			repoStatus.Status = gogo.RepoExist
+			addStatus(repoStatus)
+			continue
+		}
+		if selfPath := overwrittenSelf(&repo, config.Paths.TargetDir, opts.Output); selfPath != "" && !repo.UtilsOnly && !opts.Force {
+			repoStatus.Message = fmt.Sprintf("would overwrite the running gogo (%s), use -force to do it anyway", selfPath)
+			fmt.Fprintf(stdout, "  - %s: %s\n", repo.File, repoStatus.Message)
+			addStatus(repoStatus)
+			continue
+		}
+		if update && len(existing) > 0 && !opts.Yes && !confirmOverwrite(stdin, existing) {
+			repoStatus.Status = gogo.RepoExist
+			addStatus(repoStatus)
+			continue
+		}
+
+		if opts.Locked {
+			locked, err := lock.Status(&repo, host)
+			if err != nil {
+				repoStatus.Message = err.Error()
+				fmt.Fprintf(stdout, "  - %s: %s\n", repo.File, repoStatus.Message)
+				addStatus(repoStatus)
+				continue
+			}
+			locked.Mode = repoStatus.Mode
+			fmt.Fprintf(stdout, "  + locked Asset: %s (%s)\n", locked.Asset, locked.Tag)
+			addStatus(locked)
+			continue
+		}
+
+		progress.start(i+1, repo.Name)
+		resolved, err := client.ResolveAsset(&repo, host)
+		progress.stop()
+		if err != nil {
+			fmt.Fprintf(stdout, "  - Error fetching releases for %s: %v\n", repo.Name, err)
+			repoStatus.Message = fmt.Sprintf("error fetching releases: %v", err)
+			addStatus(repoStatus)
+			continue
+		}
+		resolved.Mode = repoStatus.Mode
+		repoStatus = resolved
+		if opts.Since > 0 && !repoStatus.PublishedAt.IsZero() && time.Since(repoStatus.PublishedAt) > opts.Since {
+			repoStatus.Status = gogo.RepoSkipped
+			repoStatus.Message = fmt.Sprintf("released %s, before -since", repoStatus.PublishedAt.Format(time.DateOnly))
+			fmt.Fprintf(stdout, "  - %s: %s\n", repo.Name, repoStatus.Message)
+			addStatus(repoStatus)
+			continue
+		}
+		switch {
+		case repoStatus.Format == gogo.GoInstallFormat:
+			fmt.Fprintf(stdout, "  + falling back to %s\n", repoStatus.Asset)
+		case repoStatus.Status == gogo.RepoOK:
+			if repoStatus.Mismatch != "" {
+				fmt.Fprintf(stdout, "  %s\n", warningStyle.Render(fmt.Sprintf("! forcing %s built for %s", repoStatus.Asset, repoStatus.Mismatch)))
+			}
+			if repoStatus.Warning != "" {
+				fmt.Fprintf(stdout, "  %s\n", warningStyle.Render(fmt.Sprintf("! %s: %s", repoStatus.Asset, repoStatus.Warning)))
+			}
+			fmt.Fprintf(stdout, "  + identified Asset: %s\n", repoStatus.Asset)
+		case repoStatus.Message != "":
+			fmt.Fprintf(stdout, "  - %s: %s\n", repo.Name, repoStatus.Message)
+		}
+
+		addStatus(repoStatus)
+	}
+	if ctx.Err() != nil {
+		fmt.Fprintln(stdout, errorStyle.Render("Interrupted"))
+		os.Exit(1)
 	}
-	return nil
-}
 
-func writeTargzipContent(targetDir string, content io.Reader) error {
-	tmpPath, err := os.MkdirTemp("/tmp", "gogo_work_*")
-	if err != nil {
-		fmt.Printf("error creating temp file: %v", err)
+	if opts.Output != "" && len(repoStatusList) != 1 {
+		fmt.Fprintf(stdout, "-o can only be used to fetch a single command, %d matched\n", len(repoStatusList))
 		os.Exit(1)
 	}
-	tmpFileName := filepath.Join(tmpPath, "asset.tar.gz")
-	if err := writeBinaryFile(tmpFileName, content); err != nil {
-		return err
+	if opts.Asset != "" && repoStatusList[0].Status == gogo.RepoKO {
+		// The asset asked for is missing, there is nothing to fall back to
+		os.Exit(1)
 	}
-	file, err := os.Open(tmpFileName)
-	if err != nil {
-		return err
+
+	fmt.Fprintf(stdout, "[Repositories]\n")
+	for _, repoStatus := range repoStatusList {
+		fmt.Fprintf(stdout, "    repository: %s ", repoStatus.Repo.Name)
+		switch repoStatus.Status {
+		case gogo.RepoOK:
+			fmt.Fprintln(stdout, okStyle.Render("[OK]"))
+		case gogo.RepoKO:
+			if repoStatus.Message != "" {
+				fmt.Fprintln(stdout, errorStyle.Render(fmt.Sprintf("[XXX] %s", repoStatus.Message)))
+			} else {
+				fmt.Fprintln(stdout, errorStyle.Render("[XXX]"))
+			}
+		case gogo.RepoExist:
+			fmt.Fprintln(stdout, warningStyle.Render("[Exist]"))
+		case gogo.RepoSkipped:
+			fmt.Fprintln(stdout, warningStyle.Render(fmt.Sprintf("[Skipped] %s", repoStatus.Message)))
+		}
 	}
-	defer file.Close()
-	gzipReader, err := gzip.NewReader(file)
-	if err != nil {
-		return err
+	// TODO What happens if not all repositories are OK?
+	fmt.Fprintf(stdout, "[Fetching]\n")
+	// Downloads run concurrently, but each one prints to its own section of
+	// the output so that lines do not interleave.
+	errs := make([]error, len(repoStatusList))
+	var downloads *downloadProgress
+	if !dryRun {
+		downloads = newDownloadProgress(!opts.Quiet && !verbose && stdout.IsTerminal())
+		client.Progress = downloads.update
+	}
+	slots := make(chan struct{}, opts.Concurrency)
+	var wg sync.WaitGroup
+	for i := range repoStatusList {
+		wg.Add(1)
+		go func(repoStatus *gogo.RepoStatus, out io.WriteCloser, err *error) {
+			defer wg.Done()
+			defer out.Close()
+			slots <- struct{}{}
+			defer func() { <-slots }()
+			fetching := !dryRun && repoStatus.Status == gogo.RepoOK
+			if fetching {
+				events.emit("fetch", repoStatus.Repo.Name, "start")
+			}
+			*err = fetchRepo(out, client, repoStatus, config.Paths.TargetDir, config.Paths.Keep, opts.Output, dryRun, opts.Check, opts.Verify)
+			if downloads != nil {
+				downloads.finish(repoStatus.Repo.File)
+			}
+			if fetching && *err != nil {
+				events.emit("fetch", repoStatus.Repo.Name, "error", (*err).Error())
+			} else if fetching {
+				events.emit("fetch", repoStatus.Repo.Name, "done", repoStatus.Tag)
+			}
+		}(&repoStatusList[i], stdout.Section(), &errs[i])
 	}
-	defer gzipReader.Close()
-	tarReader := tar.NewReader(gzipReader)
-	for {
-		header, err := tarReader.Next()
-		if err == io.EOF {
-			break
-		}
+	wg.Wait()
+	if downloads != nil {
+		downloads.stop()
+	}
+	interrupted := ctx.Err() != nil
+	stop()
+	var failed []string
+	installed := 0
+	for i, err := range errs {
 		if err != nil {
-			return err
+			failed = append(failed, repoStatusList[i].Repo.File)
+		} else if !dryRun && opts.Output == "" && repoStatusList[i].Status == gogo.RepoOK {
+			// With only utils, the installed command's version is unchanged
+			if !opts.UtilsOnly {
+				state.Record(&repoStatusList[i])
+				if lock != nil {
+					lock.Record(&repoStatusList[i], host)
+				}
+			}
+			installed++
 		}
-		if header.Typeflag != tar.TypeReg {
-			continue
+	}
+	if installed > 0 && statePath != "" {
+		if err := state.Save(statePath); err != nil {
+			fmt.Fprintf(stdout, "Error saving state: %v\n", err)
 		}
-		fileName := filepath.Base(header.Name)
-		if fileName == "config.toml" {
-			continue
+	}
+	// Installing what is locked leaves the lockfile alone
+	if installed > 0 && lock != nil && !opts.Locked && !opts.UtilsOnly {
+		if err := lock.Save(lockPath); err != nil {
+			fmt.Fprintf(stdout, "Error saving lockfile: %v\n", err)
 		}
-		filePath := filepath.Join(targetDir, fileName)
-		fmt.Printf("  - Extracting to %s\n", filePath)
-		if err := writeBinaryFile(filePath, tarReader); err != nil {
-			return err
+	}
+	fmt.Fprintln(stdout, fetchSummary(repoStatusList, len(failed), dryRun))
+	if quota, ok := client.RateQuota(); ok {
+		fmt.Fprintln(stdout, describeQuota(quota))
+	}
+	if opts.JSON {
+		printJSON(os.Stdout, fetchOutput{
+			Repositories: fetchResults(repoStatusList, errs),
+			Summary:      countFetched(repoStatusList, len(failed)),
+			DryRun:       dryRun,
+		})
+	}
+	if interrupted {
+		fmt.Fprintln(stdout, errorStyle.Render("Interrupted"))
+		os.Exit(1)
+	}
+	if len(failed) > 0 && dryRun {
+		fmt.Fprintln(stdout, warningStyle.Render(fmt.Sprintf("Not downloadable: %s", strings.Join(failed, ", "))))
+		os.Exit(1)
+	}
+	if len(failed) > 0 {
+		fmt.Fprintln(stdout, errorStyle.Render(fmt.Sprintf("Failed to install: %s", strings.Join(failed, ", "))))
+		os.Exit(1)
+	}
+}
+
+// fetchOutput is what fetch prints with -output json.
+type fetchOutput struct {
+	Repositories []fetchResult `json:"repositories"`
+	Summary      fetchCounts   `json:"summary"`
+	DryRun       bool          `json:"dry_run,omitempty"`
+}
+
+// fetchCounts counts what became of the repositories fetched.
+type fetchCounts struct {
+	// Installed counts those installed, or to install in a dry run
+	Installed      int `json:"installed"`
+	AlreadyPresent int `json:"already_present"`
+	Skipped        int `json:"skipped"`
+	Unavailable    int `json:"unavailable"`
+	Failed         int `json:"failed"`
+}
+
+// fetchResult tells what became of a repository, with -output json.
+type fetchResult struct {
+	Repository string `json:"repository"`
+	File       string `json:"file"`
+	// Status is ok (installed, or to install with -dry-run), failed, exist,
+	// skipped or unavailable
+	Status       string             `json:"status"`
+	Tag          string             `json:"tag,omitempty"`
+	InstalledTag string             `json:"installed_tag,omitempty"`
+	Asset        string             `json:"asset,omitempty"`
+	Format       *gogo.EAssetFormat `json:"format,omitempty"`
+	Url          string             `json:"url,omitempty"`
+	Sha256       string             `json:"sha256,omitempty"`
+	Installed    []string           `json:"installed,omitempty"`
+	Notes        []string           `json:"notes,omitempty"`
+	Message      string             `json:"message,omitempty"`
+}
+
+// fetchResults describes what became of each repository, given the errors
+// fetching them.
+func fetchResults(repoStatusList []gogo.RepoStatus, errs []error) []fetchResult {
+	results := []fetchResult{}
+	for i, repoStatus := range repoStatusList {
+		result := fetchResult{
+			Repository:   repoStatus.Repo.Name,
+			File:         repoStatus.Repo.File,
+			Tag:          repoStatus.Tag,
+			InstalledTag: repoStatus.InstalledTag,
+			Asset:        repoStatus.Asset,
+			Url:          repoStatus.Url,
+			Sha256:       repoStatus.Sha256,
+			Installed:    repoStatus.Installed,
+			Notes:        repoStatus.Notes,
+			Message:      repoStatus.Message,
+		}
+		switch {
+		case errs[i] != nil:
+			result.Status = "failed"
+			result.Message = errs[i].Error()
+		case repoStatus.Status == gogo.RepoOK:
+			result.Status = "ok"
+		case repoStatus.Status == gogo.RepoExist:
+			result.Status = "exist"
+		case repoStatus.Status == gogo.RepoSkipped:
+			result.Status = "skipped"
+		default:
+			result.Status = "unavailable"
+		}
+		if repoStatus.Status == gogo.RepoOK {
+			result.Format = &repoStatusList[i].Format
 		}
+		results = append(results, result)
 	}
-	return nil
+	return results
 }
 
-func writeZipFile(fileName string, utils []string, targetDir string, content io.Reader) error {
-	tmpPath, err := os.MkdirTemp("/tmp", "gogo_work_*")
-	if err != nil {
-		return fmt.Errorf("error creating temp file: %v", err)
+// countFetched counts what became of each repository, failed being how many
+// of those that were OK to install failed.
+func countFetched(repoStatusList []gogo.RepoStatus, failed int) fetchCounts {
+	var counts fetchCounts
+	for _, repoStatus := range repoStatusList {
+		switch repoStatus.Status {
+		case gogo.RepoOK:
+			counts.Installed++
+		case gogo.RepoExist:
+			counts.AlreadyPresent++
+		case gogo.RepoSkipped:
+			counts.Skipped++
+		default:
+			counts.Unavailable++
+		}
 	}
-	tmpFileName := filepath.Join(tmpPath, "asset.zip")
-	if err := writeBinaryFile(tmpFileName, content); err != nil {
-		return err
+	counts.Installed -= failed
+	counts.Failed = failed
+	return counts
+}
+
+// fetchSummary counts what became of each repository, e.g. "3 installed,
+// 2 already present, 1 failed".
+func fetchSummary(repoStatusList []gogo.RepoStatus, failed int, dryRun bool) string {
+	counts := countFetched(repoStatusList, failed)
+	installed := "installed"
+	if dryRun {
+		installed = "to install"
 	}
-	file, err := os.Open(tmpFileName)
-	if err != nil {
-		return err
+	parts := []string{okStyle.Render(fmt.Sprintf("%d %s", counts.Installed, installed))}
+	if counts.AlreadyPresent > 0 {
+		parts = append(parts, warningStyle.Render(fmt.Sprintf("%d already present", counts.AlreadyPresent)))
 	}
-	defer file.Close()
-	zipReader, err := zip.OpenReader(tmpFileName)
-	if err != nil {
-		return err
+	if counts.Skipped > 0 {
+		parts = append(parts, warningStyle.Render(fmt.Sprintf("%d skipped", counts.Skipped)))
+	}
+	if counts.Unavailable > 0 {
+		parts = append(parts, errorStyle.Render(fmt.Sprintf("%d unavailable", counts.Unavailable)))
 	}
-	defer zipReader.Close()
-	for _, file := range zipReader.File {
-		var proceed *string
-		if filepath.Base(file.Name) == fileName {
-			proceed = &fileName
+	if counts.Failed > 0 {
+		parts = append(parts, errorStyle.Render(fmt.Sprintf("%d failed", counts.Failed)))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// fetchRepo installs a single repository, printing to out as it may run
+// concurrently with others. With an output path, only the command is
+// installed, there. Otherwise, keep tells how many versions of it to keep,
+// if any, and verify whether to make sure it runs. In a dry run, check tells
+// whether to make sure the asset can be downloaded.
+func fetchRepo(out io.Writer, client *gogo.Client, repoStatus *gogo.RepoStatus, targetDir string, keep int, output string, dryRun bool, check bool, verify bool) error {
+	if dryRun {
+		if repoStatus.Status != gogo.RepoOK {
+			fmt.Fprintf(out, "  %s %s\n", repoStatus.Repo.Name, warningStyle.Render("Dry-Run: [Ignored]"))
+			return nil
+		}
+		if repoStatus.Format == gogo.GoInstallFormat {
+			fmt.Fprintf(out, "  %s %s\n", repoStatus.Repo.Name, okStyle.Render(fmt.Sprintf("Dry-Run: GOBIN=%s go install %s", targetDir, repoStatus.Url)))
 		} else {
-			for _, util := range utils {
-				if filepath.Base(file.Name) == util {
-					proceed = &util
-					break
+			fmt.Fprintf(out, "  %s %s\n", repoStatus.Repo.Name, okStyle.Render(fmt.Sprintf("Dry-Run: [%s]", fetchedLabel(repoStatus))))
+			fmt.Fprintf(out, "      asset:   %s (%s)\n", repoStatus.Asset, repoStatus.Format)
+			fmt.Fprintf(out, "      url:     %s\n", repoStatus.Url)
+			if repoStatus.Sha256 != "" {
+				fmt.Fprintf(out, "      sha256:  %s (locked)\n", repoStatus.Sha256)
+			} else if repoStatus.ChecksumUrl != "" {
+				fmt.Fprintf(out, "      sha256:  %s\n", repoStatus.ChecksumUrl)
+			}
+			if check {
+				url, size, err := client.CheckAsset(repoStatus)
+				if err != nil {
+					fmt.Fprintf(out, "      check:   %s\n", warningStyle.Render(err.Error()))
+					return err
 				}
+				found := "downloadable"
+				if url != repoStatus.Url {
+					found += " from " + url
+				}
+				if size >= 0 {
+					found += fmt.Sprintf(", %s", gogo.HumanSize(uint64(size)))
+				}
+				fmt.Fprintf(out, "      check:   %s\n", okStyle.Render(found))
 			}
 		}
-		if proceed == nil {
-			continue
+		plannedPaths := repoStatus.PlannedPaths(targetDir)
+		if output != "" {
+			plannedPaths = []string{output}
 		}
-		zipFile, err := file.Open()
-		if err != nil {
-			return err
+		for _, path := range plannedPaths {
+			fmt.Fprintf(out, "      install: %s\n", path)
+		}
+		return nil
+	}
+	if repoStatus.Status != gogo.RepoOK {
+		fmt.Fprintf(out, "  %s %s\n", repoStatus.Repo.Name, warningStyle.Render("[Ignored]"))
+		return nil
+	}
+	install := func() error { return client.Install(repoStatus, targetDir) }
+	if output != "" {
+		install = func() error { return client.InstallFile(repoStatus, output) }
+	} else if keep > 0 {
+		install = func() error { return client.InstallVersion(repoStatus, targetDir, keep) }
+	}
+	if err := install(); err != nil {
+		fmt.Fprintf(out, "  %s: %s\n", repoStatus.Repo.File, errorStyle.Render(fmt.Sprintf("[%s]", err.Error())))
+		return err
+	}
+	if verify {
+		filePath := output
+		if filePath == "" {
+			filePath = filepath.Join(targetDir, repoStatus.Repo.MainBinary())
 		}
-		defer zipFile.Close()
-		filePath := filepath.Join(targetDir, *proceed)
-		if err := writeBinaryFile(filePath, zipFile); err != nil {
+		if err := gogo.Verify(filePath, repoStatus.Repo); err != nil {
+			fmt.Fprintf(out, "  %s: %s\n", repoStatus.Repo.File, errorStyle.Render(fmt.Sprintf("[%s]", err.Error())))
+			if output == "" && keep > 0 {
+				if version, err := gogo.Rollback(targetDir, repoStatus.Repo); err == nil {
+					fmt.Fprintf(out, "  %s\n", warningStyle.Render(fmt.Sprintf("! %s: restored %s", repoStatus.Repo.File, version)))
+				}
+			}
 			return err
 		}
-		if len(utils) == 0 {
+	}
+	for _, note := range repoStatus.Notes {
+		fmt.Fprintf(out, "  %s\n", warningStyle.Render(fmt.Sprintf("! %s: %s", repoStatus.Repo.File, note)))
+	}
+	if repoStatus.Format == gogo.GoInstallFormat {
+		fmt.Fprintf(out, "  %s %s\n", repoStatus.Repo.Name, okStyle.Render("[Built]"))
+		return nil
+	}
+	fmt.Fprintf(out, "  %s %s\n", repoStatus.Repo.Name, okStyle.Render(fmt.Sprintf("[%s]", fetchedLabel(repoStatus))))
+	return nil
+}
+
+// fetchedLabel tells which version was fetched and, when updating, which one
+// it replaced, e.g. "Fetched v1.3.0 -> v1.4.2".
+func fetchedLabel(repoStatus *gogo.RepoStatus) string {
+	switch {
+	case repoStatus.Repo.UtilsOnly && repoStatus.Tag != "":
+		return "Fetched utils of " + repoStatus.Tag
+	case repoStatus.Tag == "":
+		return "Fetched"
+	case repoStatus.InstalledTag != "" && repoStatus.InstalledTag != repoStatus.Tag:
+		return fmt.Sprintf("Fetched %s -> %s", repoStatus.InstalledTag, repoStatus.Tag)
+	}
+	return "Fetched " + repoStatus.Tag
+}
+
+// resolveToken settles on the token to use, which may come from elsewhere
+// than the configuration, and returns where it was found. Users who have not
+// set a token yet are told why they may be rate limited sooner than they
+// expect.
+func resolveToken(auth *gogo.Auth, network gogo.Network) string {
+	token, source := gogo.ResolveToken(*auth, network)
+	if token == "" && gogo.IsPlaceholderToken(auth.Token) {
+		fmt.Fprintln(stdout, warningStyle.Render(fmt.Sprintf("Warning: auth.token is still the placeholder %q, making anonymous requests", auth.Token)))
+	}
+	auth.Token = token
+	return source
+}
+
+// readCommandList reads a list of commands, one per line. Like fetch
+// arguments, they may also be repositories, or file URLs followed by the
+// command name. Anything following a # is a comment.
+func readCommandList(list io.Reader) ([]string, error) {
+	var commands []string
+	scanner := bufio.NewScanner(list)
+	for scanner.Scan() {
+		line, _, _ := strings.Cut(scanner.Text(), "#")
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		commands = append(commands, line)
+	}
+	return commands, scanner.Err()
+}
+
+// directRepository returns the repository a fetch argument refers to, as
+// owner/repo or as a GitHub URL, or the file a URL refers to, installed as
+// name. For the name of a configured command, it returns nil.
+func directRepository(arg string, name string) (*gogo.Repository, error) {
+	bits := strings.Split(arg, "/")
+	if len(bits) < 2 {
+		return nil, nil
+	}
+	var directRepo gogo.Repository
+	if isFileURL(bits) {
+		// A file to download as is, rather than a repository
+		if name == "" {
+			return nil, fmt.Errorf("a command name is required to fetch a file URL (-as <name>, or after the URL in a list)")
+		}
+		directRepo.Name = arg
+		directRepo.File = name
+		directRepo.URL = arg
+	} else if bits[0] == "https:" {
+		if len(bits) < 5 || bits[4] == "" {
+			return nil, fmt.Errorf("not a repository URL")
+		}
+		directRepo.Name = strings.Join(bits[3:5], "/")
+		directRepo.File = bits[4]
+	} else {
+		directRepo.Name = strings.Join(bits[0:2], "/")
+		directRepo.File = bits[1]
+	}
+	return &directRepo, nil
+}
+
+// isFileURL tells whether a fetch argument, split on slashes, is the URL of
+// a file rather than that of a GitHub repository.
+func isFileURL(bits []string) bool {
+	if bits[0] != "https:" && bits[0] != "http:" {
+		return false
+	}
+	return len(bits) < 3 || bits[2] != "github.com" || len(bits) > 5 && bits[5] != ""
+}
+
+// parseSince parses a duration, also accepting a number of days such as "7d".
+func parseSince(since string) (time.Duration, error) {
+	if since == "" {
+		return 0, nil
+	}
+	if days, found := strings.CutSuffix(since, "d"); found {
+		n, err := strconv.Atoi(days)
+		if err != nil || n < 0 {
+			return 0, fmt.Errorf("%q is not a number of days", since)
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+	return time.ParseDuration(since)
+}
+
+// parseSize parses a size such as "512", "500M" or "2GiB". Units are
+// powers of 1024.
+func parseSize(size string) (int64, error) {
+	number := strings.TrimSuffix(strings.TrimSuffix(strings.ToUpper(size), "B"), "I")
+	multiplier := int64(1)
+	for i, unit := range "KMGT" {
+		if trimmed, found := strings.CutSuffix(number, string(unit)); found {
+			multiplier = 1 << (10 * (i + 1))
+			number = trimmed
 			break
 		}
 	}
+	n, err := strconv.ParseInt(number, 10, 64)
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("%q is not a valid size", size)
+	}
+	return n * multiplier, nil
+}
+
+func containsTag(repoTags []string, tags []string) bool {
+	for _, tag := range tags {
+		for _, repoTag := range repoTags {
+			if tag == repoTag {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// findCommand returns the repository command comes from, configured or
+// fetched directly, exiting if there is none.
+func findCommand(config *gogo.Config, state *gogo.State, command string) *gogo.Repository {
+	if i := slices.IndexFunc(config.Repositories, func(repo gogo.Repository) bool {
+		return repo.File == command
+	}); i >= 0 {
+		return &config.Repositories[i]
+	}
+	// Fetched directly, rather than configured
+	for name, recorded := range state.Repositories {
+		if recorded.File == command {
+			return &gogo.Repository{Name: name, File: command}
+		}
+	}
+	fmt.Fprintf(stdout, "Unknown command: %s\n", command)
+	os.Exit(1)
 	return nil
 }
 
-func writeBinaryFile(filePath string, content io.Reader) error {
-	out, err := os.Create(filePath)
+// doUninstall removes a command, with its utils and completions, and forgets
+// it was installed.
+func doUninstall(configPath string, command string) {
+	config, err := gogo.ReadConfig(configPath)
 	if err != nil {
-		return err
+		fmt.Fprintf(stdout, "Error reading config: %v\n", err)
+		os.Exit(1)
+	}
+	if config.Paths.TargetDir == "" {
+		config.Paths.TargetDir = "."
+	}
+	targetDir, err := gogo.ExpandPath(config.Paths.TargetDir)
+	if err != nil {
+		fmt.Fprintf(stdout, "Error expanding target directory: %v\n", err)
+		os.Exit(1)
 	}
-	defer out.Close()
+	statePath, state := loadState()
+	repo := findCommand(&config, state, command)
 
-	if _, err = io.Copy(out, content); err != nil {
-		return err
+	removed, err := state.Uninstall(repo, targetDir)
+	for _, path := range removed {
+		fmt.Fprintf(stdout, "  - %s\n", path)
+	}
+	if err != nil {
+		fmt.Fprintln(stdout, errorStyle.Render(fmt.Sprintf("Cannot uninstall %s: %v", command, err)))
+		os.Exit(1)
+	}
+	if statePath != "" {
+		if err := state.Save(statePath); err != nil {
+			fmt.Fprintf(stdout, "Error saving state: %v\n", err)
+		}
+	}
+	if len(removed) == 0 {
+		fmt.Fprintln(stdout, warningStyle.Render(fmt.Sprintf("%s is not installed", command)))
+		os.Exit(1)
+	}
+	fmt.Fprintln(stdout, okStyle.Render(fmt.Sprintf("%s uninstalled", command)))
+}
+
+// loadState reads what was previously installed. Failing that, gogo works
+// without it, and the returned path is empty so as not to overwrite it.
+func loadState() (string, *gogo.State) {
+	statePath, err := gogo.StatePath()
+	if err == nil {
+		var state *gogo.State
+		if state, err = gogo.LoadState(statePath); err == nil {
+			return statePath, state
+		}
+	}
+	fmt.Fprintln(stdout, warningStyle.Render(fmt.Sprintf("Warning: cannot read state: %v", err)))
+	return "", gogo.NewState()
+}
+
+// overwrittenSelf returns which of the files a repository installs, to
+// output if set, is the running gogo itself, if any.
+func overwrittenSelf(repo *gogo.Repository, targetDir string, output string) string {
+	self, err := os.Executable()
+	if err != nil {
+		return ""
+	}
+	selfInfo, err := os.Stat(self)
+	if err != nil {
+		return ""
+	}
+	paths := []string{output}
+	if output == "" {
+		paths = nil
+		for _, binary := range repo.Binaries() {
+			paths = append(paths, filepath.Join(targetDir, binary))
+		}
+	}
+	for _, path := range paths {
+		if info, err := os.Stat(path); err == nil && os.SameFile(info, selfInfo) {
+			return path
+		}
+	}
+	return ""
+}
+
+// existingFiles returns which of the files showing that a repository is
+// installed exist, along with the whole list.
+func existingFiles(repo *gogo.Repository, targetDir string) ([]string, []string) {
+	checkFiles := repo.Binaries()
+	var existing []string
+	for _, checkFile := range checkFiles {
+		if gogo.ExistFile(filepath.Join(targetDir, checkFile)) {
+			existing = append(existing, checkFile)
+		}
+	}
+	return existing, checkFiles
+}
+
+// confirmOverwrite asks before replacing existing files. Without a terminal
+// to ask, nothing is overwritten.
+func confirmOverwrite(stdin *bufio.Reader, files []string) bool {
+	if !isatty.IsTerminal(os.Stdin.Fd()) {
+		fmt.Fprintf(stdout, "  %s\n", warningStyle.Render(fmt.Sprintf("! not overwriting existing %s (use -yes)", strings.Join(files, ", "))))
+		return false
+	}
+	fmt.Fprintf(stdout, "  overwrite existing %s? [y/N] ", strings.Join(files, ", "))
+	answer, _ := stdin.ReadString('\n')
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		return true
 	}
+	return false
+}
 
-	if err = os.Chmod(filePath, 0o755); err != nil {
+// checkTargetDir makes sure commands can be written to targetDir, which is
+// created if missing and create is set.
+func checkTargetDir(targetDir string, create bool) error {
+	info, err := os.Stat(targetDir)
+	if os.IsNotExist(err) && create {
+		if err := os.MkdirAll(targetDir, 0o755); err != nil {
+			return err
+		}
+		fmt.Fprintf(stdout, "Created target directory %s\n", targetDir)
+		info, err = os.Stat(targetDir)
+	}
+	if err != nil {
 		return err
 	}
+	if !info.IsDir() {
+		return fmt.Errorf("target directory %s is not a directory", targetDir)
+	}
+	tmpFile, err := os.CreateTemp(targetDir, "write_test_*")
+	if err != nil {
+		return fmt.Errorf("target directory %s is not writable", targetDir)
+	}
+	tmpFile.Close()
+	os.Remove(tmpFile.Name())
 
 	return nil
 }
 
 func verbosePrintf(format string, a ...any) {
-	fmt.Printf(format, a...)
+	fmt.Fprintf(stdout, format, a...)
+}
+
+// porcelain prints, with -porcelain, one tab-separated line per event of a
+// fetch, so that other programs can follow it. A nil porcelain prints nothing.
+type porcelain struct {
+	mu     sync.Mutex
+	writer io.Writer
+}
+
+func (p *porcelain) emit(fields ...string) {
+	if p == nil {
+		return
+	}
+	for i, field := range fields {
+		fields[i] = strings.Map(func(r rune) rune {
+			if r == '\t' || r == '\n' || r == '\r' {
+				return ' '
+			}
+			return r
+		}, field)
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	fmt.Fprintln(p.writer, strings.Join(fields, "\t"))
+}
+
+// preflight tells what was found out about a repository: with an OK status,
+// the asset to install, with others, why not.
+func (p *porcelain) preflight(repoStatus *gogo.RepoStatus) {
+	var status string
+	switch repoStatus.Status {
+	case gogo.RepoOK:
+		p.emit("preflight", repoStatus.Repo.Name, "OK", repoStatus.Asset)
+		return
+	case gogo.RepoExist:
+		status = "EXIST"
+	case gogo.RepoSkipped:
+		status = "SKIPPED"
+	default:
+		status = "KO"
+	}
+	p.emit("preflight", repoStatus.Repo.Name, status, repoStatus.Message)
+}
+
+var spinnerFrames = []string{"⠋", "⠙", "⠹", "⠸", "⠼", "⠴", "⠦", "⠧", "⠇", "⠏"}
+
+// preflightProgress tells how far preflight is, as checking many repositories
+// takes a while. On a terminal, a line is animated in place while each
+// repository is checked; otherwise, a line is printed every few seconds.
+type preflightProgress struct {
+	total       int
+	live        bool
+	lastPrinted time.Time
+	done        chan struct{}
+	stopped     chan struct{}
+}
+
+func newPreflightProgress(total int, live bool) *preflightProgress {
+	return &preflightProgress{total: total, live: live, lastPrinted: time.Now()}
+}
+
+// start shows that the count-th repository is being checked. Nothing else
+// may be printed until stop is called.
+func (p *preflightProgress) start(count int, name string) {
+	if !p.live {
+		if time.Since(p.lastPrinted) >= 5*time.Second {
+			fmt.Fprintf(stdout, "  ... checking %d/%d: %s\n", count, p.total, name)
+			p.lastPrinted = time.Now()
+		}
+		return
+	}
+	p.done = make(chan struct{})
+	p.stopped = make(chan struct{})
+	go func(done <-chan struct{}, stopped chan<- struct{}) {
+		defer close(stopped)
+		ticker := time.NewTicker(100 * time.Millisecond)
+		defer ticker.Stop()
+		for frame := 0; ; frame++ {
+			fmt.Fprintf(stdout, "\r\033[K%s checking %d/%d: %s", okStyle.Render(spinnerFrames[frame%len(spinnerFrames)]), count, p.total, name)
+			select {
+			case <-done:
+				fmt.Fprint(stdout, "\r\033[K")
+				return
+			case <-ticker.C:
+			}
+		}
+	}(p.done, p.stopped)
+}
+
+// stop clears the line shown by start.
+func (p *preflightProgress) stop() {
+	if p.done == nil {
+		return
+	}
+	close(p.done)
+	<-p.stopped
+	p.done = nil
 }
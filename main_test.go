@@ -0,0 +1,200 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/fusion/gogo/pkg/gogo"
+)
+
+func TestFetchOutputJSON(t *testing.T) {
+	repoStatusList := []gogo.RepoStatus{
+		{Repo: &gogo.Repository{Name: "owner/one", File: "one"}, Status: gogo.RepoOK, Tag: "v1.0.0"},
+		{Repo: &gogo.Repository{Name: "owner/two", File: "two"}, Status: gogo.RepoOK},
+		{Repo: &gogo.Repository{Name: "owner/three", File: "three"}, Status: gogo.RepoExist},
+		{Repo: &gogo.Repository{Name: "owner/four", File: "four"}, Status: gogo.RepoSkipped},
+		{Repo: &gogo.Repository{Name: "owner/five", File: "five"}, Status: gogo.RepoKO},
+	}
+	errs := []error{nil, fmt.Errorf("download failed"), nil, nil, nil}
+	var out bytes.Buffer
+	printJSON(&out, fetchOutput{
+		Repositories: fetchResults(repoStatusList, errs),
+		Summary:      countFetched(repoStatusList, 1),
+	})
+
+	var decoded struct {
+		Repositories []struct {
+			File   string `json:"file"`
+			Status string `json:"status"`
+		} `json:"repositories"`
+		Summary map[string]int `json:"summary"`
+	}
+	if err := json.Unmarshal(out.Bytes(), &decoded); err != nil {
+		t.Fatalf("cannot decode %s: %v", out.String(), err)
+	}
+	var statuses []string
+	for _, repo := range decoded.Repositories {
+		statuses = append(statuses, repo.File+":"+repo.Status)
+	}
+	if got, want := fmt.Sprint(statuses), "[one:ok two:failed three:exist four:skipped five:unavailable]"; got != want {
+		t.Errorf("repositories = %s, want %s", got, want)
+	}
+	want := map[string]int{"installed": 1, "already_present": 1, "skipped": 1, "unavailable": 1, "failed": 1}
+	if fmt.Sprint(decoded.Summary) != fmt.Sprint(want) {
+		t.Errorf("summary = %v, want %v", decoded.Summary, want)
+	}
+}
+
+func TestOrderedOutput(t *testing.T) {
+	type step func(o *orderedOutput, sections map[string]io.WriteCloser)
+	open := func(name string) step {
+		return func(o *orderedOutput, sections map[string]io.WriteCloser) { sections[name] = o.Section() }
+	}
+	write := func(name string, text string) step {
+		return func(o *orderedOutput, sections map[string]io.WriteCloser) { io.WriteString(sections[name], text) }
+	}
+	closeSection := func(name string) step {
+		return func(o *orderedOutput, sections map[string]io.WriteCloser) { sections[name].Close() }
+	}
+	printOut := func(text string) step {
+		return func(o *orderedOutput, sections map[string]io.WriteCloser) { io.WriteString(o, text) }
+	}
+	interject := func(text string) step {
+		return func(o *orderedOutput, sections map[string]io.WriteCloser) { o.Interject([]byte(text)) }
+	}
+	live := func(text string) step {
+		return func(o *orderedOutput, sections map[string]io.WriteCloser) {
+			if text == "" {
+				o.SetLive(nil)
+			} else {
+				o.SetLive(func() string { return text })
+			}
+		}
+	}
+	redraw := func(o *orderedOutput, sections map[string]io.WriteCloser) { o.Redraw() }
+	// What clears a single live line
+	const clearLine = "\033[1F\033[J"
+
+	tests := []struct {
+		name  string
+		steps []step
+		want  string
+	}{
+		{"without sections", []step{printOut("a\n"), printOut("b\n")}, "a\nb\n"},
+		{"sections in opening order", []step{
+			open("a"), open("b"), open("c"),
+			write("c", "c\n"), closeSection("c"), write("b", "b\n"), closeSection("b"),
+			write("a", "a1\n"), write("a", "a2\n"), closeSection("a"),
+		}, "a1\na2\nb\nc\n"},
+		{"writes after pending sections", []step{
+			open("a"), printOut("p\n"), open("b"), write("b", "b\n"), closeSection("b"), write("a", "a\n"), closeSection("a"),
+		}, "a\np\nb\n"},
+		{"interjections before pending sections", []step{
+			open("a"), write("a", "a\n"), interject("i\n"), closeSection("a"),
+		}, "i\na\n"},
+		{"live lines below everything", []step{
+			live("L"), open("a"), open("b"), write("b", "b\n"), closeSection("b"),
+			interject("i\n"), write("a", "a\n"), closeSection("a"), redraw, live(""),
+		}, "L\n" + clearLine + "i\nL\n" + clearLine + "a\nL\n" + clearLine + "b\nL\n" + clearLine + "L\n" + clearLine},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			o := newOrderedOutput(&out)
+			sections := map[string]io.WriteCloser{}
+			for _, step := range tt.steps {
+				step(o, sections)
+			}
+			if got := out.String(); got != tt.want {
+				t.Errorf("output = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFuzzyMatch(t *testing.T) {
+	for _, tt := range []struct {
+		pattern string
+		s       string
+		want    bool
+	}{
+		{"", "ripgrep", true},
+		{"rg", "ripgrep", true},
+		{"RG", "ripgrep", true},
+		{"gr", "rg", false},
+		{"rgx", "ripgrep", false},
+		{"éz", "Élan-zip", true},
+	} {
+		if got := fuzzyMatch(tt.pattern, tt.s); got != tt.want {
+			t.Errorf("fuzzyMatch(%q, %q) = %v, want %v", tt.pattern, tt.s, got, tt.want)
+		}
+	}
+}
+
+func TestPicker(t *testing.T) {
+	repos := gogo.Repositories{
+		{Name: "BurntSushi/ripgrep", File: "rg", Tags: []string{"search"}},
+		{Name: "sharkdp/fd", File: "fd", Tags: []string{"search", "files"}},
+		{Name: "sharkdp/bat", File: "bat"},
+		{Name: "owner/élan", File: "élan"},
+	}
+	runes := func(s string) tea.KeyMsg { return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(s)} }
+	key := func(keyType tea.KeyType) tea.KeyMsg { return tea.KeyMsg{Type: keyType} }
+
+	tests := []struct {
+		name      string
+		keys      []tea.KeyMsg
+		visible   []string
+		selection []string
+		confirmed bool
+	}{
+		{"nothing typed", []tea.KeyMsg{key(tea.KeyEnter)}, []string{"rg", "fd", "bat", "élan"}, nil, true},
+		{"filter by name", []tea.KeyMsg{runes("b"), runes("t")}, []string{"bat"}, nil, false},
+		{"filter by tag", []tea.KeyMsg{runes("srch")}, []string{"rg", "fd"}, nil, false},
+		{"multi-byte filter", []tea.KeyMsg{runes("é")}, []string{"élan"}, nil, false},
+		{"backspace", []tea.KeyMsg{runes("élx"), key(tea.KeyBackspace)}, []string{"élan"}, nil, false},
+		{"backspace over a multi-byte rune", []tea.KeyMsg{runes("é"), key(tea.KeyBackspace)}, []string{"rg", "fd", "bat", "élan"}, nil, false},
+		{"check in listed order", []tea.KeyMsg{
+			key(tea.KeyDown), key(tea.KeyDown), key(tea.KeySpace), key(tea.KeyUp), key(tea.KeyUp), key(tea.KeyUp), key(tea.KeyTab), key(tea.KeyEnter),
+		}, []string{"rg", "fd", "bat", "élan"}, []string{"rg", "bat"}, true},
+		{"uncheck", []tea.KeyMsg{key(tea.KeySpace), key(tea.KeySpace), key(tea.KeyDown), key(tea.KeySpace)}, []string{"rg", "fd", "bat", "élan"}, []string{"fd"}, false},
+		{"cursor stays in the list", []tea.KeyMsg{
+			runes("fd"), key(tea.KeyDown), key(tea.KeyDown), key(tea.KeySpace), key(tea.KeyEnter),
+		}, []string{"fd"}, []string{"fd"}, true},
+		{"check all shown", []tea.KeyMsg{runes("s"), key(tea.KeyCtrlA)}, []string{"rg", "fd"}, []string{"rg", "fd"}, false},
+		{"uncheck all shown", []tea.KeyMsg{key(tea.KeyCtrlA), runes("search"), key(tea.KeyCtrlA)}, []string{"rg", "fd"}, []string{"bat", "élan"}, false},
+		{"cancel", []tea.KeyMsg{key(tea.KeySpace), key(tea.KeyEsc)}, []string{"rg", "fd", "bat", "élan"}, []string{"rg"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := newPicker(repos, gogo.NewState())
+			var quit bool
+			for _, msg := range tt.keys {
+				if quit {
+					t.Fatalf("%v handled after quitting", msg)
+				}
+				if _, cmd := p.Update(msg); cmd != nil {
+					_, quit = cmd().(tea.QuitMsg)
+				}
+			}
+			var visible []string
+			for _, i := range p.visible {
+				visible = append(visible, repos[i].File)
+			}
+			if !slices.Equal(visible, tt.visible) {
+				t.Errorf("visible = %v, want %v", visible, tt.visible)
+			}
+			if !slices.Equal(p.selection(), tt.selection) {
+				t.Errorf("selection() = %v, want %v", p.selection(), tt.selection)
+			}
+			if p.confirmed != tt.confirmed {
+				t.Errorf("confirmed = %v, want %v", p.confirmed, tt.confirmed)
+			}
+		})
+	}
+}
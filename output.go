@@ -0,0 +1,164 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/mattn/go-isatty"
+)
+
+// Output is where commands print. Work done concurrently, e.g. for several
+// repositories, prints to sections of it, which come out whole and in the
+// order they were opened, however their work interleaves.
+type Output interface {
+	io.Writer
+	// Section returns a buffered writer, flushed once closed and once every
+	// section opened before it has been flushed.
+	Section() io.WriteCloser
+}
+
+// stdout is the output of every command. -porcelain points it to stderr.
+var stdout = newOrderedOutput(os.Stdout)
+
+var _ Output = (*orderedOutput)(nil)
+
+type orderedOutput struct {
+	mu      sync.Mutex
+	writer  io.Writer
+	pending []*outputSection
+	// live, if set, returns lines kept below everything printed, such as
+	// progress bars, liveLines of which are on screen
+	live      func() string
+	liveLines int
+}
+
+type outputSection struct {
+	output *orderedOutput
+	buffer bytes.Buffer
+	closed bool
+}
+
+func newOrderedOutput(writer io.Writer) *orderedOutput {
+	return &orderedOutput{writer: writer}
+}
+
+// Write prints right away, unless sections are pending, in which case it
+// prints after them.
+func (o *orderedOutput) Write(p []byte) (int, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if len(o.pending) == 0 {
+		return o.write(p)
+	}
+	section := &outputSection{output: o, closed: true}
+	section.buffer.Write(p)
+	o.pending = append(o.pending, section)
+	return len(p), nil
+}
+
+func (o *orderedOutput) Section() io.WriteCloser {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	section := &outputSection{output: o}
+	o.pending = append(o.pending, section)
+	return section
+}
+
+// SetWriter changes where the output goes.
+func (o *orderedOutput) SetWriter(writer io.Writer) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.writer = writer
+}
+
+// IsTerminal tells whether the output goes to a terminal, to decide whether
+// to show live progress.
+func (o *orderedOutput) IsTerminal() bool {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	file, ok := o.writer.(*os.File)
+	return ok && isatty.IsTerminal(file.Fd())
+}
+
+// write prints p above the live lines, if any.
+func (o *orderedOutput) write(p []byte) (int, error) {
+	if o.live == nil {
+		return o.writer.Write(p)
+	}
+	o.clearLive()
+	n, err := o.writer.Write(p)
+	o.drawLive()
+	return n, err
+}
+
+// SetLive keeps the lines returned by live below everything printed, until
+// SetLive(nil) removes them. They are drawn again by Redraw.
+func (o *orderedOutput) SetLive(live func() string) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.clearLive()
+	o.live = live
+	o.drawLive()
+}
+
+// Redraw draws the live lines again, as they changed.
+func (o *orderedOutput) Redraw() {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.clearLive()
+	o.drawLive()
+}
+
+// Interject prints p right away, even before pending sections, for news that
+// cannot wait such as how far downloads are.
+func (o *orderedOutput) Interject(p []byte) (int, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.write(p)
+}
+
+func (o *orderedOutput) clearLive() {
+	if o.liveLines > 0 {
+		fmt.Fprintf(o.writer, "\033[%dF\033[J", o.liveLines)
+		o.liveLines = 0
+	}
+}
+
+func (o *orderedOutput) drawLive() {
+	if o.live == nil {
+		return
+	}
+	if lines := o.live(); lines != "" {
+		lines = strings.TrimSuffix(lines, "\n") + "\n"
+		io.WriteString(o.writer, lines)
+		o.liveLines = strings.Count(lines, "\n")
+	}
+}
+
+// flush prints the closed sections at the head of the queue.
+func (o *orderedOutput) flush() error {
+	for len(o.pending) > 0 && o.pending[0].closed {
+		if _, err := o.write(o.pending[0].buffer.Bytes()); err != nil {
+			return err
+		}
+		o.pending = o.pending[1:]
+	}
+	return nil
+}
+
+func (s *outputSection) Write(p []byte) (int, error) {
+	s.output.mu.Lock()
+	defer s.output.mu.Unlock()
+	return s.buffer.Write(p)
+}
+
+func (s *outputSection) Close() error {
+	s.output.mu.Lock()
+	defer s.output.mu.Unlock()
+	s.closed = true
+	return s.output.flush()
+}
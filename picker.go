@@ -0,0 +1,190 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/fusion/gogo/pkg/gogo"
+	"github.com/mattn/go-isatty"
+)
+
+var (
+	cursorStyle   = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("99"))
+	selectedStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#00FF00"))
+	dimStyle      = lipgloss.NewStyle().Foreground(lipgloss.Color("241"))
+)
+
+// picker lets the user check the repositories to fetch, filtering them by
+// typing part of their name or tags.
+type picker struct {
+	repos    gogo.Repositories
+	state    *gogo.State
+	selected map[string]bool
+	filter   string
+	// visible indexes the repositories matching the filter
+	visible []int
+	cursor  int
+	offset  int
+	height  int
+	// confirmed tells whether the user is done and wants the selection
+	// fetched, rather than cancelled
+	confirmed bool
+}
+
+var _ tea.Model = (*picker)(nil)
+
+func newPicker(repos gogo.Repositories, state *gogo.State) *picker {
+	p := &picker{repos: repos, state: state, selected: map[string]bool{}, height: 24}
+	p.applyFilter()
+	return p
+}
+
+// fuzzyMatch tells whether the letters of pattern appear in s in that order,
+// whatever their case.
+func fuzzyMatch(pattern string, s string) bool {
+	s = strings.ToLower(s)
+	for _, r := range strings.ToLower(pattern) {
+		i := strings.IndexRune(s, r)
+		if i < 0 {
+			return false
+		}
+		s = s[i+len(string(r)):]
+	}
+	return true
+}
+
+func (p *picker) applyFilter() {
+	p.visible = p.visible[:0]
+	for i, repo := range p.repos {
+		if fuzzyMatch(p.filter, repo.File) || slices.ContainsFunc(repo.Tags, func(tag string) bool { return fuzzyMatch(p.filter, tag) }) {
+			p.visible = append(p.visible, i)
+		}
+	}
+	p.cursor = min(p.cursor, max(len(p.visible)-1, 0))
+}
+
+func (p *picker) Init() tea.Cmd {
+	return nil
+}
+
+// Update handles key presses and the terminal being resized, quitting once
+// the user confirmed or cancelled their selection.
+func (p *picker) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		p.height = msg.Height
+	case tea.KeyMsg:
+		switch msg.Type {
+		case tea.KeyEnter:
+			p.confirmed = true
+			return p, tea.Quit
+		case tea.KeyEsc, tea.KeyCtrlC:
+			return p, tea.Quit
+		case tea.KeyUp, tea.KeyCtrlP:
+			p.cursor = max(p.cursor-1, 0)
+		case tea.KeyDown, tea.KeyCtrlN:
+			p.cursor = min(p.cursor+1, max(len(p.visible)-1, 0))
+		case tea.KeySpace, tea.KeyTab:
+			if len(p.visible) > 0 {
+				file := p.repos[p.visible[p.cursor]].File
+				p.selected[file] = !p.selected[file]
+			}
+		case tea.KeyCtrlA:
+			// Selects every repository shown, or unselects them if all are
+			all := !slices.ContainsFunc(p.visible, func(i int) bool { return !p.selected[p.repos[i].File] })
+			for _, i := range p.visible {
+				p.selected[p.repos[i].File] = !all
+			}
+		case tea.KeyBackspace:
+			if p.filter != "" {
+				runes := []rune(p.filter)
+				p.filter = string(runes[:len(runes)-1])
+				p.applyFilter()
+			}
+		case tea.KeyRunes:
+			if !msg.Alt {
+				p.filter += string(msg.Runes)
+				p.applyFilter()
+			}
+		}
+	}
+	return p, nil
+}
+
+// View draws the picker, fitting the terminal's height.
+func (p *picker) View() string {
+	var view strings.Builder
+	fmt.Fprintf(&view, "Select commands to fetch: %s\n", cursorStyle.Render(p.filter+"_"))
+	fmt.Fprintf(&view, "%s\n\n", dimStyle.Render("type to filter, ↑/↓ to move, space to check, ctrl-a for all, enter to fetch, esc to cancel"))
+	rows := max(p.height-5, 1)
+	if p.cursor < p.offset {
+		p.offset = p.cursor
+	} else if p.cursor >= p.offset+rows {
+		p.offset = p.cursor - rows + 1
+	}
+	p.offset = min(p.offset, max(len(p.visible)-rows, 0))
+	for line, i := range p.visible[p.offset:min(p.offset+rows, len(p.visible))] {
+		repo := p.repos[i]
+		prefix, box := "  ", "[ ]"
+		if p.offset+line == p.cursor {
+			prefix = cursorStyle.Render("> ")
+		}
+		if p.selected[repo.File] {
+			box = selectedStyle.Render("[x]")
+		}
+		details := repo.Name
+		if recorded, ok := p.state.Repositories[repo.Name]; ok && recorded.Tag != "" {
+			details += ", installed " + recorded.Tag
+		}
+		if len(repo.Tags) > 0 {
+			details += " (" + strings.Join(repo.Tags, ", ") + ")"
+		}
+		fmt.Fprintf(&view, "%s%s %s %s\n", prefix, box, repo.File, dimStyle.Render(details))
+	}
+	if len(p.visible) == 0 {
+		fmt.Fprintf(&view, "  %s\n", dimStyle.Render("no command matches"))
+	}
+	fmt.Fprintf(&view, "\n%d of %d selected", p.count(), len(p.repos))
+	return view.String()
+}
+
+func (p *picker) count() int {
+	count := 0
+	for _, checked := range p.selected {
+		if checked {
+			count++
+		}
+	}
+	return count
+}
+
+// selection returns the checked commands, in the order they are listed.
+func (p *picker) selection() []string {
+	var commands []string
+	for _, repo := range p.repos {
+		if p.selected[repo.File] {
+			commands = append(commands, repo.File)
+		}
+	}
+	return commands
+}
+
+// pickCommands lets the user pick among repos the commands to fetch, on the
+// terminal's alternate screen. None are returned if the user cancelled.
+func pickCommands(repos gogo.Repositories, state *gogo.State) ([]string, error) {
+	if !isatty.IsTerminal(os.Stdin.Fd()) || !isatty.IsTerminal(os.Stdout.Fd()) {
+		return nil, fmt.Errorf("-i needs a terminal")
+	}
+	model, err := tea.NewProgram(newPicker(repos, state), tea.WithAltScreen()).Run()
+	if err != nil {
+		return nil, err
+	}
+	if p := model.(*picker); p.confirmed {
+		return p.selection(), nil
+	}
+	return nil, nil
+}
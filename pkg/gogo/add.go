@@ -0,0 +1,240 @@
+package gogo
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+)
+
+// manPage matches man pages, e.g. tool.1 or tool.1.gz, capturing their
+// section.
+var manPage = regexp.MustCompile(`\.([1-9])(\.gz)?$`)
+
+// archiveEntry is a file found in an archive.
+type archiveEntry struct {
+	name string
+	mode os.FileMode
+}
+
+// DescribeRepository works out how to configure the repository named name
+// from its latest release: the command its asset for host installs and,
+// when the asset is an archive, the other commands, man pages and
+// completions it holds. The repository's description is its comment.
+func (c *Client) DescribeRepository(name string, host Host) (*Repository, error) {
+	repo := &Repository{Name: NormalizeName(name), File: path.Base(name)}
+	if err := repo.CheckName(); err != nil {
+		return nil, err
+	}
+	status, err := c.ResolveAsset(repo, host)
+	if err != nil {
+		return nil, err
+	}
+	if status.Status != RepoOK {
+		return nil, fmt.Errorf("no asset to install: %s", status.Message)
+	}
+	var info struct {
+		Description string `json:"description"`
+	}
+	if _, err := c.getAPI(repo, fmt.Sprintf("%s/repos/%s", c.APIURL, repo.Name), &info); err == nil {
+		repo.Comment = info.Description
+	}
+	if status.Format.singleCommand() || status.Format == DebFormat || status.Format == RpmFormat {
+		return repo, nil
+	}
+
+	tmpPath, err := newWorkDir()
+	if err != nil {
+		return nil, fmt.Errorf("error creating temp file: %v", err)
+	}
+	defer os.RemoveAll(tmpPath)
+	assetPath := filepath.Join(tmpPath, "asset")
+	if err := fetchResumable(c.context(), c.HTTP, c.Token, c.repoHeaders(repo), c.RateLimiter, status.Url, assetPath, nil, nil); err != nil {
+		return nil, err
+	}
+	entries, err := listArchive(c.context(), status.Format, assetPath)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read %s: %v", status.Asset, err)
+	}
+	describeArchive(repo, entries)
+	return repo, nil
+}
+
+// describeArchive configures repo to install the executables, man pages and
+// completions found in an archive. Of several executables, the one named like
+// the repository is the command, the others are listed in files.
+func describeArchive(repo *Repository, entries []archiveEntry) {
+	var executables []string
+	for _, entry := range entries {
+		name := path.Base(entry.name)
+		switch ext := path.Ext(name); {
+		case entry.mode&0o111 != 0 && (ext == "" || ext == ".exe") && !slices.Contains(executables, name):
+			executables = append(executables, name)
+		case manPage.MatchString(name):
+			repo.Utils = append(repo.Utils, name)
+			if repo.UtilsDest == nil {
+				repo.UtilsDest = map[string]string{}
+			}
+			repo.UtilsDest[name] = "../share/man/man" + manPage.FindStringSubmatch(name)[1]
+		}
+	}
+	if len(executables) > 0 {
+		main := slices.IndexFunc(executables, func(name string) bool {
+			return strings.EqualFold(strings.TrimSuffix(name, ".exe"), repo.File)
+		})
+		if main < 0 {
+			// The repository is rarely named like a helper
+			main = 0
+		}
+		repo.File = strings.TrimSuffix(executables[main], ".exe")
+		for i, name := range executables {
+			if i != main {
+				repo.Files = append(repo.Files, strings.TrimSuffix(name, ".exe"))
+			}
+		}
+	}
+	for _, shell := range []string{"bash", "fish", "zsh"} {
+		patterns, _ := completionShells[shell](repo.File)
+		if slices.ContainsFunc(entries, func(entry archiveEntry) bool {
+			return slices.ContainsFunc(patterns, func(pattern string) bool { return matchUtil(pattern, entry.name) })
+		}) {
+			repo.Completions = append(repo.Completions, shell)
+		}
+	}
+}
+
+// listArchive lists the regular files of a tarball or zip archive.
+func listArchive(ctx context.Context, format EAssetFormat, archivePath string) (entries []archiveEntry, err error) {
+	if format == ZipFormat {
+		zipReader, err := zip.OpenReader(archivePath)
+		if err != nil {
+			return nil, err
+		}
+		defer zipReader.Close()
+		for _, file := range zipReader.File {
+			if file.Mode().IsRegular() {
+				entries = append(entries, archiveEntry{file.Name, file.Mode()})
+			}
+		}
+		return entries, nil
+	}
+	file, err := os.Open(archivePath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	reader, err := decompress(ctx, bufio.NewReader(file))
+	if err != nil {
+		return nil, err
+	}
+	defer closeReader(reader, &err)
+	tarReader := tar.NewReader(reader)
+	for {
+		header, err := tarReader.Next()
+		if err == io.EOF {
+			return entries, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if header.Typeflag == tar.TypeReg {
+			entries = append(entries, archiveEntry{header.Name, header.FileInfo().Mode()})
+		}
+	}
+}
+
+// Snippet returns the repository's configuration, as a [[repositories]]
+// block to be added to a configuration file.
+func (r *Repository) Snippet() string {
+	type snippetRepo struct {
+		Name        string            `toml:"name"`
+		File        string            `toml:"file"`
+		Files       []string          `toml:"files,omitempty"`
+		Utils       []string          `toml:"utils,omitempty"`
+		UtilsDest   map[string]string `toml:"utils_dest,omitempty"`
+		Completions []string          `toml:"completions,omitempty"`
+		Comment     string            `toml:"comment,omitempty"`
+		Tags        []string          `toml:"tags,omitempty"`
+	}
+	var snippet bytes.Buffer
+	encoder := toml.NewEncoder(&snippet)
+	encoder.Indent = ""
+	encoder.Encode(struct {
+		Repositories []snippetRepo `toml:"repositories"`
+	}{[]snippetRepo{{
+		Name:        r.Name,
+		File:        r.File,
+		Files:       r.Files,
+		Utils:       r.Utils,
+		UtilsDest:   r.UtilsDest,
+		Completions: r.Completions,
+		Comment:     r.Comment,
+		Tags:        r.Tags,
+	}}})
+	return strings.TrimSpace(snippet.String())
+}
+
+// AddToConfig appends the repository's configuration to the configuration at
+// configPath, returning the file written. A configuration directory gets it in
+// its config.toml, or in a file of its own named after the command if there is
+// no config.toml or newFile is set.
+func AddToConfig(configPath string, repo *Repository, newFile bool) (string, error) {
+	if strings.Contains(configPath, ",") {
+		return "", fmt.Errorf("cannot tell which of %s to add to", configPath)
+	}
+	info, err := os.Stat(configPath)
+	if err != nil {
+		return "", err
+	}
+	configFile := configPath
+	switch {
+	case info.IsDir():
+		configFile = filepath.Join(configPath, "config.toml")
+		if _, err := os.Stat(configFile); newFile || os.IsNotExist(err) {
+			configFile = filepath.Join(configPath, repo.File+".toml")
+			if _, err := os.Stat(configFile); err == nil {
+				return "", fmt.Errorf("%s already exists", configFile)
+			}
+		}
+	case newFile:
+		return "", fmt.Errorf("%s is a file, not a configuration directory", configPath)
+	}
+
+	snippet := repo.Snippet()
+	var check Config
+	if _, err := toml.Decode(snippet, &check); err != nil {
+		return "", fmt.Errorf("invalid configuration for %s: %v", repo.Name, err)
+	}
+	content, err := os.ReadFile(configFile)
+	if err != nil && !os.IsNotExist(err) {
+		return "", err
+	}
+	switch {
+	case len(content) == 0:
+	case bytes.HasSuffix(content, []byte("\n\n")):
+	case bytes.HasSuffix(content, []byte("\n")):
+		snippet = "\n" + snippet
+	default:
+		snippet = "\n\n" + snippet
+	}
+	file, err := os.OpenFile(configFile, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o644)
+	if err != nil {
+		return "", err
+	}
+	if _, err := file.WriteString(snippet + "\n"); err != nil {
+		file.Close()
+		return "", err
+	}
+	return configFile, file.Close()
+}
@@ -0,0 +1,132 @@
+package gogo
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/BurntSushi/toml"
+)
+
+func TestDescribeRepository(t *testing.T) {
+	var archive bytes.Buffer
+	gzipWriter := gzip.NewWriter(&archive)
+	tw := tar.NewWriter(gzipWriter)
+	for name, mode := range map[string]int64{
+		"tool-1.0/tool":                  0o755,
+		"tool-1.0/toolctl":               0o755,
+		"tool-1.0/install.sh":            0o755,
+		"tool-1.0/README.md":             0o644,
+		"tool-1.0/doc/tool.1":            0o644,
+		"tool-1.0/completions/tool.bash": 0o644,
+		"tool-1.0/completions/_tool":     0o644,
+	} {
+		tw.WriteHeader(&tar.Header{Name: name, Typeflag: tar.TypeReg, Mode: mode, Size: 1})
+		tw.Write([]byte("x"))
+	}
+	tw.Close()
+	gzipWriter.Close()
+
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/repos/owner/tool":
+			fmt.Fprint(w, `{"full_name": "owner/tool", "description": "Does things"}`)
+		case "/repos/owner/tool/releases/latest":
+			fmt.Fprintf(w, `{"id": 1, "tag_name": "v1.0", "assets": [{"id": 2, "name": "tool-linux-amd64.tar.gz", "browser_download_url": "%s/tool.tar.gz"}]}`, server.URL)
+		case "/repos/owner/single/releases/latest":
+			fmt.Fprint(w, `{"id": 3, "tag_name": "v2.0", "assets": [{"id": 4, "name": "single-linux-amd64"}]}`)
+		case "/tool.tar.gz":
+			w.Write(archive.Bytes())
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+	client := &Client{HTTP: server.Client(), APIURL: server.URL}
+	host := Host{"linux", "amd64", "glibc"}
+
+	repo, err := client.DescribeRepository("owner/tool", host)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &Repository{
+		Name:        "owner/tool",
+		File:        "tool",
+		Files:       []string{"toolctl"},
+		Utils:       []string{"tool.1"},
+		UtilsDest:   map[string]string{"tool.1": "../share/man/man1"},
+		Completions: []string{"bash", "zsh"},
+		Comment:     "Does things",
+	}
+	if !reflect.DeepEqual(repo, want) {
+		t.Errorf("DescribeRepository() = %+v, want %+v", repo, want)
+	}
+	var config Config
+	if _, err := toml.Decode(repo.Snippet(), &config); err != nil || len(config.Repositories) != 1 || !reflect.DeepEqual(&config.Repositories[0], want) {
+		t.Errorf("snippet %q configures %+v (%v)", repo.Snippet(), config.Repositories, err)
+	}
+
+	// A lone binary is installed as the repository's command
+	if repo, err = client.DescribeRepository("owner/single", host); err != nil || repo.File != "single" || repo.Files != nil {
+		t.Errorf("DescribeRepository(owner/single) = %+v, %v", repo, err)
+	}
+	if _, err = client.DescribeRepository("owner/missing", host); err == nil {
+		t.Error("DescribeRepository(owner/missing) succeeded")
+	}
+}
+
+func TestAddToConfig(t *testing.T) {
+	repo := &Repository{Name: "owner/tool", File: "tool", Utils: []string{"tool.1"}, UtilsDest: map[string]string{"tool.1": "../share/man/man1"}}
+	dir := t.TempDir()
+	configFile := filepath.Join(dir, "config.toml")
+	os.WriteFile(configFile, []byte("[paths]\ntargetdir = \"~/bin\"\n\n[[repositories]]\nname = \"owner/other\"\nfile = \"other\""), 0o644)
+
+	tests := []struct {
+		configPath string
+		newFile    bool
+		want       string
+	}{
+		{dir, false, configFile},
+		{configFile, false, configFile},
+		{dir, true, filepath.Join(dir, "tool.toml")},
+	}
+	for _, tt := range tests {
+		if got, err := AddToConfig(tt.configPath, repo, tt.newFile); err != nil || got != tt.want {
+			t.Errorf("AddToConfig(%s, %t) = %s, %v, want %s", tt.configPath, tt.newFile, got, err, tt.want)
+		}
+	}
+	config, err := ReadConfig(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, configured := range config.Repositories {
+		names = append(names, configured.Name)
+		if configured.Name == "owner/tool" && configured.UtilsDest["tool.1"] != "../share/man/man1" {
+			t.Errorf("added %+v, want %+v", configured, repo)
+		}
+	}
+	if want := []string{"owner/other", "owner/tool", "owner/tool", "owner/tool"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("configured %v, want %v", names, want)
+	}
+
+	for _, configPath := range []string{dir + "," + configFile, filepath.Join(dir, "missing")} {
+		if _, err := AddToConfig(configPath, repo, false); err == nil {
+			t.Errorf("AddToConfig(%s) succeeded", configPath)
+		}
+	}
+	if _, err := AddToConfig(dir, repo, true); err == nil {
+		t.Error("AddToConfig() overwrote tool.toml")
+	}
+	if _, err := AddToConfig(configFile, repo, true); err == nil {
+		t.Error("AddToConfig(-new-file) added a file next to a configuration file")
+	}
+}
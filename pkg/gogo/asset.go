@@ -0,0 +1,974 @@
+package gogo
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"slices"
+	"strings"
+	"time"
+)
+
+type ReleaseAsset struct {
+	ID                 int64  `json:"id"`
+	BrowserDownloadURL string `json:"browser_download_url"`
+	Name               string `json:"name"`
+}
+
+type EAssetFormat int
+
+const (
+	BinaryFormat EAssetFormat = iota
+	TarballFormat
+	TargzipFormat
+	ZipFormat
+	DebFormat
+	RpmFormat
+	TarxzFormat
+	TarbzipFormat
+	TarzstFormat
+	// ZstdFormat is a single command compressed with zstd
+	ZstdFormat
+	// AppImageFormat is a self-contained Linux application, installed as is
+	AppImageFormat
+	GoInstallFormat
+)
+
+func (f EAssetFormat) String() string {
+	switch f {
+	case BinaryFormat:
+		return "binary"
+	case TarballFormat:
+		return "tar"
+	case TargzipFormat:
+		return "tar.gz"
+	case ZipFormat:
+		return "zip"
+	case DebFormat:
+		return "deb"
+	case RpmFormat:
+		return "rpm"
+	case TarxzFormat:
+		return "tar.xz"
+	case TarbzipFormat:
+		return "tar.bz2"
+	case TarzstFormat:
+		return "tar.zst"
+	case ZstdFormat:
+		return "zst"
+	case AppImageFormat:
+		return "appimage"
+	case GoInstallFormat:
+		return "go install"
+	}
+	return "unknown"
+}
+
+// singleCommand tells whether assets of the format hold a lone command,
+// rather than an archive.
+func (f EAssetFormat) singleCommand() bool {
+	return f == BinaryFormat || f == ZstdFormat || f == AppImageFormat || f == GoInstallFormat
+}
+
+func (f EAssetFormat) MarshalText() ([]byte, error) {
+	return []byte(f.String()), nil
+}
+
+func (f *EAssetFormat) UnmarshalText(text []byte) error {
+	for format := BinaryFormat; format <= GoInstallFormat; format++ {
+		if format.String() == string(text) {
+			*f = format
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown asset format: %s", text)
+}
+
+// DefaultPrefer ranks formats, from most to least preferred, to choose
+// between assets matching the host equally well.
+var DefaultPrefer = []string{"binary", "tar.gz", "zip"}
+
+// DefaultWindowsPrefer replaces DefaultPrefer on Windows, where commands are
+// mostly shipped as zip archives, tarballs being meant for other platforms.
+var DefaultWindowsPrefer = []string{"zip", "binary", "tar.gz"}
+
+// defaultPrefer returns the format ranking used for host when none is
+// configured.
+func defaultPrefer(host Host) []string {
+	if host.OS == "windows" {
+		return DefaultWindowsPrefer
+	}
+	return DefaultPrefer
+}
+
+// ParseFormat returns the format named name, as written by String.
+func ParseFormat(name string) (EAssetFormat, error) {
+	var format EAssetFormat
+	err := format.UnmarshalText([]byte(name))
+	return format, err
+}
+
+// DefaultDemote lists qualifiers, found in asset names, that make an asset
+// lose to an otherwise equal one.
+var DefaultDemote = []string{"debug", "dbg"}
+
+// assetScore ranks assets matching the host: the platform match comes
+// first, then the fewest demoted qualifiers, the preferred format and
+// finally the most specific name, the one with the fewest tokens.
+type assetScore struct {
+	strength int
+	demoted  int
+	rank     int
+	tokens   int
+}
+
+func (s assetScore) beats(other assetScore) bool {
+	switch {
+	case s.strength != other.strength:
+		return s.strength > other.strength
+	case s.demoted != other.demoted:
+		return s.demoted < other.demoted
+	case s.rank != other.rank:
+		return s.rank > other.rank
+	}
+	return s.tokens < other.tokens
+}
+
+// demotedCount tells how many of the asset name's tokens are in demote.
+func demotedCount(demote []string, assetName string) int {
+	count := 0
+	for _, token := range assetTokens(assetName) {
+		if slices.Contains(demote, token) {
+			count++
+		}
+	}
+	return count
+}
+
+// formatRank tells how much an asset's format is preferred: formats not
+// listed in prefer come last.
+func formatRank(prefer []string, assetName string) int {
+	if i := slices.Index(prefer, GetAssetFormat(assetName).String()); i >= 0 {
+		return len(prefer) - i
+	}
+	return 0
+}
+
+type ArchInfo struct {
+	desired   *[]string
+	undesired []*[]string
+}
+
+// Host describes the platform assets are selected for.
+type Host struct {
+	OS   string
+	Arch string
+	Libc string
+}
+
+func (h Host) String() string {
+	return h.OS + "/" + h.Arch + "/" + h.Libc
+}
+
+var (
+	// This list is sorted from least desirable to most desirable
+	Amd64Arch = []string{"", "amd64", "x86_64"}
+	Arm64Arch = []string{"", "arm", "arm64", "aarch64"}
+	ArchEquiv = map[string]ArchInfo{
+		"amd64": ArchInfo{desired: &Amd64Arch, undesired: []*[]string{&Arm64Arch}},
+		"arm64": ArchInfo{desired: &Arm64Arch, undesired: []*[]string{&Amd64Arch}},
+	}
+	OSEquiv = map[string][]string{
+		"darwin": {"darwin", "macos", "osx"},
+	}
+	// Tokens used to recognize assets built for another platform
+	KnownArchs = []string{
+		"386", "i386", "i686", "x86", "amd64", "arm", "armv5", "armv6", "armv7", "armhf", "armel",
+		"arm64", "aarch64", "ppc64", "ppc64le", "s390x", "riscv64", "mips", "mipsle", "mips64", "mips64le", "loong64",
+	}
+	KnownOSes = []string{
+		"linux", "darwin", "macos", "osx", "windows", "win", "win32", "win64",
+		"freebsd", "openbsd", "netbsd", "dragonfly", "android", "illumos", "solaris",
+	}
+	LibcEquiv = map[string][]string{
+		"glibc": {"gnu", "glibc"},
+		"musl":  {"musl"},
+	}
+)
+
+// AddArchAliases lets assets naming an architecture otherwise, e.g.
+// "universal", be selected for it, though they rank below its usual names.
+// It is meant to be called before selecting any asset.
+func AddArchAliases(arch string, names []string) {
+	info := hostArchs(Host{Arch: arch})
+	names = lowerNames(names, &KnownArchs)
+	// After "", which any asset matches, in a new list as other
+	// architectures' lists of undesired ones share this one
+	desired := slices.Clone(*info.desired)
+	desired = slices.Insert(desired, min(1, len(desired)), names...)
+	ArchEquiv[arch] = ArchInfo{desired: &desired, undesired: info.undesired}
+}
+
+// AddOSAliases lets assets naming an OS otherwise be selected for it, though
+// they rank below its usual names. It is meant to be called before selecting
+// any asset.
+func AddOSAliases(os string, names []string) {
+	names = lowerNames(names, &KnownOSes)
+	OSEquiv[os] = slices.Insert(slices.Clone(hostOSes(Host{OS: os})), 0, names...)
+}
+
+// lowerNames lowercases aliases, as asset names are, and adds them to the
+// known ones so that assets naming them are recognized as platform specific.
+func lowerNames(names []string, known *[]string) []string {
+	lowered := make([]string, len(names))
+	for i, name := range names {
+		lowered[i] = strings.ToLower(name)
+		if !slices.Contains(*known, lowered[i]) {
+			*known = append(*known, lowered[i])
+		}
+	}
+	return lowered
+}
+
+// DetectHost describes the platform gogo is running on.
+func DetectHost() Host {
+	hostOS := strings.ToLower(runtime.GOOS)
+	return Host{
+		OS:   hostOS,
+		Arch: strings.ToLower(runtime.GOARCH),
+		Libc: DetectLibc(hostOS),
+	}
+}
+
+// DetectLibc guesses the host's libc. Only Linux hosts may be using musl,
+// which we recognize by the presence of its dynamic loader.
+func DetectLibc(hostOS string) string {
+	if hostOS == "linux" {
+		if matches, _ := filepath.Glob("/lib/ld-musl-*.so.1"); len(matches) > 0 {
+			return "musl"
+		}
+	}
+	return "glibc"
+}
+
+func GetAssetFormat(assetName string) EAssetFormat {
+	assetName = strings.ToLower(assetName)
+	if strings.HasSuffix(assetName, ".tar.gz") {
+		return TargzipFormat
+	}
+	if strings.HasSuffix(assetName, ".tgz") {
+		return TargzipFormat
+	}
+	if strings.HasSuffix(assetName, ".tar.xz") || strings.HasSuffix(assetName, ".txz") {
+		return TarxzFormat
+	}
+	if strings.HasSuffix(assetName, ".tar.bz2") || strings.HasSuffix(assetName, ".tbz2") || strings.HasSuffix(assetName, ".tbz") {
+		return TarbzipFormat
+	}
+	if strings.HasSuffix(assetName, ".tar.zst") || strings.HasSuffix(assetName, ".tzst") {
+		return TarzstFormat
+	}
+	if strings.HasSuffix(assetName, ".zst") {
+		return ZstdFormat
+	}
+	if strings.HasSuffix(assetName, ".tar") {
+		return TarballFormat
+	}
+	if strings.HasSuffix(assetName, ".zip") {
+		return ZipFormat
+	}
+	if strings.HasSuffix(assetName, ".deb") {
+		return DebFormat
+	}
+	if strings.HasSuffix(assetName, ".rpm") {
+		return RpmFormat
+	}
+	if strings.HasSuffix(assetName, ".appimage") {
+		return AppImageFormat
+	}
+	return BinaryFormat
+}
+
+// platformName is the name an asset is matched with: Linux packages do not
+// always say they are for Linux.
+func platformName(assetName string) string {
+	if format := GetAssetFormat(assetName); (format == DebFormat || format == RpmFormat) && !strings.Contains(assetName, "linux") {
+		return assetName + ".linux"
+	}
+	return assetName
+}
+
+// release is what the API tells of a release.
+type release struct {
+	ID          int64          `json:"id"`
+	TagName     string         `json:"tag_name"`
+	PublishedAt time.Time      `json:"published_at"`
+	Draft       bool           `json:"draft"`
+	Prerelease  bool           `json:"prerelease"`
+	Assets      []ReleaseAsset `json:"assets"`
+}
+
+// latestRelease gets the repository's latest release or, with prerelease,
+// its most recent one, prereleases included. With a ReleaseOffset, it goes
+// that many releases further back. When there is none, it returns a nil
+// release and a message telling why: GitHub's latest release is never a
+// prerelease nor a draft, and a repository with only these has none.
+func (c *Client) latestRelease(repo *Repository, prerelease bool) (*release, string, error) {
+	if !prerelease && c.ReleaseOffset == 0 {
+		var latest release
+		found, err := c.getAPI(repo, c.latestReleaseURL(repo), &latest)
+		if err != nil {
+			return nil, "", err
+		}
+		if found {
+			return &latest, "", nil
+		}
+	}
+	var releases []release
+	found, err := c.getAPI(repo, c.releaseListURL(repo), &releases)
+	if err != nil {
+		return nil, "", err
+	}
+	if !found {
+		return nil, "repository not found", nil
+	}
+	published := slices.DeleteFunc(slices.Clone(releases), func(r release) bool { return r.Draft })
+	eligible := slices.DeleteFunc(slices.Clone(published), func(r release) bool { return r.Prerelease && !prerelease })
+	switch {
+	case len(releases) == 0:
+		return nil, "repository has no releases", nil
+	case len(published) == 0:
+		return nil, "no published release (only drafts)", nil
+	case len(eligible) == 0:
+		return nil, "no stable release (only prereleases or drafts), try -prerelease", nil
+	case c.ReleaseOffset >= len(eligible):
+		return nil, fmt.Sprintf("no release %d back from the newest (only %d)", c.ReleaseOffset, len(eligible)), nil
+	}
+	chosen := &eligible[c.ReleaseOffset]
+	if c.ReleaseOffset > 0 {
+		c.logf("  - Release %d back from the newest: %s (prerelease: %v)\n", c.ReleaseOffset, chosen.TagName, chosen.Prerelease)
+	} else {
+		c.logf("  - Most recent release: %s (prerelease: %v)\n", chosen.TagName, chosen.Prerelease)
+	}
+	return chosen, "", nil
+}
+
+// findRelease gets the release to install from a repository: the one tagged
+// with its version, the highest one satisfying its version constraint or,
+// without a version, the latest one. Like latestRelease, it returns a nil
+// release and a message telling why when there is none.
+func (c *Client) findRelease(repo *Repository, prerelease bool) (*release, string, error) {
+	switch {
+	case repo.Version == "":
+		return c.latestRelease(repo, prerelease)
+	case IsVersionConstraint(repo.Version):
+		return c.matchingRelease(repo, prerelease)
+	}
+	tags := []string{repo.Version}
+	if repo.Version[0] >= '0' && repo.Version[0] <= '9' {
+		// Most tags are written v1.2.3
+		tags = append(tags, "v"+repo.Version)
+	}
+	for _, tag := range tags {
+		var tagged release
+		found, err := c.getAPI(repo, c.taggedReleaseURL(repo, tag), &tagged)
+		if err != nil {
+			return nil, "", err
+		}
+		if found {
+			c.logf("  - Release pinned to %s\n", tagged.TagName)
+			return &tagged, "", nil
+		}
+	}
+	return nil, fmt.Sprintf("no release tagged %s", repo.Version), nil
+}
+
+// matchingRelease gets the release with the highest version satisfying the
+// repository's version constraint, skipping drafts and, unless allowed,
+// prereleases.
+func (c *Client) matchingRelease(repo *Repository, prerelease bool) (*release, string, error) {
+	constraint, err := parseConstraint(repo.Version)
+	if err != nil {
+		return nil, err.Error(), nil
+	}
+	releases, err := fetchAllPages[release](c.context(), c.HTTP, c.releaseListURL(repo), c.apiToken(repo), c.repoHeaders(repo))
+	if err != nil {
+		return nil, "", fmt.Errorf("error listing releases: %v", err)
+	}
+	var chosen *release
+	var chosenVersion semver
+	for i, r := range releases {
+		if r.Draft || r.Prerelease && !prerelease {
+			continue
+		}
+		version, ok := parseSemver(r.TagName)
+		if !ok || !constraint.allows(version) {
+			continue
+		}
+		if chosen == nil || version.compare(chosenVersion) > 0 {
+			chosen = &releases[i]
+			chosenVersion = version
+		}
+	}
+	if chosen == nil {
+		return nil, fmt.Sprintf("no release matching %s", repo.Version), nil
+	}
+	c.logf("  - Highest release matching %s: %s\n", repo.Version, chosen.TagName)
+	return chosen, "", nil
+}
+
+// LatestTag returns the tag of a repository's latest release, a prerelease
+// if allowed, and when it was published.
+func (c *Client) LatestTag(repo *Repository) (string, time.Time, error) {
+	latest, message, err := c.latestRelease(repo, c.Prerelease || repo.Prerelease)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	if latest == nil {
+		return "", time.Time{}, fmt.Errorf("%s: %s", repo.Name, message)
+	}
+	return latest.TagName, latest.PublishedAt, nil
+}
+
+// getAPI decodes the API's answer to a request for a repository into v. It
+// returns false if the API answered 404. Requests not made for a repository
+// have a nil repo.
+func (c *Client) getAPI(repo *Repository, url string, v any) (bool, error) {
+	req, err := NewAPIRequest(url, c.apiToken(repo))
+	if err != nil {
+		return false, err
+	}
+	req = req.WithContext(c.context())
+	addHeaders(req, c.repoHeaders(repo))
+	resp, err := c.HTTP.Do(req)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+	if repo == nil || repo.provider() == ProviderGitHub {
+		c.recordRateQuota(resp)
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		return false, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return false, fmt.Errorf("non-OK HTTP status: %s", resp.Status)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return false, fmt.Errorf("error decoding JSON: %v", err)
+	}
+	return true, nil
+}
+
+// ResolveAsset finds, in the repository's latest release, the asset that
+// best matches host. When there is none, the returned status is RepoKO, with
+// a Message explaining why, unless the repository can be built with go
+// install instead.
+func (c *Client) ResolveAsset(repo *Repository, host Host) (RepoStatus, error) {
+	status := RepoStatus{Repo: repo, Status: RepoKO}
+	if repo.URL != "" {
+		return resolveURL(repo), nil
+	}
+	if err := repo.CheckName(); err != nil {
+		status.Message = err.Error()
+		return status, nil
+	}
+
+	release, message, err := c.findRelease(repo, c.Prerelease || repo.Prerelease)
+	if err != nil {
+		if useGoInstall(&status) {
+			return status, nil
+		}
+		return status, err
+	}
+	if release == nil {
+		status.Message = message
+		useGoInstall(&status)
+		return status, nil
+	}
+	status.PublishedAt = release.PublishedAt
+	status.Tag = release.TagName
+	status.InstalledTag = c.State.installedTag(repo)
+	status.Host = host
+	status.Selection = c.selection(repo, host)
+	if cached, ok := c.State.cachedAsset(repo, release.TagName, host, status.Selection); ok && !c.Reselect && repo.Asset == "" && matchesAssetPattern(repo.AssetPattern, cached.Asset) {
+		c.logf("  - Reusing Asset selected for %s: %s\n", release.TagName, cached.Asset)
+		status.Status = RepoOK
+		status.Asset = cached.Asset
+		status.Url = cached.Url
+		status.SignatureUrl = cached.SignatureUrl
+		status.ChecksumUrl = cached.ChecksumUrl
+		status.Format = cached.Format
+		return status, nil
+	}
+	if len(release.Assets) >= apiPageSize && repo.provider() == ProviderGitHub {
+		// The embedded list may have been truncated, get the whole thing
+		assetsUrl := fmt.Sprintf("%s/repos/%s/releases/%d/assets?per_page=100", c.APIURL, repo.Name, release.ID)
+		assets, err := fetchAllPages[ReleaseAsset](c.context(), c.HTTP, assetsUrl, c.Token, c.repoHeaders(repo))
+		if err != nil {
+			return status, fmt.Errorf("error fetching assets: %v", err)
+		}
+		release.Assets = assets
+	}
+	var candidateAsset *ReleaseAsset
+	if repo.Asset != "" {
+		// Chosen by the user, whatever it looks like
+		i := slices.IndexFunc(release.Assets, func(asset ReleaseAsset) bool { return asset.Name == repo.Asset })
+		if i < 0 {
+			names := make([]string, len(release.Assets))
+			for i, asset := range release.Assets {
+				names[i] = asset.Name
+			}
+			status.Message = fmt.Sprintf("no asset named %s, available: %s", repo.Asset, strings.Join(names, ", "))
+			return status, nil
+		}
+		candidateAsset = &release.Assets[i]
+		status.Asset = candidateAsset.Name
+		status.Url = c.assetURL(repo, candidateAsset)
+		status.Format = GetAssetFormat(strings.ToLower(candidateAsset.Name))
+	} else if repo.AssetPattern != "" {
+		match, err := compileAssetPattern(repo.AssetPattern)
+		if err != nil {
+			status.Message = fmt.Sprintf("invalid asset_pattern: %v", err)
+			return status, nil
+		}
+		matching := *release
+		matching.Assets = slices.DeleteFunc(slices.Clone(release.Assets), func(asset ReleaseAsset) bool {
+			return !match(asset.Name) || isChecksumList(asset.Name)
+		})
+		switch len(matching.Assets) {
+		case 0:
+			names := make([]string, len(release.Assets))
+			for i, asset := range release.Assets {
+				names[i] = asset.Name
+			}
+			status.Message = fmt.Sprintf("no asset matches %s, available: %s", repo.AssetPattern, strings.Join(names, ", "))
+			return status, nil
+		case 1:
+			// Chosen by the user, whatever it looks like
+			candidateAsset = &matching.Assets[0]
+			status.Asset = candidateAsset.Name
+			status.Url = c.assetURL(repo, candidateAsset)
+			status.Format = GetAssetFormat(strings.ToLower(candidateAsset.Name))
+		default:
+			c.logf("  - %d assets match %s\n", len(matching.Assets), repo.AssetPattern)
+			var ok bool
+			if candidateAsset, ok = c.selectReleaseAsset(&status, &matching, host); !ok {
+				status.Message += fmt.Sprintf(" among those matching %s", repo.AssetPattern)
+				return status, nil
+			}
+		}
+	} else {
+		var ok bool
+		if candidateAsset, ok = c.selectReleaseAsset(&status, release, host); !ok {
+			useGoInstall(&status)
+			return status, nil
+		}
+	}
+	if repo.Signature != "" {
+		signatureAsset, err := findSignatureAsset(release.Assets, candidateAsset.Name, repo.Signature)
+		if err != nil {
+			status.Message = err.Error()
+			return status, nil
+		}
+		status.SignatureUrl = c.assetURL(repo, signatureAsset)
+	}
+	if checksumAsset := findChecksumAsset(release.Assets, candidateAsset.Name); checksumAsset != nil {
+		c.logf("  - Checksum in %s\n", checksumAsset.Name)
+		status.ChecksumUrl = c.assetURL(repo, checksumAsset)
+	}
+	status.Status = RepoOK
+	return status, nil
+}
+
+// selection fingerprints the settings an asset is selected with for repo, so
+// that an asset selected with others is not reused.
+func (c *Client) selection(repo *Repository, host Host) string {
+	settings, _ := json.Marshal([]any{
+		firstNonEmpty(repo.Prefer, c.Prefer, defaultPrefer(host)),
+		firstNonEmpty(repo.Demote, c.Demote, DefaultDemote),
+		firstNonEmpty(repo.Ignore, c.Ignore, DefaultIgnore),
+		repo.AssetPattern,
+		c.AllowAppImage || repo.AppImage,
+	})
+	sum := sha256.Sum256(settings)
+	return hex.EncodeToString(sum[:8])
+}
+
+// selectReleaseAsset selects the release's asset that best matches host,
+// describing it in status. If there is none, it returns false, with a
+// Message explaining why.
+func (c *Client) selectReleaseAsset(status *RepoStatus, release *release, host Host) (*ReleaseAsset, bool) {
+	ignore := firstNonEmpty(status.Repo.Ignore, c.Ignore, DefaultIgnore)
+	if !slices.ContainsFunc(release.Assets, func(asset ReleaseAsset) bool {
+		return ignoredSuffix(strings.ToLower(asset.Name), ignore) == ""
+	}) {
+		status.Message = "latest release has no downloadable assets"
+		return nil, false
+	}
+
+	// Installers are never installed, AppImages only if nothing else fits
+	var assets, appImages []ReleaseAsset
+	var installers []string
+	for _, asset := range release.Assets {
+		switch {
+		case isChecksumList(asset.Name):
+			continue
+		case isInstaller(asset.Name):
+			c.logf("  - Ignoring installer %s\n", asset.Name)
+			installers = append(installers, asset.Name)
+		case GetAssetFormat(asset.Name) == AppImageFormat:
+			appImages = append(appImages, asset)
+		default:
+			assets = append(assets, asset)
+		}
+	}
+	skippedAppImage := ""
+	if len(appImages) > 0 && !c.AllowAppImage && !status.Repo.AppImage {
+		skippedAppImage = appImages[0].Name
+		appImages = nil
+	}
+	prefer := firstNonEmpty(status.Repo.Prefer, c.Prefer, defaultPrefer(host))
+	demote := firstNonEmpty(status.Repo.Demote, c.Demote, DefaultDemote)
+	candidateAsset, format := selectAsset(assets, host, ignore, prefer, demote, c.logf)
+	if candidateAsset == nil && len(appImages) > 0 {
+		c.logf("  - Looking for an AppImage\n")
+		candidateAsset, format = selectAsset(appImages, host, ignore, prefer, demote, c.logf)
+	}
+	for _, assets := range [][]ReleaseAsset{assets, appImages} {
+		if candidateAsset != nil {
+			break
+		}
+		if candidateAsset = selectAgnosticAsset(assets, host, ignore, prefer, c.logf); candidateAsset != nil {
+			format = GetAssetFormat(candidateAsset.Name)
+			status.Warning = "platform could not be confirmed from the asset name"
+		}
+	}
+	if candidateAsset == nil {
+		status.Message = fmt.Sprintf("no asset for %s/%s", host.OS, host.Arch)
+		switch {
+		case skippedAppImage != "":
+			status.Message += fmt.Sprintf(" (only an AppImage, %s, try -allow-appimage)", skippedAppImage)
+		case len(installers) > 0:
+			status.Message += fmt.Sprintf(" (only installers: %s)", strings.Join(installers, ", "))
+		}
+		return nil, false
+	}
+
+	status.Asset = candidateAsset.Name
+	status.Url = c.assetURL(status.Repo, candidateAsset)
+	status.Format = format
+	if status.Mismatch = platformMismatch(platformName(strings.ToLower(candidateAsset.Name)), *hostArchs(host).desired, hostOSes(host)); status.Mismatch != "" && !c.Force {
+		status.Message = fmt.Sprintf("no compatible asset (only %s available)", status.Mismatch)
+		return nil, false
+	}
+	return candidateAsset, true
+}
+
+// installerSuffixes are the extensions of installers, which install software
+// their own way rather than being commands or archives of commands.
+var installerSuffixes = []string{".msi", ".dmg", ".pkg"}
+
+// isInstaller tells whether an asset is an installer: a package for macOS or
+// Windows installers, or a Windows setup program.
+func isInstaller(assetName string) bool {
+	name := strings.ToLower(assetName)
+	if ignoredSuffix(name, installerSuffixes) != "" {
+		return true
+	}
+	return strings.HasSuffix(name, ".exe") && (strings.Contains(name, "setup") || strings.Contains(name, "installer"))
+}
+
+// firstNonEmpty returns the first list that is set, repository settings
+// coming before global ones, which come before defaults.
+func firstNonEmpty(lists ...[]string) []string {
+	for _, list := range lists {
+		if len(list) > 0 {
+			return list
+		}
+	}
+	return nil
+}
+
+// resolveURL describes the download of a repository's file URL, which needs
+// no release lookup: the asset is whatever the URL points to.
+func resolveURL(repo *Repository) RepoStatus {
+	assetName := repo.URL
+	if parsed, err := url.Parse(repo.URL); err == nil {
+		assetName = path.Base(parsed.Path)
+	}
+	return RepoStatus{
+		Repo:   repo,
+		Status: RepoOK,
+		Format: GetAssetFormat(strings.ToLower(assetName)),
+		Asset:  assetName,
+		Url:    repo.URL,
+	}
+}
+
+// assetURL tells where to download an asset from. Browser download URLs
+// return a 404 for private repositories, so when we have a token we go
+// through the API instead, which serves assets of public and private
+// repositories alike.
+func (c *Client) assetURL(repo *Repository, asset *ReleaseAsset) string {
+	if c.Token == "" || asset.ID == 0 || repo.provider() != ProviderGitHub {
+		return asset.BrowserDownloadURL
+	}
+	return fmt.Sprintf("%s/repos/%s/releases/assets/%d", c.APIURL, repo.Name, asset.ID)
+}
+
+// selectAsset picks, among a release's assets, the one that best matches
+// host. When several match as well, it prefers the one without qualifiers
+// listed in demote, then the format ranking highest in prefer, then the most
+// specific name (see assetScore). It returns nil if none matches. logf
+// receives the reasons assets were passed over and may be nil.
+func selectAsset(assets []ReleaseAsset, host Host, ignore []string, prefer []string, demote []string, logf func(format string, a ...any)) (*ReleaseAsset, EAssetFormat) {
+	if logf == nil {
+		logf = func(string, ...any) {}
+	}
+	archList := hostArchs(host)
+	osList := hostOSes(host)
+
+	var candidateAsset *ReleaseAsset
+	var candidateScore assetScore
+assetLoop:
+	for _, asset := range assets {
+		logf("  - Matching Asset: %s\n", strings.ToLower(asset.Name))
+		if ignore := ignoredSuffix(strings.ToLower(asset.Name), ignore); ignore != "" {
+			logf("  - Ignoring Asset due to suffix %s\n", ignore)
+			continue
+		}
+		assetName := platformName(strings.ToLower(asset.Name))
+		for archIdx, archName := range *archList.desired {
+			if !strings.Contains(assetName, archName) {
+				logf("  - Ignoring Asset due to not matching architecture %s\n", archName)
+				continue
+			}
+			for _, undesired := range archList.undesired {
+				for _, undesiredArch := range *undesired {
+					if undesiredArch == "" {
+						continue
+					}
+					if strings.Contains(assetName, undesiredArch) {
+						logf("  - Ignoring Asset due to matching undesired architecture %s\n", undesiredArch)
+						continue assetLoop
+					}
+				}
+			}
+			for osIdx, os := range osList {
+				if !strings.Contains(assetName, os) {
+					logf("  - Ignoring Asset for not matching OS %s\n", os)
+					continue
+				}
+				score := assetScore{
+					// OS wins over architecture, which wins over libc
+					strength: osIdx<<10 + archIdx<<4 + libcScore(assetName, host.Libc),
+					demoted:  demotedCount(demote, assetName),
+					rank:     formatRank(prefer, assetName),
+					tokens:   len(assetTokens(assetName)),
+				}
+				if candidateAsset == nil || score.beats(candidateScore) {
+					candidateScore = score
+					candidateAsset = &asset
+				}
+			}
+		}
+	}
+	if candidateAsset == nil {
+		return nil, BinaryFormat
+	}
+	return candidateAsset, GetAssetFormat(candidateAsset.Name)
+}
+
+// selectAgnosticAsset is the fallback for releases where no asset names the
+// host's OS: it picks the release's only asset or, among assets naming no OS
+// at all, the one best matching the host's architecture.
+func selectAgnosticAsset(assets []ReleaseAsset, host Host, ignore []string, prefer []string, logf func(format string, a ...any)) *ReleaseAsset {
+	if logf == nil {
+		logf = func(string, ...any) {}
+	}
+	archList := hostArchs(host)
+
+	var usable []*ReleaseAsset
+	for i := range assets {
+		if ignoredSuffix(strings.ToLower(assets[i].Name), ignore) == "" {
+			usable = append(usable, &assets[i])
+		}
+	}
+	if len(usable) == 1 {
+		logf("  - Only one Asset: %s\n", usable[0].Name)
+		return usable[0]
+	}
+
+	var candidateAsset *ReleaseAsset
+	candidateIdx := -1
+	var candidateRank int
+assetLoop:
+	for _, asset := range usable {
+		assetName := platformName(strings.ToLower(asset.Name))
+		for _, token := range assetTokens(assetName) {
+			if slices.Contains(KnownOSes, token) {
+				continue assetLoop
+			}
+		}
+		for _, undesired := range archList.undesired {
+			for _, undesiredArch := range *undesired {
+				if undesiredArch != "" && strings.Contains(assetName, undesiredArch) {
+					continue assetLoop
+				}
+			}
+		}
+		for archIdx, archName := range *archList.desired {
+			if !strings.Contains(assetName, archName) {
+				continue
+			}
+			rank := formatRank(prefer, assetName)
+			if archIdx > candidateIdx || archIdx == candidateIdx && rank > candidateRank {
+				candidateIdx = archIdx
+				candidateRank = rank
+				candidateAsset = asset
+			}
+		}
+	}
+	if candidateAsset != nil {
+		logf("  - Asset naming no OS: %s\n", candidateAsset.Name)
+	}
+	return candidateAsset
+}
+
+// DefaultIgnore lists the extensions of assets not worth installing:
+// following a common convention, we ignore SHA files, signatures, etc.
+var DefaultIgnore = []string{
+	".sha", ".sha1", ".sha256", ".sha512", ".sha256sum", ".sha512sum", ".md5",
+	".sig", ".minisig", ".asc", ".pem", ".sbom",
+}
+
+// ignoredSuffix returns the extension, among ignore, that makes an asset not
+// worth installing. Only trailing extensions count, so that a tool with
+// "sha" or "sig" in its name is not mistaken for a checksum.
+func ignoredSuffix(assetName string, ignore []string) string {
+	for _, suffix := range ignore {
+		if strings.HasSuffix(assetName, strings.ToLower(suffix)) {
+			return suffix
+		}
+	}
+	return ""
+}
+
+// assetTokens splits an asset name into its words, x86_64 being one.
+func assetTokens(assetName string) []string {
+	return strings.FieldsFunc(strings.ReplaceAll(assetName, "x86_64", "amd64"), func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9')
+	})
+}
+
+func hostArchs(host Host) ArchInfo {
+	archList, ok := ArchEquiv[host.Arch]
+	if !ok {
+		archList = ArchInfo{desired: &[]string{host.Arch}}
+	}
+	return archList
+}
+
+func hostOSes(host Host) []string {
+	osList, ok := OSEquiv[host.OS]
+	if !ok {
+		osList = []string{host.OS}
+	}
+	return osList
+}
+
+// useGoInstall switches a repository without a usable release asset to a
+// source build, if it is configured with go_install.
+func useGoInstall(repoStatus *RepoStatus) bool {
+	if repoStatus.Repo.GoInstall == "" {
+		return false
+	}
+	repoStatus.Status = RepoOK
+	repoStatus.Format = GoInstallFormat
+	repoStatus.Asset = "go install " + repoStatus.Repo.GoInstall
+	repoStatus.Url = repoStatus.Repo.GoInstall
+	repoStatus.Mismatch = ""
+	repoStatus.Message = ""
+	return true
+}
+
+// platformMismatch returns the foreign platform an asset clearly targets,
+// or an empty string when it is compatible with the host. An asset is foreign
+// when it names architectures (or OSes) and none of them belong to the host.
+func platformMismatch(assetName string, hostArchs []string, hostOSes []string) string {
+	tokens := assetTokens(assetName)
+	check := func(known []string, host []string) string {
+		var foreign []string
+		for _, token := range tokens {
+			if !slices.Contains(known, token) {
+				continue
+			}
+			if slices.Contains(host, token) || (token == "amd64" && slices.Contains(host, "x86_64")) {
+				return ""
+			}
+			foreign = append(foreign, token)
+		}
+		return strings.Join(foreign, "/")
+	}
+	if mismatch := check(KnownOSes, hostOSes); mismatch != "" {
+		return mismatch
+	}
+	return check(KnownArchs, hostArchs)
+}
+
+// libcScore ranks an asset for the preferred libc: 2 if it explicitly targets
+// it, 1 if it does not mention any libc, 0 if it targets another libc.
+func libcScore(assetName string, preferred string) int {
+	score := 1
+	for libc, tokens := range LibcEquiv {
+		for _, token := range tokens {
+			if !strings.Contains(assetName, token) {
+				continue
+			}
+			if libc == preferred {
+				return 2
+			}
+			score = 0
+		}
+	}
+	return score
+}
+
+// compileAssetPattern returns a function telling whether an asset name matches
+// pattern: a regular expression between slashes, or a glob matched whatever
+// the case. An empty pattern matches every asset.
+func compileAssetPattern(pattern string) (func(string) bool, error) {
+	if pattern == "" {
+		return func(string) bool { return true }, nil
+	}
+	if len(pattern) > 2 && strings.HasPrefix(pattern, "/") && strings.HasSuffix(pattern, "/") {
+		re, err := regexp.Compile(pattern[1 : len(pattern)-1])
+		if err != nil {
+			return nil, err
+		}
+		return re.MatchString, nil
+	}
+	pattern = strings.ToLower(pattern)
+	if _, err := path.Match(pattern, ""); err != nil {
+		return nil, fmt.Errorf("invalid glob %q: %v", pattern, err)
+	}
+	return func(name string) bool {
+		matched, _ := path.Match(pattern, strings.ToLower(name))
+		return matched
+	}, nil
+}
+
+// matchesAssetPattern tells whether an asset name matches pattern, an invalid
+// pattern matching nothing.
+func matchesAssetPattern(pattern string, name string) bool {
+	match, err := compileAssetPattern(pattern)
+	return err == nil && match(name)
+}
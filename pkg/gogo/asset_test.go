@@ -0,0 +1,538 @@
+package gogo
+
+import (
+	"fmt"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+	"time"
+)
+
+func assetList(names ...string) []ReleaseAsset {
+	assets := make([]ReleaseAsset, len(names))
+	for i, name := range names {
+		assets[i] = ReleaseAsset{Name: name, BrowserDownloadURL: "https://example.com/" + name}
+	}
+	return assets
+}
+
+var (
+	lazygitAssets = assetList(
+		"checksums.txt",
+		"lazygit_0.40.2_Darwin_arm64.tar.gz",
+		"lazygit_0.40.2_Darwin_x86_64.tar.gz",
+		"lazygit_0.40.2_Linux_32-bit.tar.gz",
+		"lazygit_0.40.2_Linux_arm64.tar.gz",
+		"lazygit_0.40.2_Linux_armv6.tar.gz",
+		"lazygit_0.40.2_Linux_x86_64.tar.gz",
+		"lazygit_0.40.2_Windows_32-bit.zip",
+		"lazygit_0.40.2_Windows_arm64.zip",
+		"lazygit_0.40.2_Windows_x86_64.zip",
+	)
+	ripgrepAssets = assetList(
+		"ripgrep-14.1.0-aarch64-apple-darwin.tar.gz",
+		"ripgrep-14.1.0-aarch64-apple-darwin.tar.gz.sha256",
+		"ripgrep-14.1.0-aarch64-unknown-linux-gnu.tar.gz",
+		"ripgrep-14.1.0-aarch64-unknown-linux-gnu.tar.gz.sha256",
+		"ripgrep-14.1.0-armv7-unknown-linux-gnueabihf.tar.gz",
+		"ripgrep-14.1.0-i686-pc-windows-msvc.zip",
+		"ripgrep-14.1.0-x86_64-apple-darwin.tar.gz",
+		"ripgrep-14.1.0-x86_64-apple-darwin.tar.gz.sha256",
+		"ripgrep-14.1.0-x86_64-pc-windows-msvc.zip",
+		"ripgrep-14.1.0-x86_64-unknown-linux-musl.tar.gz",
+		"ripgrep-14.1.0-x86_64-unknown-linux-musl.tar.gz.sha256",
+		"ripgrep_14.1.0-1_amd64.deb",
+	)
+)
+
+func TestSelectAsset(t *testing.T) {
+	tests := []struct {
+		name   string
+		assets []ReleaseAsset
+		host   Host
+		want   string
+		format EAssetFormat
+	}{
+		{"lazygit linux amd64", lazygitAssets, Host{"linux", "amd64", "glibc"}, "lazygit_0.40.2_Linux_x86_64.tar.gz", TargzipFormat},
+		{"lazygit linux arm64", lazygitAssets, Host{"linux", "arm64", "glibc"}, "lazygit_0.40.2_Linux_arm64.tar.gz", TargzipFormat},
+		{"lazygit darwin amd64", lazygitAssets, Host{"darwin", "amd64", "glibc"}, "lazygit_0.40.2_Darwin_x86_64.tar.gz", TargzipFormat},
+		{"lazygit darwin arm64", lazygitAssets, Host{"darwin", "arm64", "glibc"}, "lazygit_0.40.2_Darwin_arm64.tar.gz", TargzipFormat},
+		{"lazygit windows amd64", lazygitAssets, Host{"windows", "amd64", "glibc"}, "lazygit_0.40.2_Windows_x86_64.zip", ZipFormat},
+		{"lazygit windows arm64", lazygitAssets, Host{"windows", "arm64", "glibc"}, "lazygit_0.40.2_Windows_arm64.zip", ZipFormat},
+		{"ripgrep linux amd64", ripgrepAssets, Host{"linux", "amd64", "glibc"}, "ripgrep-14.1.0-x86_64-unknown-linux-musl.tar.gz", TargzipFormat},
+		{"ripgrep linux arm64", ripgrepAssets, Host{"linux", "arm64", "glibc"}, "ripgrep-14.1.0-aarch64-unknown-linux-gnu.tar.gz", TargzipFormat},
+		{"ripgrep darwin amd64", ripgrepAssets, Host{"darwin", "amd64", "glibc"}, "ripgrep-14.1.0-x86_64-apple-darwin.tar.gz", TargzipFormat},
+		{"ripgrep darwin arm64", ripgrepAssets, Host{"darwin", "arm64", "glibc"}, "ripgrep-14.1.0-aarch64-apple-darwin.tar.gz", TargzipFormat},
+		{"ripgrep windows amd64", ripgrepAssets, Host{"windows", "amd64", "glibc"}, "ripgrep-14.1.0-x86_64-pc-windows-msvc.zip", ZipFormat},
+		{"ripgrep windows arm64", ripgrepAssets, Host{"windows", "arm64", "glibc"}, "ripgrep-14.1.0-i686-pc-windows-msvc.zip", ZipFormat},
+		{
+			"checksums and signatures are skipped",
+			assetList("tool-linux-amd64.sha256", "tool-linux-amd64.sig", "tool-linux-amd64.minisig", "tool-linux-amd64.asc", "tool-linux-amd64"),
+			Host{"linux", "amd64", "glibc"}, "tool-linux-amd64", BinaryFormat,
+		},
+		{
+			"sha in the name is not a checksum",
+			assetList("something-sha-tool_linux_amd64.sha256", "something-sha-tool_linux_amd64"),
+			Host{"linux", "amd64", "glibc"}, "something-sha-tool_linux_amd64", BinaryFormat,
+		},
+		{
+			"only checksums",
+			assetList("tool-linux-amd64.tar.gz.sha256", "tool-linux-amd64.tar.gz.sig"),
+			Host{"linux", "amd64", "glibc"}, "", BinaryFormat,
+		},
+		{
+			"undesired architecture is excluded",
+			assetList("tool-linux-arm64.tar.gz", "tool-linux-aarch64.tar.gz"),
+			Host{"linux", "amd64", "glibc"}, "", BinaryFormat,
+		},
+		{
+			"undesired architecture loses to an unqualified asset",
+			assetList("tool-linux-x86_64.tar.gz", "tool-linux.tar"),
+			Host{"linux", "arm64", "glibc"}, "tool-linux.tar", TarballFormat,
+		},
+		{
+			"preferred libc",
+			assetList("tool-x86_64-unknown-linux-musl.tgz", "tool-x86_64-unknown-linux-gnu.tgz"),
+			Host{"linux", "amd64", "musl"}, "tool-x86_64-unknown-linux-musl.tgz", TargzipFormat,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			asset, format := selectAsset(tt.assets, tt.host, DefaultIgnore, DefaultPrefer, DefaultDemote, t.Logf)
+			got := ""
+			if asset != nil {
+				got = asset.Name
+			}
+			if got != tt.want {
+				t.Fatalf("selectAsset() = %q, want %q", got, tt.want)
+			}
+			if format != tt.format {
+				t.Errorf("selectAsset() format = %v, want %v", format, tt.format)
+			}
+		})
+	}
+}
+
+func TestIgnoredSuffix(t *testing.T) {
+	tests := []struct {
+		name   string
+		ignore []string
+		want   string
+	}{
+		{"tool-linux-amd64.tar.gz.sha256", DefaultIgnore, ".sha256"},
+		{"tool-linux-amd64.tar.gz.asc", DefaultIgnore, ".asc"},
+		{"something-sha-tool_linux_amd64", DefaultIgnore, ""},
+		{"signal-cli-linux-amd64.tar.gz", DefaultIgnore, ""},
+		{"tool.ascii-linux-amd64", DefaultIgnore, ""},
+		{"tool-linux-amd64.sig", []string{".deb"}, ""},
+		{"tool-linux-amd64.deb", []string{".DEB"}, ".DEB"},
+	}
+	for _, tt := range tests {
+		if got := ignoredSuffix(tt.name, tt.ignore); got != tt.want {
+			t.Errorf("ignoredSuffix(%q, %q) = %q, want %q", tt.name, tt.ignore, got, tt.want)
+		}
+	}
+}
+
+func TestSelectAssetFormat(t *testing.T) {
+	assets := assetList("tool-linux-amd64.zip", "tool-linux-amd64.tar.gz", "tool-linux-amd64", "tool-linux-amd64.tar")
+	host := Host{"linux", "amd64", "glibc"}
+	tests := []struct {
+		prefer []string
+		want   string
+	}{
+		{DefaultPrefer, "tool-linux-amd64"},
+		{[]string{"tar.gz", "zip"}, "tool-linux-amd64.tar.gz"},
+		{[]string{"zip"}, "tool-linux-amd64.zip"},
+		{[]string{"tar"}, "tool-linux-amd64.tar"},
+	}
+	reversed := slices.Clone(assets)
+	slices.Reverse(reversed)
+	for _, tt := range tests {
+		for _, order := range [][]ReleaseAsset{assets, reversed} {
+			asset, _ := selectAsset(order, host, DefaultIgnore, tt.prefer, DefaultDemote, t.Logf)
+			if asset == nil || asset.Name != tt.want {
+				t.Errorf("selectAsset() with prefer %q = %v, want %s", tt.prefer, asset, tt.want)
+			}
+		}
+	}
+
+	windows := Host{"windows", "amd64", "glibc"}
+	windowsAssets := assetList("tool-linux-amd64.tar.gz", "tool-windows-amd64.exe", "tool-windows-amd64.tar.gz", "tool-windows-amd64.zip")
+	if asset, _ := selectAsset(windowsAssets, windows, DefaultIgnore, defaultPrefer(windows), DefaultDemote, t.Logf); asset == nil || asset.Name != "tool-windows-amd64.zip" {
+		t.Errorf("selectAsset() for Windows = %v, want tool-windows-amd64.zip", asset)
+	}
+	agnostic := assetList("tool.zip", "tool.tar.gz")
+	if asset := selectAgnosticAsset(agnostic, host, DefaultIgnore, DefaultPrefer, t.Logf); asset == nil || asset.Name != "tool.tar.gz" {
+		t.Errorf("selectAgnosticAsset() = %v, want tool.tar.gz", asset)
+	}
+}
+
+func TestSelectAssetTieBreak(t *testing.T) {
+	host := Host{"linux", "amd64", "glibc"}
+	tests := []struct {
+		name   string
+		assets []ReleaseAsset
+		demote []string
+		want   string
+	}{
+		{"architecture over OS alone", assetList("tool-linux.tar.gz", "tool-linux-amd64.tar.gz"), DefaultDemote, "tool-linux-amd64.tar.gz"},
+		{"debug build demoted", assetList("tool-linux-amd64-debug.tar.gz", "tool-linux-amd64.tar.gz"), DefaultDemote, "tool-linux-amd64.tar.gz"},
+		{"demoted before format", assetList("tool-linux-amd64-dbg", "tool-linux-amd64.zip"), DefaultDemote, "tool-linux-amd64.zip"},
+		{"static demoted", assetList("tool-linux-amd64-static.tar.gz", "tool-linux-amd64-dynamic.tar.gz"), []string{"static"}, "tool-linux-amd64-dynamic.tar.gz"},
+		{"dynamic demoted", assetList("tool-linux-amd64-static.tar.gz", "tool-linux-amd64-dynamic.tar.gz"), []string{"dynamic"}, "tool-linux-amd64-static.tar.gz"},
+		{"most specific name", assetList("tool-linux-amd64-full.tar.gz", "tool-linux-amd64.tar.gz"), DefaultDemote, "tool-linux-amd64.tar.gz"},
+		{"platform before qualifiers", assetList("tool-linux-amd64-debug.tar.gz", "tool-linux.tar.gz"), DefaultDemote, "tool-linux-amd64-debug.tar.gz"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reversed := slices.Clone(tt.assets)
+			slices.Reverse(reversed)
+			for _, order := range [][]ReleaseAsset{tt.assets, reversed} {
+				if asset, _ := selectAsset(order, host, DefaultIgnore, DefaultPrefer, tt.demote, t.Logf); asset == nil || asset.Name != tt.want {
+					t.Errorf("selectAsset(%v) = %v, want %s", order, asset, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestSelectAgnosticAsset(t *testing.T) {
+	tests := []struct {
+		name   string
+		assets []ReleaseAsset
+		host   Host
+		want   string
+	}{
+		{"single asset", assetList("tool", "tool.sha256"), Host{"linux", "amd64", "glibc"}, "tool"},
+		{"single foreign asset", assetList("tool-windows.exe"), Host{"linux", "amd64", "glibc"}, "tool-windows.exe"},
+		{"architecture only", assetList("tool-arm64", "tool-x86_64"), Host{"linux", "amd64", "glibc"}, "tool-x86_64"},
+		{"architecture only arm", assetList("tool-arm64", "tool-x86_64"), Host{"darwin", "arm64", "glibc"}, "tool-arm64"},
+		{"no platform", assetList("tool.jar", "tool-windows.zip"), Host{"linux", "amd64", "glibc"}, "tool.jar"},
+		{"other OSes only", assetList("tool-windows.zip", "tool-freebsd.tar.gz"), Host{"linux", "amd64", "glibc"}, ""},
+		{"undesired architecture", assetList("tool-arm64", "tool-aarch64"), Host{"linux", "amd64", "glibc"}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ""
+			if asset := selectAgnosticAsset(tt.assets, tt.host, DefaultIgnore, DefaultPrefer, t.Logf); asset != nil {
+				got = asset.Name
+			}
+			if got != tt.want {
+				t.Errorf("selectAgnosticAsset() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestResolveAssetMessages(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/repos/owner/empty/releases/latest":
+			fmt.Fprint(w, `{"id": 1, "assets": []}`)
+		case "/repos/owner/checksums/releases/latest":
+			fmt.Fprint(w, `{"id": 2, "assets": [{"id": 3, "name": "tool.sha256"}]}`)
+		case "/repos/owner/other/releases/latest":
+			fmt.Fprint(w, `{"id": 4, "assets": [{"id": 5, "name": "tool-windows-amd64.zip"}, {"id": 6, "name": "tool-darwin-arm64.zip"}]}`)
+		case "/repos/owner/unreleased/releases":
+			fmt.Fprint(w, `[]`)
+		case "/repos/owner/drafts/releases":
+			fmt.Fprint(w, `[{"id": 7, "draft": true, "assets": []}]`)
+		case "/repos/owner/prereleases/releases":
+			fmt.Fprint(w, `[{"id": 8, "draft": true, "assets": []}, {"id": 9, "prerelease": true, "tag_name": "v2.0-rc1", "assets": [{"id": 10, "name": "tool-linux-amd64"}]}]`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+	client := &Client{HTTP: server.Client(), APIURL: server.URL}
+
+	for name, want := range map[string]string{
+		"owner/empty":       "latest release has no downloadable assets",
+		"owner/checksums":   "latest release has no downloadable assets",
+		"owner/other":       "no asset for linux/amd64",
+		"owner/missing":     "repository not found",
+		"owner/unreleased":  "repository has no releases",
+		"owner/drafts":      "no published release (only drafts)",
+		"owner/prereleases": "no stable release (only prereleases or drafts), try -prerelease",
+	} {
+		status, err := client.ResolveAsset(&Repository{Name: name, File: "tool"}, Host{"linux", "amd64", "glibc"})
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if status.Status != RepoKO || status.Message != want {
+			t.Errorf("%s: got status %v with message %q, want %q", name, status.Status, status.Message, want)
+		}
+	}
+}
+
+func TestResolveAssetPrerelease(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/repos/owner/tool/releases/latest":
+			fmt.Fprint(w, `{"id": 1, "tag_name": "v1.0", "assets": [{"id": 2, "name": "tool-linux-amd64"}]}`)
+		case "/repos/owner/tool/releases":
+			fmt.Fprint(w, `[{"id": 3, "draft": true, "tag_name": "v2.0", "assets": []}, {"id": 4, "prerelease": true, "tag_name": "v2.0-rc1", "assets": [{"id": 5, "name": "tool-linux-amd64"}]}, {"id": 1, "tag_name": "v1.0", "assets": [{"id": 2, "name": "tool-linux-amd64"}]}]`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	for _, tt := range []struct {
+		client, repo bool
+		want         string
+	}{
+		{false, false, "v1.0"},
+		{true, false, "v2.0-rc1"},
+		{false, true, "v2.0-rc1"},
+	} {
+		client := &Client{HTTP: server.Client(), APIURL: server.URL, Prerelease: tt.client}
+		status, err := client.ResolveAsset(&Repository{Name: "owner/tool", File: "tool", Prerelease: tt.repo}, Host{"linux", "amd64", "glibc"})
+		if err != nil || status.Status != RepoOK {
+			t.Fatalf("ResolveAsset() = %v, %v", status, err)
+		}
+		if status.Tag != tt.want {
+			t.Errorf("ResolveAsset() with prerelease %v/%v got %s, want %s", tt.client, tt.repo, status.Tag, tt.want)
+		}
+	}
+}
+
+func TestResolveAssetReleaseOffset(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/repos/owner/tool/releases":
+			fmt.Fprint(w, `[{"id": 1, "prerelease": true, "tag_name": "v3.0-rc1", "assets": [{"id": 2, "name": "tool-linux-amd64"}]}, {"id": 3, "draft": true, "tag_name": "v2.1", "assets": []}, {"id": 4, "tag_name": "v2.0", "assets": [{"id": 5, "name": "tool-linux-amd64"}]}, {"id": 6, "tag_name": "v1.0", "assets": [{"id": 7, "name": "tool-linux-amd64"}]}]`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	for _, tt := range []struct {
+		offset     int
+		prerelease bool
+		want       string
+	}{
+		{1, false, "v1.0"},
+		{1, true, "v2.0"},
+		{2, true, "v1.0"},
+		{2, false, ""},
+	} {
+		client := &Client{HTTP: server.Client(), APIURL: server.URL, Prerelease: tt.prerelease, ReleaseOffset: tt.offset}
+		status, err := client.ResolveAsset(&Repository{Name: "owner/tool", File: "tool"}, Host{"linux", "amd64", "glibc"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if tt.want == "" {
+			if status.Status != RepoKO || status.Message != "no release 2 back from the newest (only 2)" {
+				t.Errorf("offset %d: got status %v with message %q, want none", tt.offset, status.Status, status.Message)
+			}
+			continue
+		}
+		if status.Status != RepoOK || status.Tag != tt.want {
+			t.Errorf("offset %d with prerelease %v: got %s (%s), want %s", tt.offset, tt.prerelease, status.Tag, status.Message, tt.want)
+		}
+	}
+}
+
+func TestResolveAssetVersion(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/repos/owner/tool/releases/tags/v1.4.1":
+			fmt.Fprint(w, `{"id": 3, "tag_name": "v1.4.1", "assets": [{"id": 30, "name": "tool-linux-amd64"}]}`)
+		case "/repos/owner/tool/releases":
+			fmt.Fprint(w, `[{"id": 1, "tag_name": "v2.0.0", "assets": [{"id": 10, "name": "tool-linux-amd64"}]}, {"id": 2, "tag_name": "v1.5.0-rc1", "prerelease": true, "assets": [{"id": 20, "name": "tool-linux-amd64"}]}, {"id": 4, "tag_name": "v1.4.0", "assets": [{"id": 40, "name": "tool-linux-amd64"}]}, {"id": 3, "tag_name": "v1.4.1", "assets": [{"id": 30, "name": "tool-linux-amd64"}]}]`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+	client := &Client{HTTP: server.Client(), APIURL: server.URL}
+
+	tests := []struct {
+		version    string
+		prerelease bool
+		want       string
+	}{
+		{"v1.4.1", false, "v1.4.1"},
+		{"1.4.1", false, "v1.4.1"},
+		{"~1.4", false, "v1.4.1"},
+		{"^1", false, "v1.4.1"},
+		{"^1", true, "v1.5.0-rc1"},
+		{"v3.0.0", false, ""},
+		{"~3", false, ""},
+	}
+	for _, tt := range tests {
+		repo := &Repository{Name: "owner/tool", File: "tool", Version: tt.version, Prerelease: tt.prerelease}
+		status, err := client.ResolveAsset(repo, Host{"linux", "amd64", "glibc"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if tt.want == "" {
+			if status.Status != RepoKO {
+				t.Errorf("version %s: installing %s, want none", tt.version, status.Tag)
+			}
+			continue
+		}
+		if status.Status != RepoOK || status.Tag != tt.want {
+			t.Errorf("version %s (prerelease: %v): got %s (%s), want %s", tt.version, tt.prerelease, status.Tag, status.Message, tt.want)
+		}
+	}
+}
+
+func TestLatestTag(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/repos/owner/tool/releases/latest":
+			fmt.Fprint(w, `{"id": 4, "tag_name": "v2.0", "published_at": "2024-05-01T10:00:00Z"}`)
+		case "/repos/owner/tool/releases":
+			fmt.Fprint(w, `[{"id": 1, "prerelease": true, "tag_name": "v3.0-rc1", "published_at": "2024-06-01T10:00:00Z"}, {"id": 4, "tag_name": "v2.0", "published_at": "2024-05-01T10:00:00Z"}]`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+	client := &Client{HTTP: server.Client(), APIURL: server.URL}
+
+	tests := []struct {
+		repo Repository
+		want string
+		date string
+	}{
+		{Repository{Name: "owner/tool"}, "v2.0", "2024-05-01"},
+		{Repository{Name: "owner/tool", Prerelease: true}, "v3.0-rc1", "2024-06-01"},
+		{Repository{Name: "owner/missing"}, "", ""},
+	}
+	for _, tt := range tests {
+		tag, publishedAt, err := client.LatestTag(&tt.repo)
+		if tt.want == "" {
+			if err == nil {
+				t.Errorf("LatestTag(%s) = %s, want an error", tt.repo.Name, tag)
+			}
+			continue
+		}
+		if err != nil || tag != tt.want || publishedAt.Format(time.DateOnly) != tt.date {
+			t.Errorf("LatestTag(%s, prerelease: %v) = %s, %v, %v, want %s", tt.repo.Name, tt.repo.Prerelease, tag, publishedAt, err, tt.want)
+		}
+	}
+}
+
+func TestResolveNamedAsset(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"id": 1, "tag_name": "v1.0", "assets": [{"id": 2, "name": "tool-linux-amd64.tar.gz"}, {"id": 3, "name": "tool-linux-amd64-static.zip"}, {"id": 4, "name": "tool-windows-amd64.zip"}]}`)
+	}))
+	defer server.Close()
+	client := &Client{HTTP: server.Client(), APIURL: server.URL}
+	host := Host{"linux", "amd64", "glibc"}
+
+	status, err := client.ResolveAsset(&Repository{Name: "owner/tool", File: "tool", Asset: "tool-linux-amd64-static.zip"}, host)
+	if err != nil || status.Status != RepoOK || status.Asset != "tool-linux-amd64-static.zip" || status.Format != ZipFormat {
+		t.Errorf("ResolveAsset() = %+v, %v, want the named zip", status, err)
+	}
+	status, err = client.ResolveAsset(&Repository{Name: "owner/tool", File: "tool", Asset: "tool-windows-amd64.zip"}, host)
+	if err != nil || status.Status != RepoOK || status.Asset != "tool-windows-amd64.zip" {
+		t.Errorf("ResolveAsset() = %+v, %v, want the named asset whatever its platform", status, err)
+	}
+	status, err = client.ResolveAsset(&Repository{Name: "owner/tool", File: "tool", Asset: "tool.tar.gz"}, host)
+	want := "no asset named tool.tar.gz, available: tool-linux-amd64.tar.gz, tool-linux-amd64-static.zip, tool-windows-amd64.zip"
+	if err != nil || status.Status != RepoKO || status.Message != want {
+		t.Errorf("ResolveAsset() = %+v, %v, want message %q", status, err, want)
+	}
+}
+
+func TestResolveAssetPattern(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"id": 1, "tag_name": "v1.0", "assets": [{"id": 2, "name": "tool-linux64.tar.gz"}, {"id": 3, "name": "tool-win.zip"}, {"id": 4, "name": "tool-static-linux-amd64.tar.gz"}, {"id": 5, "name": "tool-static-linux-arm64.tar.gz"}, {"id": 6, "name": "tool-linux-amd64.tar.gz"}, {"id": 7, "name": "tool-linux64.tar.gz.sha256"}]}`)
+	}))
+	defer server.Close()
+	client := &Client{HTTP: server.Client(), APIURL: server.URL}
+	host := Host{"linux", "amd64", "glibc"}
+
+	tests := []struct {
+		pattern string
+		want    string
+		message string
+	}{
+		{"*Linux64*", "tool-linux64.tar.gz", ""},
+		{`/-win\.zip$/`, "tool-win.zip", ""},
+		// Of several matching assets, the one for the host is selected
+		{"*-static-*", "tool-static-linux-amd64.tar.gz", ""},
+		{"*-darwin-*", "", "no asset matches *-darwin-*, available: tool-linux64.tar.gz, tool-win.zip, tool-static-linux-amd64.tar.gz, tool-static-linux-arm64.tar.gz, tool-linux-amd64.tar.gz, tool-linux64.tar.gz.sha256"},
+		{"/(/", "", "invalid asset_pattern: error parsing regexp: missing closing ): `(`"},
+	}
+	for _, tt := range tests {
+		status, err := client.ResolveAsset(&Repository{Name: "owner/tool", File: "tool", AssetPattern: tt.pattern}, host)
+		if err != nil || status.Asset != tt.want || status.Message != tt.message {
+			t.Errorf("ResolveAsset(asset_pattern %s) = %s (%s), %v, want %s (%s)", tt.pattern, status.Asset, status.Message, err, tt.want, tt.message)
+		}
+	}
+}
+
+func TestAddAliases(t *testing.T) {
+	archEquiv, osEquiv := maps.Clone(ArchEquiv), maps.Clone(OSEquiv)
+	knownArchs, knownOSes := slices.Clone(KnownArchs), slices.Clone(KnownOSes)
+	t.Cleanup(func() {
+		ArchEquiv, OSEquiv, KnownArchs, KnownOSes = archEquiv, osEquiv, knownArchs, knownOSes
+	})
+	AddOSAliases("darwin", []string{"Universal"})
+	AddArchAliases("arm64", []string{"universal"})
+
+	assets := []ReleaseAsset{{Name: "tool-universal.tar.gz"}, {Name: "tool-linux-amd64.tar.gz"}}
+	if got, _ := selectAsset(assets, Host{"darwin", "arm64", ""}, nil, nil, nil, nil); got == nil || got.Name != "tool-universal.tar.gz" {
+		t.Errorf("selectAsset() on darwin = %v, want the universal asset", got)
+	}
+	// Still ranking below the usual names
+	assets = append(assets, ReleaseAsset{Name: "tool-macos-arm64.tar.gz"})
+	if got, _ := selectAsset(assets, Host{"darwin", "arm64", ""}, nil, nil, nil, nil); got == nil || got.Name != "tool-macos-arm64.tar.gz" {
+		t.Errorf("selectAsset() on darwin = %v, want the macos asset", got)
+	}
+	if got, _ := selectAsset(assets, Host{"linux", "amd64", "glibc"}, nil, nil, nil, nil); got == nil || got.Name != "tool-linux-amd64.tar.gz" {
+		t.Errorf("selectAsset() on linux = %v, want the linux asset", got)
+	}
+	if mismatch := platformMismatch("tool-universal.tar.gz", *hostArchs(Host{Arch: "amd64"}).desired, hostOSes(Host{OS: "linux"})); mismatch != "universal" {
+		t.Errorf("platformMismatch() on linux = %q, want universal", mismatch)
+	}
+	// Other architectures are unaffected
+	if slices.Contains(*ArchEquiv["amd64"].undesired[0], "universal") {
+		t.Error("universal became undesired on amd64")
+	}
+}
+
+func TestSelectReleaseAssetInstallers(t *testing.T) {
+	linux := Host{"linux", "amd64", "glibc"}
+	windows := Host{"windows", "amd64", ""}
+	tests := []struct {
+		name     string
+		assets   []string
+		host     Host
+		appImage bool
+		want     string
+		format   EAssetFormat
+		message  string
+	}{
+		{"archive over AppImage", []string{"tool-linux-x86_64.AppImage", "tool-linux-amd64.tar.gz"}, linux, true, "tool-linux-amd64.tar.gz", TargzipFormat, ""},
+		{"only AppImage", []string{"tool-linux-x86_64.AppImage"}, linux, false, "", 0, "no asset for linux/amd64 (only an AppImage, tool-linux-x86_64.AppImage, try -allow-appimage)"},
+		{"allowed AppImage", []string{"tool-linux-x86_64.AppImage", "tool-windows-amd64.zip"}, linux, true, "tool-linux-x86_64.AppImage", AppImageFormat, ""},
+		{"only installers", []string{"tool-1.0-setup.exe", "tool-1.0.msi"}, windows, false, "", 0, "no asset for windows/amd64 (only installers: tool-1.0-setup.exe, tool-1.0.msi)"},
+		{"binary next to installers", []string{"tool-windows-amd64.msi", "tool-windows-amd64.exe", "tool-darwin.dmg"}, windows, false, "tool-windows-amd64.exe", BinaryFormat, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &Client{AllowAppImage: tt.appImage}
+			status := RepoStatus{Repo: &Repository{Name: "owner/tool", File: "tool"}}
+			asset, ok := client.selectReleaseAsset(&status, &release{Assets: assetList(tt.assets...)}, tt.host)
+			if tt.want == "" {
+				if ok || status.Message != tt.message {
+					t.Errorf("selectReleaseAsset() = %v with message %q, want %q", asset, status.Message, tt.message)
+				}
+				return
+			}
+			if !ok || asset.Name != tt.want || status.Format != tt.format {
+				t.Errorf("selectReleaseAsset() = %v (%v, %q), want %s (%v)", asset, status.Format, status.Message, tt.want, tt.format)
+			}
+		})
+	}
+}
@@ -0,0 +1,142 @@
+package gogo
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// ghTimeout bounds how long gh auth token may take, as it may need to unlock
+// the keyring.
+const ghTimeout = 5 * time.Second
+
+// ResolveToken returns the token to authenticate with, and where it was
+// found, looking in turn at the GOGO_GITHUB_TOKEN and GITHUB_TOKEN variables,
+// at what gh auth token says if the gh CLI is installed, at the hosts file of
+// older gh versions and, last, at the configuration, so that the token need
+// not be stored there. gh's token is that of the host whose API network
+// uses. Without any, both are empty and requests are anonymous.
+func ResolveToken(auth Auth, network Network) (string, string) {
+	for _, variable := range []string{"GOGO_GITHUB_TOKEN", "GITHUB_TOKEN"} {
+		if token := strings.TrimSpace(os.Getenv(variable)); token != "" {
+			return token, variable
+		}
+	}
+	host := githubHost(network.APIURL)
+	if token := ghAuthToken(host); token != "" {
+		return token, "gh auth token"
+	}
+	if token := GhToken(host); token != "" {
+		return token, "gh hosts file"
+	}
+	if auth.Token != "" && !IsPlaceholderToken(auth.Token) {
+		return auth.Token, "configuration"
+	}
+	return "", ""
+}
+
+// githubHost returns the host gh knows an API URL's GitHub by: github.com
+// for api.github.com, <name>.ghe.com for GitHub Enterprise Cloud's
+// api.<name>.ghe.com, and the API's own host for GitHub Enterprise Server.
+func githubHost(apiURL string) string {
+	parsed, err := url.Parse(apiURL)
+	if apiURL == "" || err != nil || parsed.Hostname() == "" {
+		return "github.com"
+	}
+	host := parsed.Hostname()
+	if host == "api.github.com" || strings.HasSuffix(host, ".ghe.com") {
+		host = strings.TrimPrefix(host, "api.")
+	}
+	return host
+}
+
+// ghAuthToken asks the gh CLI, if installed, for its token for host, which
+// it may keep in the system keyring.
+func ghAuthToken(host string) string {
+	gh, err := exec.LookPath("gh")
+	if err != nil {
+		return ""
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), ghTimeout)
+	defer cancel()
+	output, err := exec.CommandContext(ctx, gh, "auth", "token", "--hostname", host).Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(output))
+}
+
+// GhToken returns the token the gh CLI stored for host in its hosts file, if
+// any. Recent gh versions keep tokens in the system keyring instead, which
+// only gh auth token reads.
+func GhToken(host string) string {
+	hostsPath := ghHostsPath()
+	if hostsPath == "" {
+		return ""
+	}
+	file, err := os.Open(hostsPath)
+	if err != nil {
+		return ""
+	}
+	defer file.Close()
+	return parseGhHosts(file, host)
+}
+
+func ghHostsPath() string {
+	if dir := os.Getenv("GH_CONFIG_DIR"); dir != "" {
+		return filepath.Join(dir, "hosts.yml")
+	}
+	if dir := os.Getenv("XDG_CONFIG_HOME"); dir != "" {
+		return filepath.Join(dir, "gh", "hosts.yml")
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".config", "gh", "hosts.yml")
+}
+
+// parseGhHosts finds a host's oauth_token in gh's hosts.yml. The file is
+// simple enough that it does not take a YAML parser:
+//
+//	github.com:
+//	    user: octocat
+//	    oauth_token: gho_...
+func parseGhHosts(hosts io.Reader, host string) string {
+	scanner := bufio.NewScanner(hosts)
+	inHost := false
+	hostIndent := -1
+	for scanner.Scan() {
+		line := scanner.Text()
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		indent := len(line) - len(strings.TrimLeft(line, " \t"))
+		if indent == 0 {
+			inHost = strings.TrimSuffix(trimmed, ":") == host
+			hostIndent = -1
+			continue
+		}
+		if !inHost {
+			continue
+		}
+		// Only the host's own settings, not those nested under users
+		if hostIndent < 0 {
+			hostIndent = indent
+		}
+		if indent != hostIndent {
+			continue
+		}
+		if value, found := strings.CutPrefix(trimmed, "oauth_token:"); found {
+			return strings.Trim(strings.TrimSpace(value), `"'`)
+		}
+	}
+	return ""
+}
@@ -0,0 +1,97 @@
+package gogo
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+const ghHosts = `github.com:
+    users:
+        octocat:
+            oauth_token: gho_nested
+    oauth_token: gho_github
+    user: octocat
+    git_protocol: https
+github.example.com:
+    oauth_token: "gho_enterprise"
+`
+
+func TestParseGhHosts(t *testing.T) {
+	for host, want := range map[string]string{
+		"github.com":         "gho_github",
+		"github.example.com": "gho_enterprise",
+		"gitlab.com":         "",
+	} {
+		if got := parseGhHosts(strings.NewReader(ghHosts), host); got != want {
+			t.Errorf("parseGhHosts(%q) = %q, want %q", host, got, want)
+		}
+	}
+}
+
+func TestResolveToken(t *testing.T) {
+	const enterpriseAPI = "https://github.example.com/api/v3"
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "hosts.yml"), []byte(ghHosts), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("GH_CONFIG_DIR", dir)
+	// No gh CLI, unless the test provides one
+	t.Setenv("PATH", t.TempDir())
+
+	t.Setenv("GOGO_GITHUB_TOKEN", "ghp_gogo")
+	t.Setenv("GITHUB_TOKEN", "ghp_env")
+	if token, source := ResolveToken(Auth{Token: "ghp_config"}, Network{}); token != "ghp_gogo" {
+		t.Errorf("ResolveToken() = %q from %s, want GOGO_GITHUB_TOKEN", token, source)
+	}
+	t.Setenv("GOGO_GITHUB_TOKEN", "")
+	if token, source := ResolveToken(Auth{Token: "ghp_config"}, Network{}); token != "ghp_env" {
+		t.Errorf("ResolveToken() = %q from %s, want GITHUB_TOKEN", token, source)
+	}
+	t.Setenv("GITHUB_TOKEN", "")
+	if token, source := ResolveToken(Auth{Token: "ghp_config"}, Network{}); token != "gho_github" {
+		t.Errorf("ResolveToken() = %q from %s, want the gh token", token, source)
+	}
+	if token, source := ResolveToken(Auth{Token: "ghp_config"}, Network{APIURL: enterpriseAPI}); token != "gho_enterprise" {
+		t.Errorf("ResolveToken() = %q from %s, want the enterprise gh token", token, source)
+	}
+	t.Setenv("GH_CONFIG_DIR", t.TempDir())
+	if token, source := ResolveToken(Auth{Token: "ghp_config"}, Network{}); token != "ghp_config" {
+		t.Errorf("ResolveToken() = %q from %s, want the configured token", token, source)
+	}
+	if token, source := ResolveToken(Auth{Token: PlaceholderToken}, Network{}); token != "" {
+		t.Errorf("ResolveToken() = %q from %s, want none", token, source)
+	}
+
+	if runtime.GOOS == "windows" {
+		return
+	}
+	bin := t.TempDir()
+	gh := "#!/bin/sh\ncase \"$*\" in\n\"auth token --hostname github.com\") echo gho_keyring ;;\n\"auth token --hostname github.example.com\") echo gho_enterprise_keyring ;;\nesac\n"
+	if err := os.WriteFile(filepath.Join(bin, "gh"), []byte(gh), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", bin)
+	if token, source := ResolveToken(Auth{Token: "ghp_config"}, Network{}); token != "gho_keyring" || source != "gh auth token" {
+		t.Errorf("ResolveToken() = %q from %s, want gh auth token's", token, source)
+	}
+	if token, source := ResolveToken(Auth{Token: "ghp_config"}, Network{APIURL: enterpriseAPI}); token != "gho_enterprise_keyring" || source != "gh auth token" {
+		t.Errorf("ResolveToken() = %q from %s, want gh auth token's for the enterprise host", token, source)
+	}
+}
+
+func TestGithubHost(t *testing.T) {
+	for apiURL, want := range map[string]string{
+		"":                                  "github.com",
+		"https://api.github.com":            "github.com",
+		"https://github.example.com/api/v3": "github.example.com",
+		"https://api.acme.ghe.com":          "acme.ghe.com",
+		"http://localhost:8080/api/v3":      "localhost",
+	} {
+		if got := githubHost(apiURL); got != want {
+			t.Errorf("githubHost(%q) = %q, want %q", apiURL, got, want)
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package gogo
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// DefaultCatalogAsset is the release asset catalogs publish, unless they
+// name another one.
+const DefaultCatalogAsset = "config.tgz"
+
+// DefaultCatalog is gogo's own list of commands.
+var DefaultCatalog = Catalog{Repo: "fusion/gogo"}
+
+// Catalog is a repository whose releases carry configuration files listing
+// commands, as a gzipped tarball, for gogo refresh to install.
+type Catalog struct {
+	// Repo is the catalog's repository, as owner/repo
+	Repo string `toml:"repo"`
+	// Asset is the release's tarball, DefaultCatalogAsset if empty
+	Asset string `toml:"asset"`
+}
+
+// prefix is prepended to the catalog's files, so that catalogs do not
+// overwrite each other. gogo's own catalog keeps its names.
+func (catalog Catalog) prefix() string {
+	if catalog.Repo == DefaultCatalog.Repo {
+		return ""
+	}
+	return strings.ReplaceAll(catalog.Repo, "/", "_") + "_"
+}
+
+// RefreshCatalog downloads the catalog's latest release tarball and extracts
+// it to configDir, returning the extracted paths.
+func (c *Client) RefreshCatalog(catalog Catalog, configDir string) ([]string, error) {
+	if err := ValidateName(catalog.Repo); err != nil {
+		return nil, err
+	}
+	assetName := catalog.Asset
+	if assetName == "" {
+		assetName = DefaultCatalogAsset
+	}
+	repo := &Repository{Name: catalog.Repo}
+	release, message, err := c.latestRelease(repo, false)
+	if err != nil {
+		return nil, err
+	}
+	if release == nil {
+		return nil, fmt.Errorf("%s: %s", catalog.Repo, message)
+	}
+	var asset *ReleaseAsset
+	for i := range release.Assets {
+		if release.Assets[i].Name == assetName {
+			asset = &release.Assets[i]
+		}
+	}
+	if asset == nil {
+		return nil, fmt.Errorf("%s: no %s in release %s", catalog.Repo, assetName, release.TagName)
+	}
+	c.logf("  - Downloading %s from %s\n", assetName, release.TagName)
+	req, err := newDownloadRequest(c.context(), c.assetURL(repo, asset), c.Token, c.repoHeaders(repo))
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.HTTP.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("non-OK HTTP status: %s", resp.Status)
+	}
+	return ExtractConfigArchive(configDir, catalog.prefix(), resp.Body)
+}
@@ -0,0 +1,64 @@
+package gogo
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestRefreshCatalog(t *testing.T) {
+	var archive bytes.Buffer
+	gzipWriter := gzip.NewWriter(&archive)
+	tw := tar.NewWriter(gzipWriter)
+	for _, name := range []string{"config/config.toml", "config/catalog.toml"} {
+		tw.WriteHeader(&tar.Header{Name: name, Typeflag: tar.TypeReg, Mode: 0o644, Size: 1})
+		tw.Write([]byte("\n"))
+	}
+	tw.Close()
+	gzipWriter.Close()
+
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/repos/fusion/gogo/releases/latest", "/repos/myorg/catalog/releases/latest":
+			fmt.Fprintf(w, `{"id": 1, "tag_name": "v1.0", "assets": [{"name": "config.tgz", "browser_download_url": "%s/config.tgz"}, {"name": "team.tgz", "browser_download_url": "%s/config.tgz"}]}`, server.URL, server.URL)
+		case "/config.tgz":
+			w.Write(archive.Bytes())
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+	client := &Client{HTTP: server.Client(), APIURL: server.URL}
+
+	tests := []struct {
+		catalog Catalog
+		want    string
+	}{
+		{DefaultCatalog, "catalog.toml"},
+		{Catalog{Repo: "myorg/catalog"}, "myorg_catalog_catalog.toml"},
+		{Catalog{Repo: "myorg/catalog", Asset: "team.tgz"}, "myorg_catalog_catalog.toml"},
+	}
+	for _, tt := range tests {
+		configDir := t.TempDir()
+		extracted, err := client.RefreshCatalog(tt.catalog, configDir)
+		if err != nil {
+			t.Fatalf("RefreshCatalog(%v): %v", tt.catalog, err)
+		}
+		if want := []string{filepath.Join(configDir, tt.want)}; !slices.Equal(extracted, want) {
+			t.Errorf("RefreshCatalog(%v) extracted %v, want %v", tt.catalog, extracted, want)
+		}
+	}
+
+	for _, catalog := range []Catalog{{Repo: "myorg/catalog", Asset: "missing.tgz"}, {Repo: "myorg/missing"}, {Repo: "invalid"}} {
+		if _, err := client.RefreshCatalog(catalog, t.TempDir()); err == nil {
+			t.Errorf("RefreshCatalog(%v) succeeded", catalog)
+		}
+	}
+}
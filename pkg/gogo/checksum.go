@@ -0,0 +1,121 @@
+package gogo
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"strings"
+)
+
+// maxChecksumSize bounds checksum files, which list a few lines per asset.
+const maxChecksumSize = 1 << 20
+
+// findChecksumAsset returns the release's asset holding the SHA-256 checksum
+// of assetName: <asset>.sha256 or <asset>.sha256sum, or else a file listing
+// the checksums of all assets, such as SHA256SUMS or checksums.txt. It
+// returns nil if there is none.
+func findChecksumAsset(assets []ReleaseAsset, assetName string) *ReleaseAsset {
+	for _, suffix := range []string{".sha256", ".sha256sum"} {
+		for i := range assets {
+			if strings.EqualFold(assets[i].Name, assetName+suffix) {
+				return &assets[i]
+			}
+		}
+	}
+	for i := range assets {
+		if isChecksumList(assets[i].Name) {
+			return &assets[i]
+		}
+	}
+	return nil
+}
+
+// isChecksumList tells whether an asset lists the SHA-256 checksums of a
+// release's assets, e.g. SHA256SUMS, sha256sums.txt or tool_1.0_checksums.txt.
+func isChecksumList(name string) bool {
+	name = strings.ToLower(name)
+	if ignoredSuffix(name, []string{".sig", ".minisig", ".asc", ".pem"}) != "" || strings.Contains(name, "sha512") || strings.Contains(name, "sha1") {
+		return false
+	}
+	return strings.Contains(name, "sha256sum") || strings.Contains(name, "checksums")
+}
+
+// fetchChecksum gets the SHA-256 checksum of the asset being installed from
+// the checksum file at status.ChecksumUrl, empty if it is not listed.
+func (c *Client) fetchChecksum(status *RepoStatus) (string, error) {
+	req, err := newDownloadRequest(c.context(), status.ChecksumUrl, c.Token, c.repoHeaders(status.Repo))
+	if err != nil {
+		return "", err
+	}
+	resp, err := c.HTTP.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("non-OK HTTP status fetching checksum: %s", resp.Status)
+	}
+	return parseChecksum(io.LimitReader(resp.Body, maxChecksumSize), status.Asset)
+}
+
+// parseChecksum finds assetName's SHA-256 checksum in a checksum file, in
+// the format of sha256sum ("<checksum>  <name>", the name optionally
+// prefixed with *), in the BSD format ("SHA256 (<name>) = <checksum>"), or a
+// lone checksum. It returns an empty checksum if assetName is not listed.
+func parseChecksum(content io.Reader, assetName string) (string, error) {
+	scanner := bufio.NewScanner(content)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		var checksum, name string
+		switch {
+		case len(fields) == 1:
+			checksum = fields[0]
+		case len(fields) == 4 && fields[0] == "SHA256" && fields[2] == "=":
+			checksum, name = fields[3], strings.Trim(fields[1], "()")
+		case len(fields) == 2:
+			checksum, name = fields[0], strings.TrimPrefix(fields[1], "*")
+		default:
+			continue
+		}
+		if name != "" && path.Base(name) != assetName {
+			continue
+		}
+		if _, err := hex.DecodeString(checksum); err != nil || len(checksum) != sha256.Size*2 {
+			return "", fmt.Errorf("invalid SHA-256 checksum for %s: %s", assetName, checksum)
+		}
+		return strings.ToLower(checksum), nil
+	}
+	return "", scanner.Err()
+}
+
+// verifyChecksum makes sure the file at filePath has the given SHA-256
+// checksum.
+func verifyChecksum(filePath string, checksum string) error {
+	actual, err := fileChecksum(filePath)
+	if err != nil {
+		return err
+	}
+	if actual != checksum {
+		return fmt.Errorf("checksum mismatch: got %s, expected %s", actual, checksum)
+	}
+	return nil
+}
+
+// fileChecksum returns the SHA-256 checksum of the file at filePath.
+func fileChecksum(filePath string) (string, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
@@ -0,0 +1,98 @@
+package gogo
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFindChecksumAsset(t *testing.T) {
+	tests := []struct {
+		assets []ReleaseAsset
+		want   string
+	}{
+		{assetList("tool-linux-amd64.tar.gz", "tool-linux-amd64.tar.gz.sha256", "checksums.txt"), "tool-linux-amd64.tar.gz.sha256"},
+		{assetList("tool-linux-amd64.tar.gz", "tool_1.0_checksums.txt", "tool_1.0_checksums.txt.sig"), "tool_1.0_checksums.txt"},
+		{assetList("tool-linux-amd64.tar.gz", "SHA256SUMS.asc", "SHA256SUMS"), "SHA256SUMS"},
+		{assetList("tool-linux-amd64.tar.gz", "SHA512SUMS", "tool-linux-amd64.tar.gz.sha1"), ""},
+	}
+	for _, tt := range tests {
+		got := ""
+		if asset := findChecksumAsset(tt.assets, "tool-linux-amd64.tar.gz"); asset != nil {
+			got = asset.Name
+		}
+		if got != tt.want {
+			t.Errorf("findChecksumAsset(%v) = %q, want %q", tt.assets, got, tt.want)
+		}
+	}
+}
+
+func TestParseChecksum(t *testing.T) {
+	sum := strings.Repeat("ab", 32)
+	tests := []struct {
+		content string
+		want    string
+		valid   bool
+	}{
+		{sum + "\n", sum, true},
+		{strings.Repeat("cd", 32) + "  other.tar.gz\n" + sum + "  tool.tar.gz\n", sum, true},
+		{sum + " *dist/tool.tar.gz\n", sum, true},
+		{"SHA256 (tool.tar.gz) = " + sum + "\n", sum, true},
+		{sum + "  other.tar.gz\n", "", true},
+		{"d41d8cd98f00b204e9800998ecf8427e  tool.tar.gz\n", "", false},
+	}
+	for _, tt := range tests {
+		got, err := parseChecksum(strings.NewReader(tt.content), "tool.tar.gz")
+		if got != tt.want || (err == nil) != tt.valid {
+			t.Errorf("parseChecksum(%q) = %q, %v, want %q", tt.content, got, err, tt.want)
+		}
+	}
+}
+
+func TestInstallVerifiesChecksum(t *testing.T) {
+	content := "#!/bin/sh\n"
+	sum := sha256.Sum256([]byte(content))
+	checksums := map[string]string{
+		"/good.sha256": hex.EncodeToString(sum[:]) + "  tool-linux-amd64\n",
+		"/bad.sha256":  strings.Repeat("00", 32) + "  tool-linux-amd64\n",
+		"/other.txt":   strings.Repeat("00", 32) + "  tool-darwin-arm64\n",
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if checksum, ok := checksums[r.URL.Path]; ok {
+			fmt.Fprint(w, checksum)
+			return
+		}
+		fmt.Fprint(w, content)
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		checksum string
+		skip     bool
+		valid    bool
+	}{
+		{"/good.sha256", false, true},
+		{"/bad.sha256", false, false},
+		{"/bad.sha256", true, true},
+		{"/other.txt", false, true},
+	}
+	for _, tt := range tests {
+		targetDir := t.TempDir()
+		repo := &Repository{Name: "owner/tool", File: "tool"}
+		status := &RepoStatus{Repo: repo, Status: RepoOK, Format: BinaryFormat, Asset: "tool-linux-amd64", Url: server.URL + "/tool-linux-amd64", ChecksumUrl: server.URL + tt.checksum}
+		client := &Client{HTTP: server.Client(), SkipChecksum: tt.skip}
+		err := client.Install(status, targetDir)
+		if (err == nil) != tt.valid {
+			t.Errorf("Install() with checksum %s (skip: %v) = %v, want success: %v", tt.checksum, tt.skip, err, tt.valid)
+		}
+		if _, statErr := os.Stat(filepath.Join(targetDir, "tool")); (statErr == nil) != tt.valid {
+			t.Errorf("checksum %s (skip: %v): installed: %v, want %v", tt.checksum, tt.skip, statErr == nil, tt.valid)
+		}
+	}
+}
@@ -0,0 +1,98 @@
+package gogo
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// completion describes where a shell completion file found in an archive
+// gets installed.
+type completion struct {
+	shell    string
+	patterns []string
+	dir      string
+	name     string
+}
+
+// completionShells lists, for each supported shell, the archive entries
+// recognized as a command's completion and the name it is installed as.
+var completionShells = map[string]func(command string) ([]string, string){
+	"bash": func(command string) ([]string, string) {
+		return []string{command + ".bash", "*.bash", "*.bash-completion", "*.bash_completion", "bash/" + command}, command
+	},
+	"zsh": func(command string) ([]string, string) {
+		return []string{"_" + command, "*.zsh"}, "_" + command
+	},
+	"fish": func(command string) ([]string, string) {
+		return []string{command + ".fish", "*.fish"}, command + ".fish"
+	},
+}
+
+// newCompletions parses a repository's completions, each being a shell name,
+// optionally followed by ":" and a pattern replacing the usual ones, e.g.
+// "zsh:contrib/completion/_tool".
+func newCompletions(repo *Repository) ([]completion, error) {
+	var completions []completion
+	for _, entry := range repo.Completions {
+		shell, pattern, custom := strings.Cut(entry, ":")
+		shellInfo, ok := completionShells[shell]
+		if !ok {
+			return nil, fmt.Errorf("unsupported shell for completions: %s", shell)
+		}
+		patterns, name := shellInfo(repo.MainCommand())
+		if custom {
+			patterns = []string{pattern}
+		}
+		completions = append(completions, completion{
+			shell:    shell,
+			patterns: patterns,
+			dir:      CompletionDir(shell),
+			name:     name,
+		})
+	}
+	return completions, nil
+}
+
+// CompletionDir returns the directory where the given shell looks for user
+// completions, or an empty string if it cannot be determined.
+func CompletionDir(shell string) string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	switch shell {
+	case "bash":
+		if dir := os.Getenv("BASH_COMPLETION_USER_DIR"); dir != "" {
+			return filepath.Join(dir, "completions")
+		}
+		return filepath.Join(xdgDir("XDG_DATA_HOME", home, ".local/share"), "bash-completion", "completions")
+	case "zsh":
+		// Only zsh knows its fpath: use the first user directory it exported
+		for _, dir := range filepath.SplitList(os.Getenv("FPATH")) {
+			if strings.HasPrefix(dir, home+string(filepath.Separator)) && ExistFile(dir) {
+				return dir
+			}
+		}
+	case "fish":
+		return filepath.Join(xdgDir("XDG_CONFIG_HOME", home, ".config"), "fish", "completions")
+	}
+	return ""
+}
+
+func xdgDir(variable string, home string, fallback string) string {
+	if dir := os.Getenv(variable); dir != "" {
+		return dir
+	}
+	return filepath.Join(home, fallback)
+}
+
+// path returns where the completion should be written, falling back to
+// targetDir when the shell's completion directory is unknown or unusable.
+func (c *completion) path(targetDir string, entryName string) (string, error) {
+	if c.dir != "" && os.MkdirAll(c.dir, 0o755) == nil {
+		return filepath.Join(c.dir, c.name), nil
+	}
+	return safeJoin(targetDir, filepath.Base(entryName))
+}
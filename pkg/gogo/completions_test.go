@@ -0,0 +1,54 @@
+package gogo
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestCompletionDestination(t *testing.T) {
+	home := t.TempDir()
+	targetDir := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_DATA_HOME", "")
+	t.Setenv("XDG_CONFIG_HOME", "")
+	t.Setenv("BASH_COMPLETION_USER_DIR", "")
+	t.Setenv("FPATH", "")
+
+	repo := &Repository{Name: "owner/tool", File: "tool", Completions: []string{"bash", "zsh", "fish:contrib/*.fish"}}
+	extraction, err := newExtraction(&RepoStatus{Repo: repo, Mode: 0o755}, targetDir, DefaultMaxSize)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		entry string
+		want  string
+	}{
+		{"tool-1.0/tool", filepath.Join(targetDir, "tool")},
+		{"tool-1.0/completions/tool.bash", filepath.Join(home, ".local/share/bash-completion/completions/tool")},
+		{"tool-1.0/completions/other.bash", ""},
+		// zsh does not tell us its fpath
+		{"tool-1.0/completions/_tool", filepath.Join(targetDir, "_tool")},
+		{"tool-1.0/completions/tool.fish", ""},
+		{"tool-1.0/contrib/tool.fish", filepath.Join(home, ".config/fish/completions/tool.fish")},
+	}
+	for _, tt := range tests {
+		got, mode, err := extraction.destination(tt.entry, 0o644)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tt.want {
+			t.Errorf("destination(%q) = %q, want %q", tt.entry, got, tt.want)
+		}
+		if got != "" && filepath.Base(got) != "tool" && mode != 0o644 {
+			t.Errorf("destination(%q) mode = %v, want 0644", tt.entry, mode)
+		}
+	}
+	if !extraction.complete() {
+		t.Error("extraction is not complete")
+	}
+
+	repo.Completions = []string{"powershell"}
+	if _, err := newExtraction(&RepoStatus{Repo: repo}, targetDir, DefaultMaxSize); err == nil {
+		t.Error("unsupported shell was accepted")
+	}
+}
@@ -0,0 +1,64 @@
+package gogo
+
+import (
+	"bufio"
+	"bytes"
+	"compress/bzip2"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/ulikunitz/xz"
+)
+
+// decompress recognizes how a tarball or a package's payload is compressed
+// from its first bytes, and returns its content. Uncompressed content is
+// returned as is. Decompressing stops when ctx is done.
+func decompress(ctx context.Context, reader *bufio.Reader) (io.ReadCloser, error) {
+	magic, _ := reader.Peek(6)
+	switch {
+	case bytes.HasPrefix(magic, []byte{0x1f, 0x8b}):
+		return gzip.NewReader(reader)
+	case bytes.HasPrefix(magic, []byte("BZh")):
+		return io.NopCloser(bzip2.NewReader(reader)), nil
+	case bytes.HasPrefix(magic, []byte{0xfd, '7', 'z', 'X', 'Z', 0x00}):
+		content, err := xz.NewReader(reader)
+		if err != nil {
+			return nil, fmt.Errorf("invalid xz stream: %v", err)
+		}
+		return &contextReader{ctx: ctx, ReadCloser: io.NopCloser(content)}, nil
+	case bytes.HasPrefix(magic, []byte{0x28, 0xb5, 0x2f, 0xfd}):
+		// Closing stops the decoder's goroutines, whether or not the whole
+		// stream was read
+		decoder, err := zstd.NewReader(reader)
+		if err != nil {
+			return nil, fmt.Errorf("invalid zstd stream: %v", err)
+		}
+		return &contextReader{ctx: ctx, ReadCloser: decoder.IOReadCloser()}, nil
+	}
+	return io.NopCloser(reader), nil
+}
+
+// contextReader stops reading once ctx is done, for decompression that takes
+// long enough to be worth interrupting.
+type contextReader struct {
+	io.ReadCloser
+	ctx context.Context
+}
+
+func (r *contextReader) Read(p []byte) (int, error) {
+	if err := r.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return r.ReadCloser.Read(p)
+}
+
+// closeReader closes reader once done with it, setting *err to what closing
+// reports unless there was an error already.
+func closeReader(reader io.Closer, err *error) {
+	if closeErr := reader.Close(); *err == nil {
+		*err = closeErr
+	}
+}
@@ -0,0 +1,79 @@
+package gogo
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"io"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/ulikunitz/xz"
+)
+
+func TestDecompress(t *testing.T) {
+	content := bytes.Repeat([]byte("gogo "), 100000)
+	for _, tt := range []struct {
+		format   string
+		compress func(w io.Writer) (io.WriteCloser, error)
+	}{
+		{"xz", func(w io.Writer) (io.WriteCloser, error) { return xz.NewWriter(w) }},
+		{"zstd", func(w io.Writer) (io.WriteCloser, error) { return zstd.NewWriter(w) }},
+	} {
+		t.Run(tt.format, func(t *testing.T) {
+			var compressed bytes.Buffer
+			writer, err := tt.compress(&compressed)
+			if err != nil {
+				t.Fatal(err)
+			}
+			writer.Write(content)
+			if err := writer.Close(); err != nil {
+				t.Fatal(err)
+			}
+
+			reader, err := decompress(context.Background(), bufio.NewReader(bytes.NewReader(compressed.Bytes())))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got, err := io.ReadAll(reader); err != nil || !bytes.Equal(got, content) {
+				t.Errorf("decompressed %d bytes (%v), want %d", len(got), err, len(content))
+			}
+			if err := reader.Close(); err != nil {
+				t.Errorf("Close() = %v", err)
+			}
+
+			// Reading stops early, as with a tarball's end
+			reader, err = decompress(context.Background(), bufio.NewReader(bytes.NewReader(compressed.Bytes())))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := io.ReadFull(reader, make([]byte, 10)); err != nil {
+				t.Fatal(err)
+			}
+			if err := reader.Close(); err != nil {
+				t.Errorf("Close() before the end = %v", err)
+			}
+
+			truncated := compressed.Bytes()[:compressed.Len()/2]
+			reader, err = decompress(context.Background(), bufio.NewReader(bytes.NewReader(truncated)))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := io.Copy(io.Discard, reader); err == nil {
+				t.Error("decompressed a truncated stream")
+			}
+			reader.Close()
+
+			ctx, cancel := context.WithCancel(context.Background())
+			reader, err = decompress(ctx, bufio.NewReader(bytes.NewReader(compressed.Bytes())))
+			if err != nil {
+				t.Fatal(err)
+			}
+			cancel()
+			if _, err := io.Copy(io.Discard, reader); err == nil {
+				t.Error("decompressing went on once cancelled")
+			}
+			reader.Close()
+		})
+	}
+}
@@ -0,0 +1,538 @@
+package gogo
+
+import (
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"dario.cat/mergo"
+	"github.com/BurntSushi/toml"
+)
+
+type Auth struct {
+	Token string `toml:"token"`
+}
+
+type Paths struct {
+	TargetDir string `toml:"targetdir"`
+	Mode      string `toml:"mode"`
+	// Keep, if positive, is how many versions of each command are kept
+	Keep int `toml:"keep"`
+}
+
+// ArchAlias gives other names assets use for an architecture.
+type ArchAlias struct {
+	Arch  string   `toml:"arch"`
+	Names []string `toml:"names"`
+}
+
+// OSAlias gives other names assets use for an OS.
+type OSAlias struct {
+	OS    string   `toml:"os"`
+	Names []string `toml:"names"`
+}
+
+type Filter struct {
+	// DefaultTags filter list and fetch when no tags are given
+	DefaultTags []string `toml:"default_tags"`
+}
+
+// Repository tells where to install commands from. File names it, in gogo
+// list, fetch and the state, and is its command's name too, unless Command
+// gives another one: the name of the command both in the release's asset and
+// once installed.
+type Repository struct {
+	Name        string            `toml:"name"`
+	Provider    string            `toml:"provider"`
+	Host        string            `toml:"host"`
+	URL         string            `toml:"url"`
+	File        string            `toml:"file"`
+	Files       []string          `toml:"files"`
+	Command     string            `toml:"command"`
+	Utils       []string          `toml:"utils"`
+	UtilsDest   map[string]string `toml:"utils_dest"`
+	Completions []string          `toml:"completions"`
+	GoInstall   string            `toml:"go_install"`
+	Signature   string            `toml:"signature"`
+	PubKey      string            `toml:"pubkey"`
+	Mode        string            `toml:"mode"`
+	Comment     string            `toml:"comment"`
+	Tags        []string          `toml:"tags"`
+	Prefer      []string          `toml:"prefer"`
+	Ignore      []string          `toml:"ignore"`
+	Demote      []string          `toml:"demote"`
+	// VerifyCmd holds the arguments a command is run with to verify it
+	VerifyCmd string `toml:"verify_cmd"`
+	// Headers are added to the repository's requests
+	Headers map[string]string `toml:"headers"`
+	// Prerelease installs from prereleases too
+	Prerelease bool `toml:"prerelease"`
+	// Version pins the release to install: a tag, or a constraint such as
+	// "~1.4" satisfied by the highest matching release
+	Version string `toml:"version"`
+	// AppImage installs AppImages when no other asset fits
+	AppImage bool `toml:"appimage"`
+	// Mirrors are URL templates tried in order when downloading the asset
+	// fails, e.g. "https://mirror.example.com/{{.Asset}}"
+	Mirrors []string `toml:"mirrors"`
+	// Disabled repositories are only installed when asked for by name
+	Disabled bool `toml:"disabled"`
+	// AssetPattern selects the asset to install among those whose name
+	// matches it: a glob, or a regular expression between slashes
+	AssetPattern string `toml:"asset_pattern"`
+	// Asset, only set from the command line, names the asset to install
+	// rather than selecting one
+	Asset string `toml:"-"`
+	// UtilsOnly, only set from the command line, installs the utils and
+	// completions but leaves the commands alone
+	UtilsOnly bool `toml:"-"`
+}
+
+type Repositories []Repository
+
+// MainCommand is the name of the repository's command: Command if set,
+// File otherwise.
+func (r *Repository) MainCommand() string {
+	if r.Command != "" {
+		return r.Command
+	}
+	return r.File
+}
+
+// MainBinary is the file name of the repository's command, which ends in
+// .exe on Windows.
+func (r *Repository) MainBinary() string {
+	return executableName(r.MainCommand())
+}
+
+// Binaries lists the file names of every command installed from the
+// repository.
+func (r *Repository) Binaries() []string {
+	binaries := []string{r.MainBinary()}
+	for _, file := range r.Files {
+		binaries = append(binaries, executableName(file))
+	}
+	return binaries
+}
+
+// executableName returns the file name of a command, adding the suffix
+// executables need on this platform unless it is there already.
+func executableName(name string) string {
+	if strings.HasSuffix(strings.ToLower(name), exeSuffix) {
+		return name
+	}
+	return name + exeSuffix
+}
+
+// WithoutUtils returns a copy of the repository only installing its commands.
+func (r Repository) WithoutUtils() *Repository {
+	r.Utils, r.UtilsDest, r.Completions = nil, nil, nil
+	return &r
+}
+
+func (p Repositories) Len() int           { return len(p) }
+func (p Repositories) Less(i, j int) bool { return p[i].File < p[j].File }
+func (p Repositories) Swap(i, j int)      { p[i], p[j] = p[j], p[i] }
+
+// SortBy sorts repositories with the given comparisons, the first one taking
+// precedence, and then by file.
+func (p Repositories) SortBy(cmps ...func(a, b *Repository) int) {
+	slices.SortStableFunc(p, func(a, b Repository) int {
+		for _, cmp := range cmps {
+			if c := cmp(&a, &b); c != 0 {
+				return c
+			}
+		}
+		return strings.Compare(a.File, b.File)
+	})
+}
+
+// ByTag compares repositories by their first tag, untagged ones last.
+func ByTag(a, b *Repository) int {
+	switch {
+	case len(a.Tags) == 0 && len(b.Tags) == 0:
+		return 0
+	case len(a.Tags) == 0:
+		return 1
+	case len(b.Tags) == 0:
+		return -1
+	}
+	return strings.Compare(a.Tags[0], b.Tags[0])
+}
+
+type Platform struct {
+	Libc   string   `toml:"libc"`
+	Prefer []string `toml:"prefer"`
+	Ignore []string `toml:"ignore"`
+	Demote []string `toml:"demote"`
+}
+
+type Network struct {
+	Proxy string `toml:"proxy"`
+	// Headers are added to every request made for a repository
+	Headers map[string]string `toml:"headers"`
+	// Retries is how many times failed requests are tried again, 3 if 0,
+	// none if negative
+	Retries int `toml:"retries"`
+	// Timeout bounds connecting and waiting for a response, e.g. "30s"
+	Timeout string `toml:"timeout"`
+	// APIURL is the GitHub API's root URL, that of a GitHub Enterprise
+	// Server being https://<host>/api/v3
+	APIURL string `toml:"api_url"`
+}
+
+type Config struct {
+	Auth         Auth         `toml:"auth"`
+	Paths        Paths        `toml:"paths"`
+	Platform     Platform     `toml:"platform"`
+	Network      Network      `toml:"network"`
+	Filter       Filter       `toml:"filter"`
+	Catalogs     []Catalog    `toml:"catalogs"`
+	ArchAliases  []ArchAlias  `toml:"arch_alias"`
+	OSAliases    []OSAlias    `toml:"os_alias"`
+	Repositories Repositories `toml:"repositories"`
+
+	// Oddities found while reading the configuration, worth fixing
+	warnings []string
+}
+
+var DefaultMode os.FileMode = 0o755
+
+// DefaultMaxSize is the largest file installed unless told otherwise.
+const DefaultMaxSize int64 = 2 << 30
+
+// ReadConfig reads a configuration file or, if configPath is a directory,
+// merges every .toml file it contains, in lexical order. configPath may also
+// be a comma-separated list of files and directories, merged in order.
+// Either way, later files override the settings of earlier ones, while their
+// repositories add up.
+func ReadConfig(configPath string) (Config, error) {
+	var config Config
+	for _, onePath := range strings.Split(configPath, ",") {
+		oneConfig, err := readConfigPath(strings.TrimSpace(onePath))
+		if err != nil {
+			return config, err
+		}
+		if err := mergeConfig(&config, oneConfig); err != nil {
+			return config, err
+		}
+	}
+	for i := range config.Repositories {
+		repo := &config.Repositories[i]
+		if repo.URL == "" {
+			repo.Name = NormalizeName(repo.Name)
+		}
+		var duplicates []string
+		if repo.Tags, duplicates = NormalizeTags(repo.Tags); len(duplicates) > 0 {
+			config.warnings = append(config.warnings, fmt.Sprintf("%s: tags listed more than once: %s", repo.File, strings.Join(duplicates, ", ")))
+		}
+	}
+	config.Filter.DefaultTags, _ = NormalizeTags(config.Filter.DefaultTags)
+	sort.Sort(Repositories(config.Repositories))
+
+	return config, nil
+}
+
+// mergeConfig merges other into config: its settings, lists included,
+// override those of config, while its repositories are added to config's.
+func mergeConfig(config *Config, other Config) error {
+	repositories := append(config.Repositories, other.Repositories...)
+	if err := mergo.Merge(config, other, mergo.WithOverride); err != nil {
+		return err
+	}
+	config.Repositories = repositories
+	return nil
+}
+
+// NormalizeTags lowercases and trims tags, so that "CLI" and "cli " are the
+// same tag, and removes duplicates, which it also returns.
+func NormalizeTags(tags []string) ([]string, []string) {
+	var normalized, duplicates []string
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		switch {
+		case tag == "":
+		case slices.Contains(normalized, tag):
+			if !slices.Contains(duplicates, tag) {
+				duplicates = append(duplicates, tag)
+			}
+		default:
+			normalized = append(normalized, tag)
+		}
+	}
+	return normalized, duplicates
+}
+
+// AddAliases teaches asset selection the configured names of architectures
+// and OSes.
+func (config *Config) AddAliases() {
+	for _, alias := range config.ArchAliases {
+		AddArchAliases(alias.Arch, alias.Names)
+	}
+	for _, alias := range config.OSAliases {
+		AddOSAliases(alias.OS, alias.Names)
+	}
+}
+
+// Warnings lists what ReadConfig found odd, without being a problem.
+func (config *Config) Warnings() []string {
+	return config.warnings
+}
+
+// NormalizeName turns a GitHub repository URL, as pasted from a browser, into
+// the owner/repo form repositories are named with. Other names are returned
+// as is.
+func NormalizeName(name string) string {
+	trimmed := strings.TrimPrefix(strings.TrimPrefix(name, "https://"), "http://")
+	trimmed, found := strings.CutPrefix(trimmed, "github.com/")
+	if !found {
+		return name
+	}
+	bits := strings.Split(strings.TrimSuffix(trimmed, "/"), "/")
+	if len(bits) < 2 || bits[0] == "" || bits[1] == "" {
+		return name
+	}
+	return bits[0] + "/" + strings.TrimSuffix(bits[1], ".git")
+}
+
+// FilterTags returns the tags to filter repositories with: the given ones or,
+// if nil, the default ones. Filtering on "all" keeps every repository.
+func (config *Config) FilterTags(tags []string) []string {
+	if tags == nil {
+		tags = config.Filter.DefaultTags
+	}
+	if len(tags) == 1 && tags[0] == "all" {
+		return nil
+	}
+	return tags
+}
+
+// ValidateName makes sure a repository name is of the owner/repo form, as
+// it is used to build API URLs.
+func ValidateName(name string) error {
+	if strings.Contains(name, "://") {
+		return fmt.Errorf("malformed repository name %q: expected owner/repo, not a URL", name)
+	}
+	owner, repo, found := strings.Cut(name, "/")
+	if !found || owner == "" || repo == "" || strings.Contains(repo, "/") || strings.ContainsAny(name, " ?#") {
+		return fmt.Errorf("malformed repository name %q: expected owner/repo", name)
+	}
+	return nil
+}
+
+// CheckPrefer makes sure a format preference only lists known formats.
+func CheckPrefer(prefer []string) error {
+	for _, name := range prefer {
+		if _, err := ParseFormat(name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkHeaders makes sure extra headers can be sent as they are.
+func checkHeaders(headers map[string]string) error {
+	for name, value := range headers {
+		if name == "" || strings.ContainsAny(name, " \t\r\n:") {
+			return fmt.Errorf("invalid header name %q", name)
+		}
+		if strings.ContainsAny(value, "\r\n") {
+			return fmt.Errorf("invalid value for header %s", name)
+		}
+	}
+	return nil
+}
+
+// Validate lists the problems found in a configuration, without contacting
+// anything. It returns nil if there are none.
+func (config *Config) Validate() []error {
+	var problems []error
+	if config.Paths.TargetDir != "" {
+		if _, err := ExpandPath(config.Paths.TargetDir); err != nil {
+			problems = append(problems, fmt.Errorf("paths.targetdir: %v", err))
+		}
+	}
+	if config.Paths.Mode != "" {
+		if _, err := ParseMode(config.Paths.Mode); err != nil {
+			problems = append(problems, fmt.Errorf("paths.mode: %v", err))
+		}
+	}
+	if config.Paths.Keep < 0 {
+		problems = append(problems, fmt.Errorf("paths.keep: cannot be negative"))
+	}
+	if _, ok := LibcEquiv[config.Platform.Libc]; config.Platform.Libc != "" && !ok {
+		problems = append(problems, fmt.Errorf("platform.libc: unknown libc %q (expected glibc or musl)", config.Platform.Libc))
+	}
+	if err := CheckPrefer(config.Platform.Prefer); err != nil {
+		problems = append(problems, fmt.Errorf("platform.prefer: %v", err))
+	}
+	if err := checkHeaders(config.Network.Headers); err != nil {
+		problems = append(problems, fmt.Errorf("network.headers: %v", err))
+	}
+	if _, err := config.Network.timeout(); err != nil {
+		problems = append(problems, fmt.Errorf("network.timeout: %v", err))
+	}
+	if _, err := config.Network.apiURL(); err != nil {
+		problems = append(problems, fmt.Errorf("network.api_url: %v", err))
+	}
+	for _, alias := range config.ArchAliases {
+		if !slices.Contains(KnownArchs, alias.Arch) {
+			problems = append(problems, fmt.Errorf("arch_alias: unknown architecture %q", alias.Arch))
+		} else if len(alias.Names) == 0 {
+			problems = append(problems, fmt.Errorf("arch_alias: no names for %s", alias.Arch))
+		}
+	}
+	for _, alias := range config.OSAliases {
+		if !slices.Contains(KnownOSes, alias.OS) {
+			problems = append(problems, fmt.Errorf("os_alias: unknown OS %q", alias.OS))
+		} else if len(alias.Names) == 0 {
+			problems = append(problems, fmt.Errorf("os_alias: no names for %s", alias.OS))
+		}
+	}
+	for _, catalog := range config.Catalogs {
+		if err := ValidateName(catalog.Repo); err != nil {
+			problems = append(problems, fmt.Errorf("catalogs: %v", err))
+		}
+	}
+	files := map[string]string{}
+	for _, repo := range config.Repositories {
+		label := repo.Name
+		if label == "" {
+			label = repo.File
+		}
+		if repo.URL == "" {
+			if err := repo.CheckName(); err != nil {
+				problems = append(problems, fmt.Errorf("%s: %v", label, err))
+			}
+		}
+		if err := checkProvider(&repo); err != nil {
+			problems = append(problems, fmt.Errorf("%s: %v", label, err))
+		}
+		if repo.File == "" {
+			problems = append(problems, fmt.Errorf("%s: file is not set", label))
+		} else if other, ok := files[repo.File]; ok {
+			problems = append(problems, fmt.Errorf("%s: file %s is also installed by %s", label, repo.File, other))
+		} else {
+			files[repo.File] = label
+		}
+		if repo.Mode != "" {
+			if _, err := ParseMode(repo.Mode); err != nil {
+				problems = append(problems, fmt.Errorf("%s: mode: %v", label, err))
+			}
+		}
+		if err := CheckPrefer(repo.Prefer); err != nil {
+			problems = append(problems, fmt.Errorf("%s: prefer: %v", label, err))
+		}
+		if err := checkHeaders(repo.Headers); err != nil {
+			problems = append(problems, fmt.Errorf("%s: headers: %v", label, err))
+		}
+		if err := CheckVersion(repo.Version); err != nil {
+			problems = append(problems, fmt.Errorf("%s: version: %v", label, err))
+		}
+		if _, err := compileAssetPattern(repo.AssetPattern); err != nil {
+			problems = append(problems, fmt.Errorf("%s: asset_pattern: %v", label, err))
+		}
+		if err := checkMirrors(repo.Mirrors); err != nil {
+			problems = append(problems, fmt.Errorf("%s: mirrors: %v", label, err))
+		}
+		if _, err := newCompletions(&repo); err != nil {
+			problems = append(problems, fmt.Errorf("%s: completions: %v", label, err))
+		}
+	}
+	return problems
+}
+
+func readConfigPath(configPath string) (Config, error) {
+	var config Config
+	fileInfo, err := os.Stat(configPath)
+	if err != nil {
+		return config, err
+	}
+
+	if !fileInfo.IsDir() {
+		return readOneConfig(configPath)
+	}
+	entries, err := os.ReadDir(configPath)
+	if err != nil {
+		return config, err
+	}
+	// Precedence must not depend on the platform's directory order
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Name() < entries[j].Name()
+	})
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		if !strings.HasSuffix(entry.Name(), ".toml") {
+			continue
+		}
+		oneConfig, err := readOneConfig(filepath.Join(configPath, entry.Name()))
+		if err != nil {
+			return config, err
+		}
+		if err := mergeConfig(&config, oneConfig); err != nil {
+			return config, err
+		}
+	}
+	return config, nil
+}
+
+func readOneConfig(configPath string) (Config, error) {
+	var config Config
+	if _, err := toml.DecodeFile(configPath, &config); err != nil {
+		return config, fmt.Errorf("error reading config file: %v", err)
+	}
+	return config, nil
+}
+
+// ExpandPath expands environment variables ($VAR or ${VAR}) as well as a
+// leading ~ ($HOME) or ~user in path. Referencing an undefined variable is an
+// error, rather than silently producing a truncated path.
+func ExpandPath(path string) (string, error) {
+	var undefined []string
+	path = os.Expand(path, func(name string) string {
+		value, ok := os.LookupEnv(name)
+		if !ok {
+			undefined = append(undefined, name)
+		}
+		return value
+	})
+	if len(undefined) > 0 {
+		return "", fmt.Errorf("undefined environment variable(s) in path: %s", strings.Join(undefined, ", "))
+	}
+	if strings.HasPrefix(path, "~") {
+		name, rest, _ := strings.Cut(path[1:], "/")
+		if name == "" {
+			home, err := os.UserHomeDir()
+			if err != nil {
+				return "", err
+			}
+			return filepath.Join(home, rest), nil
+		}
+		usr, err := user.Lookup(name)
+		if err != nil {
+			return "", err
+		}
+		return filepath.Join(usr.HomeDir, rest), nil
+	}
+	return path, nil
+}
+
+// ParseMode parses an octal permission string such as "0755". Modes giving
+// write access to everyone are refused.
+func ParseMode(mode string) (os.FileMode, error) {
+	value, err := strconv.ParseUint(mode, 8, 32)
+	if err != nil || value > 0o777 {
+		return 0, fmt.Errorf("%q is not a valid octal file mode", mode)
+	}
+	if value&0o002 != 0 {
+		return 0, fmt.Errorf("%q would make files world-writable", mode)
+	}
+	return os.FileMode(value), nil
+}
@@ -0,0 +1,241 @@
+package gogo
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func writeConfig(t *testing.T, dir string, name string, content string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestReadConfigDirectoryPrecedence(t *testing.T) {
+	dir := t.TempDir()
+	// Written out of order on purpose
+	writeConfig(t, dir, "20-work.toml", "[paths]\ntargetdir = \"/work/bin\"\n[[repositories]]\nname = \"b/two\"\nfile = \"two\"\n")
+	writeConfig(t, dir, "30-local.toml", "[auth]\ntoken = \"local\"\n[[repositories]]\nname = \"c/three\"\nfile = \"three\"\n")
+	writeConfig(t, dir, "10-base.toml", "[auth]\ntoken = \"base\"\n[paths]\ntargetdir = \"/base/bin\"\nmode = \"0700\"\n[[repositories]]\nname = \"a/one\"\nfile = \"one\"\n")
+	writeConfig(t, dir, "notes.txt", "[paths]\ntargetdir = \"/ignored\"\n")
+
+	config, err := ReadConfig(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.Paths.TargetDir != "/work/bin" {
+		t.Errorf("TargetDir = %q, want %q", config.Paths.TargetDir, "/work/bin")
+	}
+	if config.Paths.Mode != "0700" {
+		t.Errorf("Mode = %q, want %q", config.Paths.Mode, "0700")
+	}
+	if config.Auth.Token != "local" {
+		t.Errorf("Token = %q, want %q", config.Auth.Token, "local")
+	}
+	var files []string
+	for _, repo := range config.Repositories {
+		files = append(files, repo.File)
+	}
+	if len(files) != 3 || files[0] != "one" || files[1] != "three" || files[2] != "two" {
+		t.Errorf("repositories = %v, want [one three two]", files)
+	}
+}
+
+func TestReadConfigPathList(t *testing.T) {
+	dir := t.TempDir()
+	base := writeConfig(t, dir, "base.toml", "[paths]\ntargetdir = \"/base/bin\"\n[[repositories]]\nname = \"a/one\"\nfile = \"one\"\n")
+	local := writeConfig(t, dir, "local.toml", "[paths]\ntargetdir = \"/local/bin\"\n")
+
+	config, err := ReadConfig(local + "," + base)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.Paths.TargetDir != "/base/bin" {
+		t.Errorf("TargetDir = %q, want %q", config.Paths.TargetDir, "/base/bin")
+	}
+	if len(config.Repositories) != 1 {
+		t.Errorf("got %d repositories, want 1", len(config.Repositories))
+	}
+}
+
+func TestReadConfigOverridesLists(t *testing.T) {
+	dir := t.TempDir()
+	writeConfig(t, dir, "10-base.toml", "[platform]\nprefer = [\"binary\", \"tar.gz\"]\ndemote = [\"debug\"]\n[[repositories]]\nname = \"a/one\"\nfile = \"one\"\n")
+	writeConfig(t, dir, "20-local.toml", "[platform]\nprefer = [\"zip\"]\n[[repositories]]\nname = \"b/two\"\nfile = \"two\"\n")
+
+	config, err := ReadConfig(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(config.Platform.Prefer, []string{"zip"}) {
+		t.Errorf("Prefer = %q, want [zip]", config.Platform.Prefer)
+	}
+	if !slices.Equal(config.Platform.Demote, []string{"debug"}) {
+		t.Errorf("Demote = %q, want [debug]", config.Platform.Demote)
+	}
+	if len(config.Repositories) != 2 {
+		t.Errorf("got %d repositories, want 2", len(config.Repositories))
+	}
+}
+
+func TestReadConfigNormalizesTags(t *testing.T) {
+	dir := t.TempDir()
+	path := writeConfig(t, dir, "config.toml", "[filter]\ndefault_tags = [\" Daily\"]\n[[repositories]]\nname = \"a/one\"\nfile = \"one\"\ntags = [\"Net\", \"dev \", \"net\"]\n")
+
+	config, err := ReadConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := config.Repositories[0].Tags; !slices.Equal(got, []string{"net", "dev"}) {
+		t.Errorf("Tags = %q, want [net dev]", got)
+	}
+	if got := config.Filter.DefaultTags; !slices.Equal(got, []string{"daily"}) {
+		t.Errorf("DefaultTags = %q, want [daily]", got)
+	}
+	if warnings := config.Warnings(); len(warnings) != 1 {
+		t.Errorf("got warnings %q, want one about net", warnings)
+	}
+}
+
+func TestNormalizeTags(t *testing.T) {
+	tests := []struct {
+		tags       []string
+		want       []string
+		duplicates []string
+	}{
+		{nil, nil, nil},
+		{[]string{"dev", "net"}, []string{"dev", "net"}, nil},
+		{[]string{" CLI", "cli ", "", "Cli"}, []string{"cli"}, []string{"cli"}},
+		{[]string{"a", "b", "A", "b"}, []string{"a", "b"}, []string{"a", "b"}},
+	}
+	for _, tt := range tests {
+		got, duplicates := NormalizeTags(tt.tags)
+		if !slices.Equal(got, tt.want) || !slices.Equal(duplicates, tt.duplicates) {
+			t.Errorf("NormalizeTags(%q) = %q, %q, want %q, %q", tt.tags, got, duplicates, tt.want, tt.duplicates)
+		}
+	}
+}
+
+func TestNormalizeName(t *testing.T) {
+	for name, want := range map[string]string{
+		"owner/repo":                        "owner/repo",
+		"https://github.com/owner/repo":     "owner/repo",
+		"https://github.com/owner/repo/":    "owner/repo",
+		"https://github.com/owner/repo.git": "owner/repo",
+		"github.com/owner/repo/releases":    "owner/repo",
+		"https://gitlab.com/owner/repo":     "https://gitlab.com/owner/repo",
+		"https://github.com/owner":          "https://github.com/owner",
+	} {
+		if got := NormalizeName(name); got != want {
+			t.Errorf("NormalizeName(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
+
+func TestExpandPath(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	// Restored once the test is done
+	t.Setenv("GOGO_UNDEFINED", "")
+	os.Unsetenv("GOGO_UNDEFINED")
+	tests := []struct {
+		path    string
+		want    string
+		wantErr bool
+	}{
+		{"~", home, false},
+		{"~/sub", filepath.Join(home, "sub"), false},
+		{"$HOME/x", filepath.Join(home, "x"), false},
+		{"${GOGO_UNDEFINED}/bin", "", true},
+		{"/usr/local/bin", "/usr/local/bin", false},
+	}
+	for _, tt := range tests {
+		got, err := ExpandPath(tt.path)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("ExpandPath(%q) = %q, %v, want %q (error: %v)", tt.path, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestValidateName(t *testing.T) {
+	for name, valid := range map[string]bool{
+		"owner/repo":                    true,
+		"owner-1/repo.go":               true,
+		"owner":                         false,
+		"owner/":                        false,
+		"/repo":                         false,
+		"owner/repo/releases":           false,
+		"https://gitlab.com/owner/repo": false,
+		"owner/repo name":               false,
+		"":                              false,
+	} {
+		if err := ValidateName(name); (err == nil) != valid {
+			t.Errorf("ValidateName(%q) = %v, want valid: %v", name, err, valid)
+		}
+	}
+}
+
+func TestValidateConfig(t *testing.T) {
+	config := Config{
+		Paths:    Paths{Mode: "0777"},
+		Platform: Platform{Libc: "uclibc", Prefer: []string{"tar.gz", "rar"}},
+		Network:  Network{Headers: map[string]string{"X Key": "value"}},
+		Repositories: Repositories{
+			{Name: "owner/one", File: "one"},
+			{Name: "owner", File: "two"},
+			{Name: "owner/three", File: "one"},
+			{Name: "owner/four", File: "four", Mode: "abc", Completions: []string{"powershell"}, AssetPattern: "[linux"},
+			{Name: "Tool from a URL", URL: "https://example.com/tool", File: "tool", Headers: map[string]string{"X-Key": "a\nb"}},
+		},
+	}
+	problems := config.Validate()
+	if len(problems) != 10 {
+		t.Errorf("Validate() found %d problems, want 10: %q", len(problems), problems)
+	}
+	if problems := (&Config{Repositories: Repositories{{Name: "owner/one", File: "one"}}}).Validate(); problems != nil {
+		t.Errorf("Validate() = %q, want no problems", problems)
+	}
+}
+
+func TestFilterTags(t *testing.T) {
+	config := Config{Filter: Filter{DefaultTags: []string{"daily"}}}
+	tests := []struct {
+		tags []string
+		want []string
+	}{
+		{nil, []string{"daily"}},
+		{[]string{}, []string{}},
+		{[]string{"all"}, nil},
+		{[]string{"dev", "net"}, []string{"dev", "net"}},
+	}
+	for _, tt := range tests {
+		if got := config.FilterTags(tt.tags); !slices.Equal(got, tt.want) {
+			t.Errorf("FilterTags(%q) = %q, want %q", tt.tags, got, tt.want)
+		}
+	}
+	if got := (&Config{}).FilterTags(nil); len(got) != 0 {
+		t.Errorf("FilterTags(nil) without defaults = %q, want none", got)
+	}
+}
+
+func TestSortBy(t *testing.T) {
+	repos := Repositories{
+		{File: "d"},
+		{File: "c", Tags: []string{"net"}},
+		{File: "b", Tags: []string{"dev"}},
+		{File: "a", Tags: []string{"net", "dev"}},
+	}
+	repos.SortBy(ByTag)
+	var files []string
+	for _, repo := range repos {
+		files = append(files, repo.File)
+	}
+	if want := []string{"b", "a", "c", "d"}; !slices.Equal(files, want) {
+		t.Errorf("SortBy(ByTag) = %q, want %q", files, want)
+	}
+}
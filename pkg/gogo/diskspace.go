@@ -0,0 +1,46 @@
+package gogo
+
+import "fmt"
+
+// checkSpace refuses a download that would not fit: remaining bytes still
+// have to be downloaded to tmpDir, and at least total bytes will then be
+// extracted to targetDir. If free space cannot be determined, the download
+// is allowed.
+func checkSpace(tmpDir string, targetDir string, remaining int64, total int64) error {
+	tmpFree, tmpFS, err := freeSpace(tmpDir)
+	if err != nil {
+		return nil
+	}
+	targetFree, targetFS, err := freeSpace(targetDir)
+	if err != nil {
+		return nil
+	}
+	if tmpFS == targetFS {
+		return ensureSpace(targetDir, targetFree, remaining+total)
+	}
+	if err := ensureSpace(tmpDir, tmpFree, remaining); err != nil {
+		return err
+	}
+	return ensureSpace(targetDir, targetFree, total)
+}
+
+func ensureSpace(dir string, free uint64, needed int64) error {
+	if needed > 0 && uint64(needed) > free {
+		return fmt.Errorf("not enough space in %s: %s needed, %s available", dir, HumanSize(uint64(needed)), HumanSize(free))
+	}
+	return nil
+}
+
+// HumanSize formats a size in bytes, e.g. 1.5 MiB.
+func HumanSize(size uint64) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+	div, exp := uint64(unit), 0
+	for n := size / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(size)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,9 @@
+//go:build !(linux || darwin)
+
+package gogo
+
+import "errors"
+
+func freeSpace(dir string) (uint64, any, error) {
+	return 0, nil, errors.New("free space unknown on this platform")
+}
@@ -0,0 +1,33 @@
+package gogo
+
+import (
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestCheckSpace(t *testing.T) {
+	if runtime.GOOS != "linux" && runtime.GOOS != "darwin" {
+		t.Skip("free space unknown on this platform")
+	}
+	dir := t.TempDir()
+	if err := checkSpace(dir, dir, 1024, 1024); err != nil {
+		t.Errorf("checkSpace(1 KiB) = %v", err)
+	}
+	err := checkSpace(dir, dir, 1<<61, 1<<61)
+	if err == nil || !strings.Contains(err.Error(), "not enough space") {
+		t.Errorf("checkSpace(4 EiB) = %v, want not enough space", err)
+	}
+}
+
+func TestHumanSize(t *testing.T) {
+	for size, want := range map[uint64]string{
+		512:             "512 B",
+		2048:            "2.0 KiB",
+		5 * 1024 * 1024: "5.0 MiB",
+	} {
+		if got := HumanSize(size); got != want {
+			t.Errorf("HumanSize(%d) = %q, want %q", size, got, want)
+		}
+	}
+}
@@ -0,0 +1,15 @@
+//go:build linux || darwin
+
+package gogo
+
+import "syscall"
+
+// freeSpace returns the space available to us on dir's filesystem, as well as
+// an identifier of that filesystem.
+func freeSpace(dir string) (uint64, any, error) {
+	var stat syscall.Statfs_t
+	if err := syscall.Statfs(dir, &stat); err != nil {
+		return 0, nil, err
+	}
+	return uint64(stat.Bavail) * uint64(stat.Bsize), stat.Fsid, nil
+}
@@ -0,0 +1,13 @@
+//go:build !windows
+
+package gogo
+
+import "os"
+
+// exeSuffix ends the names of executables.
+const exeSuffix = ""
+
+// setMode gives an installed file its mode.
+func setMode(filePath string, mode os.FileMode) error {
+	return os.Chmod(filePath, mode)
+}
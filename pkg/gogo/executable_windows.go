@@ -0,0 +1,14 @@
+//go:build windows
+
+package gogo
+
+import "os"
+
+// exeSuffix ends the names of executables, which Windows only runs with it.
+const exeSuffix = ".exe"
+
+// setMode does nothing: Windows has no execute bits, and read-only files
+// could not be replaced.
+func setMode(filePath string, mode os.FileMode) error {
+	return nil
+}
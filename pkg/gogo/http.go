@@ -0,0 +1,478 @@
+package gogo
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	httpRetries = 3
+	// Retries wait about twice as long as the previous one, from
+	// httpRetryDelay up to httpMaxRetryDelay
+	httpRetryDelay    = time.Second
+	httpMaxRetryDelay = 30 * time.Second
+	httpTimeout       = 30 * time.Second
+
+	// GitHub's default number of items per page
+	apiPageSize = 30
+
+	DefaultAPIURL = "https://api.github.com"
+
+	maxRedirects = 10
+)
+
+// PlaceholderToken is the token written to new configurations, to be replaced
+// by the user's own.
+const PlaceholderToken = "github_<your-token>"
+
+// Hosts that may receive our GitHub token. Release downloads redirect to
+// other hosts (S3, objects.githubusercontent.com, etc.) which must not.
+var tokenHosts = []string{"github.com", "api.github.com"}
+
+// UserAgent identifies gogo in every request it makes.
+var UserAgent = "gogo"
+
+// Headers still sent when a request is redirected to another host. Others
+// may be credentials meant for the original host only.
+var redirectHeaders = []string{"Accept", "Range", "User-Agent"}
+
+// Client resolves and installs release assets, sharing a single HTTP client
+// and GitHub token.
+type Client struct {
+	HTTP *http.Client
+	// APIURL is the GitHub API's root URL, without trailing slash
+	APIURL string
+	Token  string
+	// Force selects assets even if they seem built for another platform
+	Force bool
+	// Prerelease installs from the most recent release, even if it is a
+	// prerelease, rather than from the latest one
+	Prerelease bool
+	// AllowAppImage installs AppImages when no other asset fits
+	AllowAppImage bool
+	// ReleaseOffset installs from the release that many releases older
+	// than the one otherwise chosen, e.g. 1 for the previous one
+	ReleaseOffset int
+	// State, if set, lets the asset installed from a release be reused
+	// rather than selected again, unless Reselect is set
+	State    *State
+	Reselect bool
+	// Prefer ranks asset formats, DefaultPrefer (DefaultWindowsPrefer for
+	// Windows) if empty. Repositories may have their own ranking.
+	Prefer []string
+	// Ignore lists the extensions of assets never to install, DefaultIgnore
+	// if empty. Repositories may have their own list.
+	Ignore []string
+	// Demote lists qualifiers making an asset lose to an otherwise equal
+	// one, DefaultDemote if empty. Repositories may have their own list.
+	Demote []string
+	// SkipChecksum installs assets without verifying their checksum
+	SkipChecksum bool
+	// MaxSize limits the size of each installed file, DefaultMaxSize if 0
+	MaxSize int64
+	// RateLimiter, if set, caps the transfer rate of all downloads
+	RateLimiter *RateLimiter
+	// Logf, if set, receives a detailed account of asset selection
+	Logf func(format string, a ...any)
+	// Progress, if set, is told how the download of a repository's asset
+	// goes: how many bytes were downloaded, out of total, -1 if unknown
+	Progress func(repo *Repository, downloaded int64, total int64)
+	// Headers are added to every request made for a repository, along
+	// with the repository's own
+	Headers map[string]string
+	// Context, if set, aborts requests and downloads once done, e.g. when
+	// the user interrupts gogo
+	Context context.Context
+
+	mu        sync.Mutex
+	rateQuota *RateQuota
+}
+
+// NewClient returns a Client using the given network settings. A placeholder
+// token is ignored.
+func NewClient(network Network, token string) (*Client, error) {
+	httpClient, err := NewHTTPClient(network)
+	if err != nil {
+		return nil, err
+	}
+	apiURL, err := network.apiURL()
+	if err != nil {
+		return nil, err
+	}
+	if IsPlaceholderToken(token) {
+		token = ""
+	}
+	return &Client{HTTP: httpClient, APIURL: apiURL, Token: token, Headers: network.Headers}, nil
+}
+
+// repoHeaders returns the extra headers of a repository's requests, its own
+// overriding the client's.
+func (c *Client) repoHeaders(repo *Repository) http.Header {
+	header := http.Header{}
+	for name, value := range c.Headers {
+		header.Set(name, value)
+	}
+	if repo == nil {
+		return header
+	}
+	for name, value := range repo.Headers {
+		header.Set(name, value)
+	}
+	return header
+}
+
+// addHeaders sets extra headers on a request, replacing any of the same name.
+func addHeaders(req *http.Request, header http.Header) {
+	for name, values := range header {
+		req.Header[name] = values
+	}
+}
+
+// IsPlaceholderToken tells whether token was obviously never filled in:
+// GitHub rejects such tokens, where it would accept anonymous requests.
+func IsPlaceholderToken(token string) bool {
+	if strings.ContainsAny(token, "<>") {
+		return true
+	}
+	for _, prefix := range []string{"github_pat_", "github_", "ghp_"} {
+		if strings.HasPrefix(token, prefix) && strings.TrimPrefix(token, prefix) == "" {
+			return true
+		}
+	}
+	return false
+}
+
+// tokenFormat matches GitHub tokens: a prefix telling their kind, e.g. ghp_
+// for classic personal access tokens, or the 40 hex digits of legacy ones.
+var tokenFormat = regexp.MustCompile(`^((ghp|gho|ghu|ghs|ghr)_[A-Za-z0-9]{30,}|github_pat_[A-Za-z0-9_]{30,}|[0-9a-f]{40})$`)
+
+// CheckToken makes sure token looks like a GitHub token, as a token pasted
+// incompletely or with quotes only fails once sent.
+func CheckToken(token string) error {
+	switch {
+	case IsPlaceholderToken(token):
+		return fmt.Errorf("token is a placeholder, never filled in")
+	case strings.TrimSpace(token) != token:
+		return fmt.Errorf("token has leading or trailing spaces")
+	case !tokenFormat.MatchString(token):
+		return fmt.Errorf("token does not look like a GitHub token (ghp_..., github_pat_...)")
+	}
+	return nil
+}
+
+// context returns the context requests are made with.
+func (c *Client) context() context.Context {
+	if c.Context == nil {
+		return context.Background()
+	}
+	return c.Context
+}
+
+func (c *Client) logf(format string, a ...any) {
+	if c.Logf != nil {
+		c.Logf(format, a...)
+	}
+}
+
+// retryPolicy tells how many times to try a failed request again, and how
+// long to wait before the first retry.
+type retryPolicy struct {
+	retries int
+	delay   time.Duration
+}
+
+var defaultRetryPolicy = retryPolicy{retries: httpRetries, delay: httpRetryDelay}
+
+// backoff returns how long to wait before the given retry, counting from 0:
+// twice as long as before the previous one, up to httpMaxRetryDelay, half of
+// it random so that clients failing together do not retry together.
+func (p retryPolicy) backoff(attempt int) time.Duration {
+	delay := min(p.delay<<min(attempt, 16), httpMaxRetryDelay)
+	if delay <= 1 {
+		return delay
+	}
+	return delay/2 + rand.N(delay/2)
+}
+
+// wait waits before the given retry, unless ctx is done first.
+func (p retryPolicy) wait(ctx context.Context, attempt int) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(p.backoff(attempt)):
+		return nil
+	}
+}
+
+// clientRetryPolicy returns how client retries requests, to retry downloads
+// interrupted midway the same way.
+func clientRetryPolicy(client *http.Client) retryPolicy {
+	if transport, ok := client.Transport.(*retryTransport); ok {
+		return transport.policy
+	}
+	return defaultRetryPolicy
+}
+
+// retryTransport retries idempotent requests that failed because of a
+// network error or a server-side (5xx) error.
+type retryTransport struct {
+	next   http.RoundTripper
+	policy retryPolicy
+}
+
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.next.RoundTrip(req)
+	for attempt := 0; attempt < t.policy.retries && retryable(req, resp, err); attempt++ {
+		if resp != nil {
+			resp.Body.Close()
+		}
+		if err := t.policy.wait(req.Context(), attempt); err != nil {
+			return nil, err
+		}
+		resp, err = t.next.RoundTrip(req)
+	}
+	return resp, err
+}
+
+func retryable(req *http.Request, resp *http.Response, err error) bool {
+	if req.Method != http.MethodGet && req.Method != http.MethodHead {
+		return false
+	}
+	if err != nil {
+		return true
+	}
+	return resp.StatusCode >= 500
+}
+
+// NewHTTPClient returns the client shared by every request gogo makes.
+// Unless a proxy is configured, proxies are taken from the HTTP_PROXY,
+// HTTPS_PROXY and NO_PROXY variables.
+// There is no overall timeout, as downloads may legitimately take a while,
+// but connecting and waiting for response headers are bounded. Failed
+// requests are retried as the network settings say.
+func NewHTTPClient(network Network) (*http.Client, error) {
+	timeout, err := network.timeout()
+	if err != nil {
+		return nil, fmt.Errorf("invalid timeout: %v", err)
+	}
+	policy := defaultRetryPolicy
+	if network.Retries != 0 {
+		policy.retries = max(network.Retries, 0)
+	}
+	proxy := http.ProxyFromEnvironment
+	if network.Proxy != "" {
+		proxyURL, err := url.Parse(network.Proxy)
+		if err != nil || proxyURL.Scheme == "" || proxyURL.Host == "" {
+			return nil, fmt.Errorf("invalid proxy URL: %s", network.Proxy)
+		}
+		proxy = http.ProxyURL(proxyURL)
+	}
+	transport := &http.Transport{
+		Proxy: proxy,
+		DialContext: (&net.Dialer{
+			Timeout:   timeout,
+			KeepAlive: 30 * time.Second,
+		}).DialContext,
+		ForceAttemptHTTP2:     true,
+		MaxIdleConns:          100,
+		IdleConnTimeout:       90 * time.Second,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ResponseHeaderTimeout: timeout,
+		ExpectContinueTimeout: 1 * time.Second,
+	}
+	return &http.Client{
+		Transport:     &retryTransport{next: transport, policy: policy},
+		CheckRedirect: checkRedirect,
+	}, nil
+}
+
+// timeout returns the network's timeout, httpTimeout if not set.
+func (n *Network) timeout() (time.Duration, error) {
+	if n.Timeout == "" {
+		return httpTimeout, nil
+	}
+	timeout, err := time.ParseDuration(n.Timeout)
+	if err == nil && timeout <= 0 {
+		err = fmt.Errorf("%s is not positive", n.Timeout)
+	}
+	return timeout, err
+}
+
+// apiURL returns the network's GitHub API URL, DefaultAPIURL if not set.
+func (n *Network) apiURL() (string, error) {
+	if n.APIURL == "" {
+		return DefaultAPIURL, nil
+	}
+	apiURL, err := url.Parse(n.APIURL)
+	if err != nil || apiURL.Scheme == "" || apiURL.Host == "" {
+		return "", fmt.Errorf("invalid API URL: %s", n.APIURL)
+	}
+	return strings.TrimSuffix(n.APIURL, "/"), nil
+}
+
+// checkRedirect gives up on redirect loops and never forwards credentials
+// to a host other than the one originally requested: S3 rejects requests
+// carrying GitHub's Authorization header, and other headers may be
+// credentials too.
+func checkRedirect(req *http.Request, via []*http.Request) error {
+	if len(via) >= maxRedirects {
+		return fmt.Errorf("stopped after %d redirects", maxRedirects)
+	}
+	if req.URL.Host != via[0].URL.Host {
+		for name := range req.Header {
+			if !slices.Contains(redirectHeaders, name) {
+				req.Header.Del(name)
+			}
+		}
+	}
+	return nil
+}
+
+// NewAPIRequest builds a GitHub API request, authenticated if a token is set.
+func NewAPIRequest(endpoint string, token string) (*http.Request, error) {
+	req, err := http.NewRequest("GET", endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", UserAgent)
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
+	if token != "" && !IsPlaceholderToken(token) {
+		req.Header.Set("Authorization", fmt.Sprintf("token %s", token))
+	}
+	return req, nil
+}
+
+// newDownloadRequest builds a request for a release file. Private releases
+// require authentication even for downloads, so the token is sent, but only
+// to GitHub itself. header holds the repository's extra headers.
+func newDownloadRequest(ctx context.Context, endpoint string, token string, header http.Header) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", UserAgent)
+	if strings.Contains(req.URL.Path, "/releases/assets/") {
+		// Otherwise, the API describes the asset rather than serving it
+		req.Header.Set("Accept", "application/octet-stream")
+	}
+	if token != "" && !IsPlaceholderToken(token) && slices.Contains(tokenHosts, req.URL.Hostname()) {
+		req.Header.Set("Authorization", fmt.Sprintf("token %s", token))
+	}
+	addHeaders(req, header)
+	return req, nil
+}
+
+// FetchText gets a text file, such as a list of commands, failing unless the
+// server answers with a 2xx status. The caller closes the returned body.
+func (c *Client) FetchText(url string) (io.ReadCloser, error) {
+	req, err := newDownloadRequest(c.context(), url, c.Token, c.repoHeaders(nil))
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.HTTP.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		resp.Body.Close()
+		return nil, fmt.Errorf("non-OK HTTP status: %s", resp.Status)
+	}
+	return resp.Body, nil
+}
+
+// CheckAsset makes sure the asset selected for a repository can be
+// downloaded, from its URL or else one of its mirrors, without downloading
+// it: it sends HEAD requests and returns the URL that answered with a 2xx
+// status and the asset's size, -1 when the server does not tell.
+func (c *Client) CheckAsset(status *RepoStatus) (string, int64, error) {
+	mirrors, err := mirrorURLs(status)
+	if err != nil {
+		return "", -1, err
+	}
+	var failures []string
+	for _, url := range append([]string{status.Url}, mirrors...) {
+		size, err := c.headAsset(status.Repo, url)
+		if err == nil {
+			return url, size, nil
+		}
+		failures = append(failures, err.Error())
+	}
+	return "", -1, fmt.Errorf("cannot download %s: %s", status.Asset, strings.Join(failures, "; "))
+}
+
+func (c *Client) headAsset(repo *Repository, url string) (int64, error) {
+	req, err := newDownloadRequest(c.context(), url, c.Token, c.repoHeaders(repo))
+	if err != nil {
+		return -1, err
+	}
+	req.Method = http.MethodHead
+	resp, err := c.HTTP.Do(req)
+	if err != nil {
+		return -1, err
+	}
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return -1, fmt.Errorf("%s: %s", url, resp.Status)
+	}
+	return resp.ContentLength, nil
+}
+
+// fetchAllPages gets a paginated list from the GitHub API, following the
+// "next" links until the last page and accumulating every page's items.
+func fetchAllPages[T any](ctx context.Context, client *http.Client, endpoint string, token string, header http.Header) ([]T, error) {
+	var items []T
+	for endpoint != "" {
+		req, err := NewAPIRequest(endpoint, token)
+		if err != nil {
+			return nil, err
+		}
+		req = req.WithContext(ctx)
+		addHeaders(req, header)
+		resp, err := client.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		if resp.StatusCode != http.StatusOK {
+			resp.Body.Close()
+			return nil, fmt.Errorf("non-OK HTTP status: %s", resp.Status)
+		}
+		var page []T
+		err = json.NewDecoder(resp.Body).Decode(&page)
+		resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("error decoding JSON: %v", err)
+		}
+		items = append(items, page...)
+		endpoint = nextPageURL(resp.Header.Get("Link"))
+	}
+	return items, nil
+}
+
+// nextPageURL extracts the rel="next" target from a Link header such as
+// `<https://api.github.com/...&page=2>; rel="next", <...>; rel="last"`.
+func nextPageURL(link string) string {
+	for _, entry := range strings.Split(link, ",") {
+		target, params, found := strings.Cut(entry, ";")
+		if !found {
+			continue
+		}
+		for _, param := range strings.Split(params, ";") {
+			if strings.TrimSpace(param) == `rel="next"` {
+				return strings.Trim(strings.TrimSpace(target), "<>")
+			}
+		}
+	}
+	return ""
+}
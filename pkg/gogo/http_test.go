@@ -0,0 +1,273 @@
+package gogo
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCheckRedirect(t *testing.T) {
+	storage := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if auth := r.Header.Get("Authorization"); auth != "" {
+			t.Errorf("storage host received Authorization: %q", auth)
+		}
+		if key := r.Header.Get("X-Api-Key"); key != "" {
+			t.Errorf("storage host received X-Api-Key: %q", key)
+		}
+		if agent := r.Header.Get("User-Agent"); agent != UserAgent {
+			t.Errorf("storage host received User-Agent %q, want %q", agent, UserAgent)
+		}
+	}))
+	defer storage.Close()
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/loop" {
+			http.Redirect(w, r, "/loop", http.StatusFound)
+			return
+		}
+		if r.Header.Get("Authorization") == "" {
+			t.Errorf("API host did not receive Authorization")
+		}
+		http.Redirect(w, r, storage.URL+"/asset", http.StatusFound)
+	}))
+	defer api.Close()
+
+	client, err := NewHTTPClient(Network{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	req, err := NewAPIRequest(api.URL+"/asset", "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("X-Api-Key", "key")
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	resp, err = client.Get(api.URL + "/loop")
+	if err == nil {
+		resp.Body.Close()
+		t.Fatal("redirect loop was followed")
+	}
+}
+
+func TestRepoHeaders(t *testing.T) {
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if agent := r.Header.Get("User-Agent"); agent != UserAgent {
+			t.Errorf("%s: User-Agent = %q, want %q", r.URL.Path, agent, UserAgent)
+		}
+		if key := r.Header.Get("X-Api-Key"); key != "repo" {
+			t.Errorf("%s: X-Api-Key = %q, want the repository's", r.URL.Path, key)
+		}
+		if tenant := r.Header.Get("X-Tenant"); tenant != "all" {
+			t.Errorf("%s: X-Tenant = %q, want the client's", r.URL.Path, tenant)
+		}
+		switch r.URL.Path {
+		case "/repos/owner/tool/releases/latest":
+			fmt.Fprintf(w, `{"id": 1, "tag_name": "v1", "assets": [{"id": 2, "name": "tool-linux-amd64", "browser_download_url": "%s/download/tool-linux-amd64"}]}`, server.URL)
+		case "/download/tool-linux-amd64":
+			fmt.Fprint(w, "#!/bin/sh\n")
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	client := &Client{HTTP: server.Client(), APIURL: server.URL, Headers: map[string]string{"X-Api-Key": "client", "X-Tenant": "all"}}
+	repo := &Repository{Name: "owner/tool", File: "tool", Headers: map[string]string{"X-Api-Key": "repo"}}
+	status, err := client.ResolveAsset(repo, Host{"linux", "amd64", "glibc"})
+	if err != nil || status.Status != RepoOK {
+		t.Fatalf("ResolveAsset() = %v, %v", status, err)
+	}
+	if err := client.Install(&status, t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestIsPlaceholderToken(t *testing.T) {
+	for token, want := range map[string]bool{
+		PlaceholderToken:           true,
+		"<your-token>":             true,
+		"github_":                  true,
+		"github_pat_":              true,
+		"ghp_":                     true,
+		"":                         false,
+		"ghp_abcdef0123456789":     false,
+		"github_pat_11ABCDEF_0123": false,
+	} {
+		if got := IsPlaceholderToken(token); got != want {
+			t.Errorf("IsPlaceholderToken(%q) = %v, want %v", token, got, want)
+		}
+	}
+}
+
+func TestCheckToken(t *testing.T) {
+	for token, valid := range map[string]bool{
+		"ghp_" + strings.Repeat("a1B2", 9):                 true,
+		"gho_" + strings.Repeat("a1B2", 9):                 true,
+		"github_pat_11ABCDEF0_" + strings.Repeat("x", 59):  true,
+		strings.Repeat("0123456789abcdef", 2) + "01234567": true,
+		PlaceholderToken:                          false,
+		"ghp_abcdef0123456789":                    false,
+		" ghp_" + strings.Repeat("a1B2", 9):       false,
+		`"ghp_` + strings.Repeat("a1B2", 9) + `"`: false,
+		"glpat-" + strings.Repeat("x", 20):        false,
+	} {
+		if err := CheckToken(token); (err == nil) != valid {
+			t.Errorf("CheckToken(%q) = %v, want valid %v", token, err, valid)
+		}
+	}
+}
+
+func TestFetchText(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/tools.txt" {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprint(w, "lazygit\nfd # files\n")
+	}))
+	defer server.Close()
+	client := &Client{HTTP: server.Client()}
+
+	body, err := client.FetchText(server.URL + "/tools.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	content, err := io.ReadAll(body)
+	body.Close()
+	if err != nil || string(content) != "lazygit\nfd # files\n" {
+		t.Errorf("FetchText() = %q, %v", content, err)
+	}
+	if _, err := client.FetchText(server.URL + "/missing.txt"); err == nil {
+		t.Error("FetchText() of a missing file succeeded")
+	}
+}
+
+func TestCheckAsset(t *testing.T) {
+	var methods []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		methods = append(methods, r.Method)
+		switch r.URL.Path {
+		case "/tool.tar.gz", "/mirror/tool.tar.gz":
+			w.Header().Set("Content-Length", "1234")
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+	client := &Client{HTTP: server.Client()}
+
+	tests := []struct {
+		url     string
+		mirrors []string
+		want    string
+		size    int64
+	}{
+		{server.URL + "/tool.tar.gz", nil, server.URL + "/tool.tar.gz", 1234},
+		{server.URL + "/moved.tar.gz", []string{server.URL + "/mirror/{{.Asset}}"}, server.URL + "/mirror/tool.tar.gz", 1234},
+		{server.URL + "/moved.tar.gz", nil, "", -1},
+	}
+	for _, tt := range tests {
+		status := &RepoStatus{Repo: &Repository{Name: "owner/tool", Mirrors: tt.mirrors}, Asset: "tool.tar.gz", Url: tt.url}
+		url, size, err := client.CheckAsset(status)
+		if url != tt.want || size != tt.size || (err == nil) != (tt.want != "") {
+			t.Errorf("CheckAsset(%s) = %s, %d, %v, want %s, %d", tt.url, url, size, err, tt.want, tt.size)
+		}
+	}
+	for _, method := range methods {
+		if method != http.MethodHead {
+			t.Errorf("CheckAsset() sent a %s request", method)
+		}
+	}
+}
+
+func TestRetryTransport(t *testing.T) {
+	failures := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if failures > 0 {
+			failures--
+			http.Error(w, "unavailable", http.StatusBadGateway)
+			return
+		}
+		fmt.Fprint(w, "ok")
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		failures int
+		retries  int
+		want     int
+	}{
+		{2, 3, http.StatusOK},
+		{3, 3, http.StatusOK},
+		{4, 3, http.StatusBadGateway},
+		{1, 0, http.StatusBadGateway},
+	}
+	for _, tt := range tests {
+		failures = tt.failures
+		client := &http.Client{Transport: &retryTransport{next: http.DefaultTransport, policy: retryPolicy{retries: tt.retries, delay: time.Millisecond}}}
+		resp, err := client.Get(server.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != tt.want {
+			t.Errorf("%d failures, %d retries: status %d, want %d", tt.failures, tt.retries, resp.StatusCode, tt.want)
+		}
+	}
+	// Only idempotent requests are retried
+	failures = 1
+	client := &http.Client{Transport: &retryTransport{next: http.DefaultTransport, policy: retryPolicy{retries: 3, delay: time.Millisecond}}}
+	resp, err := client.Post(server.URL, "text/plain", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadGateway {
+		t.Errorf("POST was retried: status %d", resp.StatusCode)
+	}
+}
+
+func TestRetryBackoff(t *testing.T) {
+	policy := retryPolicy{retries: 10, delay: time.Second}
+	for attempt, want := range []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 8 * time.Second, 16 * time.Second, httpMaxRetryDelay, httpMaxRetryDelay} {
+		for range 20 {
+			if delay := policy.backoff(attempt); delay < want/2 || delay >= want {
+				t.Errorf("backoff(%d) = %v, want between %v and %v", attempt, delay, want/2, want)
+			}
+		}
+	}
+}
+
+func TestNetworkSettings(t *testing.T) {
+	client, err := NewHTTPClient(Network{Retries: -1, Timeout: "5s"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	transport := client.Transport.(*retryTransport)
+	if transport.policy.retries != 0 || transport.next.(*http.Transport).ResponseHeaderTimeout != 5*time.Second {
+		t.Errorf("retries %d, timeout %v, want none and 5s", transport.policy.retries, transport.next.(*http.Transport).ResponseHeaderTimeout)
+	}
+	if client, _ := NewHTTPClient(Network{}); clientRetryPolicy(client) != defaultRetryPolicy {
+		t.Errorf("retry policy %+v, want %+v", clientRetryPolicy(client), defaultRetryPolicy)
+	}
+	for _, timeout := range []string{"5", "-1s", "0s"} {
+		if _, err := NewHTTPClient(Network{Timeout: timeout}); err == nil {
+			t.Errorf("NewHTTPClient(timeout %q) succeeded", timeout)
+		}
+	}
+	if client, err := NewClient(Network{APIURL: "https://github.example.com/api/v3/"}, ""); err != nil || client.APIURL != "https://github.example.com/api/v3" {
+		t.Errorf("NewClient() API URL %v (%v), want the configured one without trailing slash", client, err)
+	}
+	if _, err := NewClient(Network{APIURL: "github.example.com"}, ""); err == nil {
+		t.Errorf("NewClient() accepted an API URL without scheme")
+	}
+}
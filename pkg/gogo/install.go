@@ -0,0 +1,916 @@
+package gogo
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bufio"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type ERepoStatus int
+
+const (
+	RepoOK ERepoStatus = iota
+	RepoKO
+	RepoExist
+	RepoSkipped
+)
+
+// RepoStatus tells whether, and how, a repository can be installed.
+type RepoStatus struct {
+	Repo         *Repository
+	Status       ERepoStatus
+	Format       EAssetFormat
+	Asset        string
+	Url          string
+	SignatureUrl string
+	// ChecksumUrl is that of the file holding the asset's SHA-256 checksum,
+	// if the release has one
+	ChecksumUrl string
+	// Sha256 is the asset's SHA-256 checksum: the one it must have if set
+	// before installing, the one it had once installed
+	Sha256 string
+	Mode   os.FileMode
+	// Mismatch names the platform the asset seems built for, if not the host's
+	Mismatch string
+	// Warning tells about a doubt over the selected asset
+	Warning string
+	// Tag is the release's tag, if known
+	Tag string
+	// InstalledTag is the tag of the release installed before, if recorded
+	InstalledTag string
+	// Host and Selection are the platform the asset was selected for, and
+	// the fingerprint of the settings it was selected with, if selected
+	Host      Host
+	Selection string
+	// PublishedAt is when the release was published, if known
+	PublishedAt time.Time
+	// Notes tells what installing had to skip
+	Notes []string
+	// Installed lists the paths of the files installed, once installed
+	Installed []string
+	Message   string
+}
+
+// Install downloads, verifies and installs a resolved repository to
+// targetDir. Commands are installed with status.Mode, or DefaultMode.
+func (c *Client) Install(status *RepoStatus, targetDir string) error {
+	if status.Mode == 0 {
+		status.Mode = DefaultMode
+	}
+	if status.Repo.UtilsOnly && status.Format.singleCommand() {
+		return fmt.Errorf("%s is a single command, without utils", status.Asset)
+	}
+	if status.Format == GoInstallFormat {
+		if err := goInstall(c.context(), status.Url, targetDir); err != nil {
+			return err
+		}
+		status.Installed = []string{filepath.Join(targetDir, status.Repo.MainBinary())}
+		return nil
+	}
+	return c.downloadFile(status, targetDir)
+}
+
+// InstallFile installs a repository's command, and nothing else, as filePath
+// rather than in a target directory. Missing parent directories are created.
+func (c *Client) InstallFile(status *RepoStatus, filePath string) error {
+	dir := filepath.Dir(filePath)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	// Installing next to filePath lets the command be moved in place
+	tmpDir, err := os.MkdirTemp(dir, ".gogo_*")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmpDir)
+
+	repo := status.Repo.WithoutUtils()
+	repo.Files = nil
+	single := *status
+	single.Repo = repo
+	err = c.Install(&single, tmpDir)
+	status.Notes = single.Notes
+	if err != nil {
+		return err
+	}
+	if !ExistFile(filepath.Join(tmpDir, repo.MainBinary())) {
+		return fmt.Errorf("%s not found in %s", repo.MainCommand(), status.Asset)
+	}
+	return os.Rename(filepath.Join(tmpDir, repo.MainBinary()), filePath)
+}
+
+func (c *Client) downloadFile(repoStatus *RepoStatus, targetDir string) error {
+	tmpPath, err := newWorkDir()
+	if err != nil {
+		return fmt.Errorf("error creating temp file: %v", err)
+	}
+	defer os.RemoveAll(tmpPath)
+
+	assetPath := filepath.Join(tmpPath, "asset")
+	reserve := func(remaining int64, total int64) error {
+		return checkSpace(tmpPath, targetDir, remaining, total)
+	}
+	mirrors, err := mirrorURLs(repoStatus)
+	if err != nil {
+		return err
+	}
+	var progress func(downloaded int64, total int64)
+	if c.Progress != nil {
+		progress = func(downloaded int64, total int64) { c.Progress(repoStatus.Repo, downloaded, total) }
+	}
+	var notes []string
+	err = fetchResumable(c.context(), c.HTTP, c.Token, c.repoHeaders(repoStatus.Repo), c.RateLimiter, repoStatus.Url, assetPath, reserve, progress)
+	for _, mirror := range mirrors {
+		if err == nil {
+			break
+		}
+		c.logf("  - Download of %s failed (%v), trying %s\n", repoStatus.Asset, err, mirror)
+		// What was downloaded may not come from the same file
+		os.Remove(assetPath + ".part")
+		if err = fetchResumable(c.context(), c.HTTP, c.Token, c.repoHeaders(repoStatus.Repo), c.RateLimiter, mirror, assetPath, reserve, progress); err == nil {
+			notes = append(notes, "downloaded from mirror "+mirror)
+		}
+	}
+	if err != nil {
+		return err
+	}
+
+	if repoStatus.ChecksumUrl != "" && !c.SkipChecksum {
+		checksum, err := c.fetchChecksum(repoStatus)
+		if err != nil {
+			return fmt.Errorf("cannot get checksum: %v", err)
+		}
+		if checksum == "" {
+			c.logf("  - No checksum for %s in %s\n", repoStatus.Asset, repoStatus.ChecksumUrl)
+		} else if err := verifyChecksum(assetPath, checksum); err != nil {
+			return fmt.Errorf("%s: %v", repoStatus.Asset, err)
+		}
+	}
+	if repoStatus.Sha256 != "" {
+		if err := verifyChecksum(assetPath, repoStatus.Sha256); err != nil {
+			return fmt.Errorf("%s changed since it was locked: %v", repoStatus.Asset, err)
+		}
+	} else if repoStatus.Sha256, err = fileChecksum(assetPath); err != nil {
+		return err
+	}
+
+	repo := repoStatus.Repo
+	if repo.Signature != "" {
+		signaturePath := filepath.Join(tmpPath, "asset.sig")
+		if err := fetchSignature(c.context(), c.HTTP, c.Token, c.repoHeaders(repoStatus.Repo), repoStatus.SignatureUrl, signaturePath); err != nil {
+			return err
+		}
+		if err := verifySignature(c.context(), repo, assetPath, signaturePath); err != nil {
+			return fmt.Errorf("signature verification failed: %v", err)
+		}
+	}
+
+	// A corrupt archive would only fail midway, some files installed already
+	if err := checkArchive(c.context(), repoStatus.Format, assetPath); err != nil {
+		return fmt.Errorf("corrupt asset %s: %v", repoStatus.Asset, err)
+	}
+
+	maxSize := c.MaxSize
+	if maxSize == 0 {
+		maxSize = DefaultMaxSize
+	}
+	extraction, err := newExtraction(repoStatus, targetDir, maxSize)
+	if err != nil {
+		return err
+	}
+	extraction.notes = notes
+	extraction.ctx = c.context()
+	defer func() {
+		repoStatus.Notes = extraction.notes
+		repoStatus.Installed = extraction.written
+	}()
+	extract := func() (err error) {
+		switch repoStatus.Format {
+		case TarballFormat, TarxzFormat, TarbzipFormat, TarzstFormat:
+			return writeTarballFile(extraction, assetPath)
+		case TargzipFormat:
+			return writeTargzipFile(extraction, assetPath)
+		case ZipFormat:
+			return writeZipFile(extraction, assetPath)
+		case DebFormat:
+			return writeDebFile(extraction, assetPath)
+		case RpmFormat:
+			return writeRpmFile(extraction, assetPath)
+		case BinaryFormat, AppImageFormat:
+			file, err := os.Open(assetPath)
+			if err != nil {
+				return err
+			}
+			defer file.Close()
+			return extraction.write(filepath.Join(targetDir, repo.MainBinary()), file, repoStatus.Mode)
+		case ZstdFormat:
+			file, err := os.Open(assetPath)
+			if err != nil {
+				return err
+			}
+			defer file.Close()
+			reader, err := decompress(c.context(), bufio.NewReader(file))
+			if err != nil {
+				return err
+			}
+			defer closeReader(reader, &err)
+			return extraction.write(filepath.Join(targetDir, repo.MainBinary()), reader, repoStatus.Mode)
+		}
+		return nil
+	}
+	if err := extract(); err != nil || !extraction.fallBack() {
+		if err == nil && repo.UtilsOnly && len(extraction.installed) == 0 {
+			return fmt.Errorf("no utils found in %s", repoStatus.Asset)
+		}
+		return err
+	}
+	return extract()
+}
+
+// fetchResumable downloads url to filePath. The download goes to a .part
+// file first so that, should the transfer be interrupted, it can be resumed
+// from where it stopped rather than restarted.
+// reserve, when the size of the download is known, is given a chance to
+// refuse it: how much remains to be downloaded, and the whole file's size.
+// progress, if set, is told how many bytes were downloaded as they come.
+func fetchResumable(ctx context.Context, client *http.Client, token string, header http.Header, limiter *RateLimiter, url string, filePath string, reserve func(remaining int64, total int64) error, progress func(downloaded int64, total int64)) error {
+	partPath := filePath + ".part"
+	policy := clientRetryPolicy(client)
+	var err error
+	for attempt := 0; attempt <= policy.retries; attempt++ {
+		if attempt > 0 {
+			if err := policy.wait(ctx, attempt-1); err != nil {
+				return err
+			}
+		}
+		var retry bool
+		if retry, err = fetchPart(ctx, client, token, header, limiter, url, partPath, reserve, progress); err == nil {
+			return os.Rename(partPath, filePath)
+		}
+		if !retry || ctx.Err() != nil {
+			break
+		}
+	}
+	return err
+}
+
+// fetchPart appends the missing part of url's content to partPath, and tells
+// whether it is worth trying again if it fails.
+func fetchPart(ctx context.Context, client *http.Client, token string, header http.Header, limiter *RateLimiter, url string, partPath string, reserve func(remaining int64, total int64) error, progress func(downloaded int64, total int64)) (bool, error) {
+	out, err := os.OpenFile(partPath, os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return false, err
+	}
+	defer out.Close()
+	info, err := out.Stat()
+	if err != nil {
+		return false, err
+	}
+	offset := info.Size()
+
+	req, err := newDownloadRequest(ctx, url, token, header)
+	if err != nil {
+		return false, err
+	}
+	if offset > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		// The client retries requests itself, only transfers cut short are
+		// worth resuming
+		return false, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusPartialContent:
+		if start := contentRangeStart(resp.Header.Get("Content-Range")); start != offset {
+			// Not the part we asked for, it cannot be appended: start over
+			if err := out.Truncate(0); err != nil {
+				return false, err
+			}
+			return true, fmt.Errorf("resumed download at %d, got content range %q", offset, resp.Header.Get("Content-Range"))
+		}
+		if _, err := out.Seek(offset, io.SeekStart); err != nil {
+			return false, err
+		}
+	case http.StatusOK:
+		// No range support, or nothing downloaded yet: start over
+		if err := out.Truncate(0); err != nil {
+			return false, err
+		}
+		offset = 0
+	case http.StatusRequestedRangeNotSatisfiable:
+		// Our partial file cannot be trusted anymore
+		if err := out.Truncate(0); err != nil {
+			return false, err
+		}
+		return true, fmt.Errorf("cannot resume download at %d: %s", offset, resp.Status)
+	default:
+		return false, fmt.Errorf("non-OK HTTP status: %s", resp.Status)
+	}
+	if resp.ContentLength >= 0 && reserve != nil {
+		if err := reserve(resp.ContentLength, offset+resp.ContentLength); err != nil {
+			return false, err
+		}
+	}
+	body := limiter.Reader(resp.Body)
+	if progress != nil {
+		total := int64(-1)
+		if resp.ContentLength >= 0 {
+			total = offset + resp.ContentLength
+		}
+		body = &progressReader{reader: body, progress: progress, downloaded: offset, total: total}
+		progress(offset, total)
+	}
+	written, err := io.Copy(out, body)
+	if err != nil {
+		return true, err
+	}
+	if resp.ContentLength >= 0 && written != resp.ContentLength {
+		return true, fmt.Errorf("truncated download: got %d bytes out of %d", written, resp.ContentLength)
+	}
+	return false, nil
+}
+
+// contentRangeStart returns where the content of a 206 response starts,
+// given its Content-Range header such as "bytes 1000-1999/5000", or -1.
+func contentRangeStart(contentRange string) int64 {
+	unit, byteRange, found := strings.Cut(contentRange, " ")
+	if !found || unit != "bytes" {
+		return -1
+	}
+	first, _, found := strings.Cut(byteRange, "-")
+	if !found {
+		return -1
+	}
+	start, err := strconv.ParseInt(first, 10, 64)
+	if err != nil {
+		return -1
+	}
+	return start
+}
+
+// progressReader tells progress how many bytes were read through it, along
+// with those downloaded before.
+type progressReader struct {
+	reader     io.Reader
+	progress   func(downloaded int64, total int64)
+	downloaded int64
+	total      int64
+}
+
+func (r *progressReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	if n > 0 {
+		r.downloaded += int64(n)
+		r.progress(r.downloaded, r.total)
+	}
+	return n, err
+}
+
+// checkArchive reads a whole tarball, zip archive or compressed command,
+// making sure it is complete and readable before anything is extracted
+// from it.
+func checkArchive(ctx context.Context, format EAssetFormat, archivePath string) (err error) {
+	switch format {
+	case TarballFormat, TargzipFormat, TarxzFormat, TarbzipFormat, TarzstFormat:
+		file, err := os.Open(archivePath)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		reader, err := decompress(ctx, bufio.NewReader(file))
+		if err != nil {
+			return err
+		}
+		defer closeReader(reader, &err)
+		tarReader := tar.NewReader(reader)
+		for {
+			if _, err := tarReader.Next(); err == io.EOF {
+				return nil
+			} else if err != nil {
+				return err
+			}
+			if _, err := io.Copy(io.Discard, tarReader); err != nil {
+				return err
+			}
+		}
+	case ZstdFormat:
+		file, err := os.Open(archivePath)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		reader, err := decompress(ctx, bufio.NewReader(file))
+		if err != nil {
+			return err
+		}
+		defer closeReader(reader, &err)
+		_, err = io.Copy(io.Discard, reader)
+		return err
+	case ZipFormat:
+		zipReader, err := zip.OpenReader(archivePath)
+		if err != nil {
+			return err
+		}
+		defer zipReader.Close()
+		for _, file := range zipReader.File {
+			entry, err := file.Open()
+			if err != nil {
+				return err
+			}
+			// Reading to the end checks the entry's checksum
+			_, err = io.Copy(io.Discard, entry)
+			entry.Close()
+			if err != nil {
+				return fmt.Errorf("%s: %v", file.Name, err)
+			}
+		}
+	}
+	return nil
+}
+
+func goInstall(ctx context.Context, module string, targetDir string) error {
+	goBin, err := exec.LookPath("go")
+	if err != nil {
+		return fmt.Errorf("go toolchain not found: %v", err)
+	}
+	absTargetDir, err := filepath.Abs(targetDir)
+	if err != nil {
+		return err
+	}
+	cmd := exec.CommandContext(ctx, goBin, "install", module)
+	cmd.Env = append(os.Environ(), "GOBIN="+absTargetDir)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("go install failed: %v: %s", err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// writeTarballFile installs the wanted files of a tarball, uncompressed or
+// compressed with xz, bzip2 or zstd.
+func writeTarballFile(extraction *extraction, archivePath string) (err error) {
+	file, err := os.Open(archivePath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	reader, err := decompress(extraction.ctx, bufio.NewReader(file))
+	if err != nil {
+		return err
+	}
+	defer closeReader(reader, &err)
+	return extractTar(tar.NewReader(reader), extraction)
+}
+
+func writeTargzipFile(extraction *extraction, archivePath string) error {
+	file, err := os.Open(archivePath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	gzipReader, err := gzip.NewReader(file)
+	if err != nil {
+		return err
+	}
+	defer gzipReader.Close()
+	return extractTar(tar.NewReader(gzipReader), extraction)
+}
+
+// extractTar installs the wanted entries of a tar archive. Links are created
+// last, once the files they point to are installed.
+func extractTar(tarReader *tar.Reader, extraction *extraction) error {
+	var links []*tar.Header
+	var linkPaths []string
+	for !extraction.complete() {
+		header, err := tarReader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		if header.Typeflag != tar.TypeReg && header.Typeflag != tar.TypeSymlink && header.Typeflag != tar.TypeLink {
+			continue
+		}
+		filePath, fileMode, err := extraction.destination(header.Name, header.FileInfo().Mode())
+		if err != nil {
+			return err
+		}
+		if filePath == "" {
+			continue
+		}
+		if header.Typeflag != tar.TypeReg {
+			links = append(links, header)
+			linkPaths = append(linkPaths, filePath)
+			continue
+		}
+		if err := extraction.write(filePath, tarReader, fileMode); err != nil {
+			return err
+		}
+		extraction.extracted[path.Clean(header.Name)] = filePath
+	}
+	for i, header := range links {
+		if err := extraction.link(header.Name, header.Linkname, header.Typeflag == tar.TypeSymlink, linkPaths[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ExtractConfigArchive extracts a gzipped tarball of configuration files to
+// targetDir, leaving any config.toml alone. Their names are given prefix,
+// which may be empty. It returns the extracted paths.
+func ExtractConfigArchive(targetDir string, prefix string, content io.Reader) ([]string, error) {
+	tmpPath, err := newWorkDir()
+	if err != nil {
+		return nil, fmt.Errorf("error creating temp file: %v", err)
+	}
+	defer os.RemoveAll(tmpPath)
+	tmpFileName := filepath.Join(tmpPath, "asset.tar.gz")
+	if err := writeBinaryFile(tmpFileName, content, 0o644, DefaultMaxSize); err != nil {
+		return nil, err
+	}
+	file, err := os.Open(tmpFileName)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	gzipReader, err := gzip.NewReader(file)
+	if err != nil {
+		return nil, err
+	}
+	defer gzipReader.Close()
+	tarReader := tar.NewReader(gzipReader)
+	var extracted []string
+	for {
+		header, err := tarReader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return extracted, err
+		}
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+		fileName := filepath.Base(header.Name)
+		if fileName == "config.toml" {
+			continue
+		}
+		filePath, err := safeJoin(targetDir, prefix+fileName)
+		if err != nil {
+			return extracted, err
+		}
+		if err := writeBinaryFile(filePath, tarReader, 0o644, DefaultMaxSize); err != nil {
+			return extracted, err
+		}
+		extracted = append(extracted, filePath)
+	}
+	return extracted, nil
+}
+
+func writeZipFile(extraction *extraction, archivePath string) error {
+	zipReader, err := zip.OpenReader(archivePath)
+	if err != nil {
+		return err
+	}
+	defer zipReader.Close()
+	for _, file := range zipReader.File {
+		if extraction.complete() {
+			break
+		}
+		filePath, fileMode, err := extraction.destination(file.Name, file.Mode())
+		if err != nil {
+			return err
+		}
+		if filePath == "" {
+			continue
+		}
+		zipFile, err := file.Open()
+		if err != nil {
+			return err
+		}
+		err = extraction.write(filePath, zipFile, fileMode)
+		zipFile.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// extraction describes which archive entries get installed, and where.
+// Commands go directly in the target directory, utils may be redirected, and
+// completions go where their shell will find them.
+type extraction struct {
+	files       []string
+	utils       []string
+	utilsDest   map[string]string
+	completions []completion
+	mode        os.FileMode
+	maxSize     int64
+	targetDir   string
+	installed   map[string]bool
+	// extracted maps archive entries to where they were written
+	extracted map[string]string
+	// written lists every path written, links included
+	written []string
+	notes   []string
+	// executables lists the archive's executable files, should none be
+	// named like the command
+	executables []string
+	fallback    string
+	// ctx stops decompressing commands
+	ctx context.Context
+}
+
+func newExtraction(repoStatus *RepoStatus, targetDir string, maxSize int64) (*extraction, error) {
+	completions, err := newCompletions(repoStatus.Repo)
+	if err != nil {
+		return nil, err
+	}
+	files := repoStatus.Repo.Binaries()
+	if repoStatus.Repo.UtilsOnly {
+		files = nil
+	}
+	return &extraction{
+		files:       files,
+		utils:       repoStatus.Repo.Utils,
+		utilsDest:   repoStatus.Repo.UtilsDest,
+		completions: completions,
+		mode:        repoStatus.Mode,
+		maxSize:     maxSize,
+		ctx:         context.Background(),
+		targetDir:   targetDir,
+		installed:   map[string]bool{},
+		extracted:   map[string]string{},
+	}, nil
+}
+
+// destination returns the path and mode an archive entry should be installed
+// with, or an empty path if it is not wanted (or was already installed).
+func (e *extraction) destination(entryName string, entryMode os.FileMode) (string, os.FileMode, error) {
+	name := filepath.Base(entryName)
+	if e.fallback != "" && entryName == e.fallback {
+		e.fallback = ""
+		e.installed[e.files[0]] = true
+		filePath, err := safeJoin(e.targetDir, e.files[0])
+		return filePath, e.mode, err
+	}
+	if entryMode.IsRegular() && isExecutable(name, entryMode) && !slices.Contains(e.executables, entryName) {
+		e.executables = append(e.executables, entryName)
+	}
+	if e.installed[name] {
+		return "", 0, nil
+	}
+	if slices.Contains(e.files, name) {
+		e.installed[name] = true
+		filePath, err := safeJoin(e.targetDir, name)
+		return filePath, e.mode, err
+	}
+	for i := range e.completions {
+		completion := &e.completions[i]
+		key := "completion:" + completion.shell
+		if e.installed[key] || !slices.ContainsFunc(completion.patterns, func(pattern string) bool {
+			return matchUtil(pattern, entryName)
+		}) {
+			continue
+		}
+		e.installed[key] = true
+		filePath, err := completion.path(e.targetDir, entryName)
+		return filePath, e.mode &^ 0o111, err
+	}
+	for _, util := range e.utils {
+		if !matchUtil(util, entryName) {
+			continue
+		}
+		filePath, err := utilPath(e.targetDir, name, util, e.utilsDest)
+		if err != nil {
+			return "", 0, err
+		}
+		e.installed[name] = true
+		return filePath, utilMode(e.mode, entryMode), nil
+	}
+	return "", 0, nil
+}
+
+// write installs an entry's content at filePath.
+func (e *extraction) write(filePath string, content io.Reader, mode os.FileMode) error {
+	if err := writeBinaryFile(filePath, content, mode, e.maxSize); err != nil {
+		return err
+	}
+	e.written = append(e.written, filePath)
+	return nil
+}
+
+// complete tells whether every wanted entry has been installed. When utils
+// contain patterns, there is no telling, and the whole archive is scanned.
+func (e *extraction) complete() bool {
+	for _, util := range e.utils {
+		if isGlob(util) {
+			return false
+		}
+	}
+	return len(e.installed) == len(e.files)+len(e.utils)+len(e.completions)
+}
+
+// fallBack picks the only executable file of the archive as the command when
+// no entry was named like it, telling whether the archive should be scanned
+// again to install it.
+func (e *extraction) fallBack() bool {
+	if len(e.files) != 1 || e.installed[e.files[0]] || len(e.executables) != 1 {
+		return false
+	}
+	e.fallback = e.executables[0]
+	e.notes = append(e.notes, fmt.Sprintf("%s not found in the archive, installed its only executable, %s, instead", e.files[0], e.fallback))
+	return true
+}
+
+// link recreates an archive's link at filePath, provided it points to a file
+// that was installed too. Otherwise, it is skipped with a note.
+func (e *extraction) link(entryName string, linkName string, symbolic bool, filePath string) error {
+	target := path.Clean(linkName)
+	if symbolic {
+		target = path.Join(path.Dir(path.Clean(entryName)), linkName)
+	}
+	if path.IsAbs(linkName) || target == ".." || strings.HasPrefix(target, "../") {
+		e.notes = append(e.notes, fmt.Sprintf("skipped %s: link to %s, outside of the archive", entryName, linkName))
+		return nil
+	}
+	targetPath, ok := e.extracted[target]
+	if !ok {
+		e.notes = append(e.notes, fmt.Sprintf("skipped %s: link to %s, which is not installed", entryName, linkName))
+		return nil
+	}
+	if err := os.Remove(filePath); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if symbolic {
+		relPath, err := filepath.Rel(filepath.Dir(filePath), targetPath)
+		if err != nil {
+			return err
+		}
+		if err := os.Symlink(relPath, filePath); err != nil {
+			return err
+		}
+	} else if err := os.Link(targetPath, filePath); err != nil {
+		return err
+	}
+	e.written = append(e.written, filePath)
+	return nil
+}
+
+func isGlob(pattern string) bool {
+	return strings.ContainsAny(pattern, "*?[")
+}
+
+// matchUtil tells whether an archive entry is the given util. Plain names
+// must equal the entry's base name, while glob patterns such as "*.1" or
+// "completions/*.bash" may match any trailing part of the entry's path.
+func matchUtil(util string, entryName string) bool {
+	entryName = path.Clean(filepath.ToSlash(entryName))
+	if !isGlob(util) {
+		return path.Base(entryName) == util
+	}
+	segments := strings.Split(entryName, "/")
+	for i := range segments {
+		if matched, _ := path.Match(util, strings.Join(segments[i:], "/")); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// utilPath returns where a util should be written: flattened into targetDir,
+// unless utils_dest maps its name (or the pattern it matched) to a
+// subdirectory, which is created if needed.
+func utilPath(targetDir string, name string, pattern string, utilsDest map[string]string) (string, error) {
+	dir := utilDir(targetDir, name, pattern, utilsDest)
+	if dir != targetDir {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return "", fmt.Errorf("error creating util directory %s: %v", dir, err)
+		}
+	}
+	return safeJoin(dir, name)
+}
+
+// safeJoin joins dir and a name taken from an archive, making sure the result
+// is within dir: a crafted entry must not be written anywhere else.
+func safeJoin(dir string, name string) (string, error) {
+	filePath := filepath.Join(dir, name)
+	relPath, err := filepath.Rel(dir, filePath)
+	if err != nil || relPath == "." || relPath == ".." || strings.HasPrefix(relPath, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("archive entry %q would be written outside of %s", name, dir)
+	}
+	return filePath, nil
+}
+
+func utilDir(targetDir string, name string, pattern string, utilsDest map[string]string) string {
+	dest, ok := utilsDest[name]
+	if !ok {
+		dest, ok = utilsDest[pattern]
+	}
+	if !ok {
+		return targetDir
+	}
+	return filepath.Join(targetDir, dest)
+}
+
+// PlannedPaths lists the files installing status would write to targetDir,
+// without touching anything. Utils given as patterns are listed as such, as
+// what they match is only known once the archive is downloaded.
+func (status *RepoStatus) PlannedPaths(targetDir string) []string {
+	repo := status.Repo
+	var paths []string
+	if status.Format.singleCommand() {
+		if repo.UtilsOnly {
+			return nil
+		}
+		return []string{filepath.Join(targetDir, repo.MainBinary())}
+	}
+	if !repo.UtilsOnly {
+		for _, file := range repo.Binaries() {
+			paths = append(paths, filepath.Join(targetDir, file))
+		}
+	}
+	for _, util := range repo.Utils {
+		paths = append(paths, filepath.Join(utilDir(targetDir, util, util, repo.UtilsDest), util))
+	}
+	if completions, err := newCompletions(repo); err == nil {
+		for _, completion := range completions {
+			dir := completion.dir
+			if dir == "" {
+				dir = targetDir
+			}
+			paths = append(paths, filepath.Join(dir, completion.name))
+		}
+	}
+	return paths
+}
+
+// isExecutable tells whether an archive entry is an executable: marked so, or
+// named like one on platforms where that is what tells them.
+func isExecutable(name string, mode os.FileMode) bool {
+	return mode&0o111 != 0 || exeSuffix != "" && strings.HasSuffix(strings.ToLower(name), exeSuffix)
+}
+
+// utilMode returns the mode for a util: the configured mode if the archive
+// marks it executable, the same mode minus execute bits otherwise.
+func utilMode(mode os.FileMode, archiveMode os.FileMode) os.FileMode {
+	if archiveMode&0o111 != 0 {
+		return mode
+	}
+	return mode &^ 0o111
+}
+
+// writeBinaryFile writes content to filePath, up to maxSize bytes: beyond
+// that, the file is removed and an error returned, so that a decompression
+// bomb cannot fill the disk. The file is written next to filePath first and
+// then moved in place, so that filePath is never left half written.
+func writeBinaryFile(filePath string, content io.Reader, mode os.FileMode, maxSize int64) error {
+	out, err := os.CreateTemp(filepath.Dir(filePath), "."+filepath.Base(filePath)+".gogo-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(out.Name())
+	defer out.Close()
+
+	written, err := io.Copy(out, io.LimitReader(content, maxSize+1))
+	if err == nil && written > maxSize {
+		err = fmt.Errorf("%s exceeds the maximum size of %s", filepath.Base(filePath), HumanSize(uint64(maxSize)))
+	}
+	if err == nil {
+		err = out.Close()
+	}
+	if err != nil {
+		return err
+	}
+
+	if err = setMode(out.Name(), mode); err != nil {
+		return err
+	}
+	return os.Rename(out.Name(), filePath)
+}
+
+func ExistFile(fileName string) bool {
+	if _, err := os.Stat(fileName); err != nil {
+		return false
+	}
+	return true
+}
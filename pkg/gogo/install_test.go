@@ -0,0 +1,488 @@
+package gogo
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/ulikunitz/xz"
+)
+
+func TestPlannedPaths(t *testing.T) {
+	t.Setenv("XDG_DATA_HOME", "/data")
+	t.Setenv("BASH_COMPLETION_USER_DIR", "")
+	repo := &Repository{
+		Name:        "owner/tool",
+		File:        "tool",
+		Files:       []string{"toolctl"},
+		Utils:       []string{"tool.1", "*.md"},
+		UtilsDest:   map[string]string{"tool.1": "../share/man/man1"},
+		Completions: []string{"bash"},
+	}
+	got := (&RepoStatus{Repo: repo, Format: TargzipFormat}).PlannedPaths("/opt/bin")
+	want := []string{
+		"/opt/bin/tool",
+		"/opt/bin/toolctl",
+		"/opt/share/man/man1/tool.1",
+		"/opt/bin/*.md",
+		filepath.Join("/data", "bash-completion", "completions", "tool"),
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("PlannedPaths() = %v, want %v", got, want)
+	}
+
+	got = (&RepoStatus{Repo: repo, Format: BinaryFormat}).PlannedPaths("/opt/bin")
+	if !slices.Equal(got, []string{"/opt/bin/tool"}) {
+		t.Errorf("PlannedPaths() for a binary = %v", got)
+	}
+
+	utilsOnly := *repo
+	utilsOnly.UtilsOnly = true
+	got = (&RepoStatus{Repo: &utilsOnly, Format: TargzipFormat}).PlannedPaths("/opt/bin")
+	if !slices.Equal(got, want[2:]) {
+		t.Errorf("PlannedPaths() with utils only = %v, want %v", got, want[2:])
+	}
+	got = (&RepoStatus{Repo: repo.WithoutUtils(), Format: TargzipFormat}).PlannedPaths("/opt/bin")
+	if !slices.Equal(got, want[:2]) {
+		t.Errorf("PlannedPaths() without utils = %v, want %v", got, want[:2])
+	}
+}
+
+func TestExtractSelectedParts(t *testing.T) {
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	for _, name := range []string{"tool-1.0/tool", "tool-1.0/tool.1"} {
+		tw.WriteHeader(&tar.Header{Name: name, Typeflag: tar.TypeReg, Mode: 0o755, Size: 1})
+		tw.Write([]byte("x"))
+	}
+	tw.Close()
+	archive := buf.Bytes()
+
+	repo := &Repository{Name: "owner/tool", File: "tool", Utils: []string{"tool.1"}}
+	utilsOnly := *repo
+	utilsOnly.UtilsOnly = true
+	tests := []struct {
+		name string
+		repo *Repository
+		want []string
+	}{
+		{"everything", repo, []string{"tool", "tool.1"}},
+		{"utils only", &utilsOnly, []string{"tool.1"}},
+		{"no utils", repo.WithoutUtils(), []string{"tool"}},
+		{"named by command", &Repository{Name: "owner/toolkit", File: "toolkit", Command: "tool"}, []string{"tool"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			targetDir := t.TempDir()
+			extraction, err := newExtraction(&RepoStatus{Repo: tt.repo, Mode: 0o755}, targetDir, DefaultMaxSize)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := extractTar(tar.NewReader(bytes.NewReader(archive)), extraction); err != nil {
+				t.Fatal(err)
+			}
+			entries, _ := os.ReadDir(targetDir)
+			var got []string
+			for _, entry := range entries {
+				got = append(got, entry.Name())
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("installed %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExtractTarLinks(t *testing.T) {
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	content := []byte("#!/bin/sh\n")
+	entries := []*tar.Header{
+		{Name: "suite/bin/ls", Typeflag: tar.TypeSymlink, Linkname: "suite"},
+		{Name: "suite/bin/suite", Typeflag: tar.TypeReg, Mode: 0o755, Size: int64(len(content))},
+		{Name: "suite/bin/cat", Typeflag: tar.TypeLink, Linkname: "suite/bin/suite"},
+		{Name: "suite/bin/passwd", Typeflag: tar.TypeSymlink, Linkname: "../../../etc/passwd"},
+		{Name: "suite/bin/cp", Typeflag: tar.TypeSymlink, Linkname: "missing"},
+	}
+	for _, header := range entries {
+		if err := tw.WriteHeader(header); err != nil {
+			t.Fatal(err)
+		}
+		if header.Typeflag == tar.TypeReg {
+			tw.Write(content)
+		}
+	}
+	tw.Close()
+
+	targetDir := t.TempDir()
+	repo := &Repository{Name: "owner/suite", File: "suite", Files: []string{"ls", "cat", "passwd", "cp"}}
+	extraction, err := newExtraction(&RepoStatus{Repo: repo, Mode: 0o755}, targetDir, DefaultMaxSize)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := extractTar(tar.NewReader(&buf), extraction); err != nil {
+		t.Fatal(err)
+	}
+
+	if link, err := os.Readlink(filepath.Join(targetDir, "ls")); err != nil || link != "suite" {
+		t.Errorf("ls links to %q (%v), want suite", link, err)
+	}
+	suiteInfo, _ := os.Stat(filepath.Join(targetDir, "suite"))
+	catInfo, err := os.Lstat(filepath.Join(targetDir, "cat"))
+	if err != nil || !os.SameFile(suiteInfo, catInfo) {
+		t.Errorf("cat is not a hard link to suite (%v)", err)
+	}
+	for _, name := range []string{"passwd", "cp"} {
+		if _, err := os.Lstat(filepath.Join(targetDir, name)); !os.IsNotExist(err) {
+			t.Errorf("%s was created", name)
+		}
+	}
+	if len(extraction.notes) != 2 {
+		t.Errorf("notes = %q, want 2 skipped links", extraction.notes)
+	}
+}
+
+func TestExtractSingleExecutable(t *testing.T) {
+	tests := []struct {
+		name    string
+		entries map[string]int64
+		want    bool
+	}{
+		{"single executable", map[string]int64{"myapp-1.0/myapp": 0o755, "myapp-1.0/README.md": 0o644}, true},
+		{"several executables", map[string]int64{"myapp-1.0/myapp": 0o755, "myapp-1.0/helper": 0o755}, false},
+		{"no executable", map[string]int64{"myapp-1.0/myapp": 0o644}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			tw := tar.NewWriter(&buf)
+			for name, mode := range tt.entries {
+				tw.WriteHeader(&tar.Header{Name: name, Typeflag: tar.TypeReg, Mode: mode, Size: 1})
+				tw.Write([]byte("x"))
+			}
+			tw.Close()
+			archive := buf.Bytes()
+
+			targetDir := t.TempDir()
+			repo := &Repository{Name: "owner/myapp-cli", File: "myapp-cli"}
+			extraction, err := newExtraction(&RepoStatus{Repo: repo, Mode: 0o755}, targetDir, DefaultMaxSize)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := extractTar(tar.NewReader(bytes.NewReader(archive)), extraction); err != nil {
+				t.Fatal(err)
+			}
+			if got := extraction.fallBack(); got != tt.want {
+				t.Fatalf("fallBack() = %v, want %v", got, tt.want)
+			}
+			if !tt.want {
+				return
+			}
+			if err := extractTar(tar.NewReader(bytes.NewReader(archive)), extraction); err != nil {
+				t.Fatal(err)
+			}
+			if !ExistFile(filepath.Join(targetDir, "myapp-cli")) {
+				t.Error("myapp-cli was not installed")
+			}
+			if len(extraction.notes) != 1 {
+				t.Errorf("notes = %q, want a warning", extraction.notes)
+			}
+		})
+	}
+}
+
+func TestCheckArchive(t *testing.T) {
+	content := bytes.Repeat([]byte("gogo"), 4096)
+	var targz bytes.Buffer
+	gzipWriter := gzip.NewWriter(&targz)
+	tw := tar.NewWriter(gzipWriter)
+	tw.WriteHeader(&tar.Header{Name: "tool", Typeflag: tar.TypeReg, Mode: 0o755, Size: int64(len(content))})
+	tw.Write(content)
+	tw.Close()
+	gzipWriter.Close()
+	var zipped bytes.Buffer
+	zw := zip.NewWriter(&zipped)
+	w, _ := zw.Create("tool")
+	w.Write(content)
+	zw.Close()
+
+	tests := []struct {
+		name    string
+		format  EAssetFormat
+		archive []byte
+		valid   bool
+	}{
+		{"tar.gz", TargzipFormat, targz.Bytes(), true},
+		{"truncated tar.gz", TargzipFormat, targz.Bytes()[:targz.Len()/2], false},
+		{"zip", ZipFormat, zipped.Bytes(), true},
+		{"truncated zip", ZipFormat, zipped.Bytes()[:zipped.Len()-10], false},
+		{"binary", BinaryFormat, content[:10], true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			archivePath := filepath.Join(t.TempDir(), "asset")
+			if err := os.WriteFile(archivePath, tt.archive, 0o644); err != nil {
+				t.Fatal(err)
+			}
+			if err := checkArchive(context.Background(), tt.format, archivePath); (err == nil) != tt.valid {
+				t.Errorf("checkArchive() = %v, want valid: %v", err, tt.valid)
+			}
+		})
+	}
+}
+
+func TestWriteCompressedTarball(t *testing.T) {
+	content := bytes.Repeat([]byte("gogo"), 4096)
+	var tarball bytes.Buffer
+	tw := tar.NewWriter(&tarball)
+	tw.WriteHeader(&tar.Header{Name: "tool-1.0/tool", Typeflag: tar.TypeReg, Mode: 0o755, Size: int64(len(content))})
+	tw.Write(content)
+	tw.WriteHeader(&tar.Header{Name: "tool-1.0/README.md", Typeflag: tar.TypeReg, Mode: 0o644, Size: 1})
+	tw.Write([]byte("x"))
+	tw.Close()
+
+	for _, tt := range []struct {
+		asset    string
+		compress func(t *testing.T, tarball []byte) []byte
+	}{
+		{"tool.tar.xz", func(t *testing.T, tarball []byte) []byte {
+			var compressed bytes.Buffer
+			writer, err := xz.NewWriter(&compressed)
+			if err != nil {
+				t.Fatal(err)
+			}
+			writer.Write(tarball)
+			writer.Close()
+			return compressed.Bytes()
+		}},
+		// Go has no bzip2 compressor, the testdata is that same tarball
+		{"tool.tar.bz2", func(t *testing.T, tarball []byte) []byte {
+			compressed, err := os.ReadFile(filepath.Join("testdata", "tool.tar.bz2"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			return compressed
+		}},
+		{"tool.tar.zst", func(t *testing.T, tarball []byte) []byte {
+			return zstdCompress(t, tarball)
+		}},
+	} {
+		t.Run(tt.asset, func(t *testing.T) {
+			format := GetAssetFormat(tt.asset)
+			if format != TarxzFormat && format != TarbzipFormat && format != TarzstFormat {
+				t.Fatalf("GetAssetFormat(%s) = %s", tt.asset, format)
+			}
+			compressed := tt.compress(t, tarball.Bytes())
+			archivePath := filepath.Join(t.TempDir(), tt.asset)
+			os.WriteFile(archivePath, compressed, 0o644)
+			if err := checkArchive(context.Background(), format, archivePath); err != nil {
+				t.Fatalf("checkArchive() = %v", err)
+			}
+
+			targetDir := t.TempDir()
+			status := &RepoStatus{Repo: &Repository{Name: "owner/tool", File: "tool"}, Format: format, Mode: 0o755}
+			extraction, err := newExtraction(status, targetDir, DefaultMaxSize)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := writeTarballFile(extraction, archivePath); err != nil {
+				t.Fatal(err)
+			}
+			if installed, err := os.ReadFile(filepath.Join(targetDir, "tool")); err != nil || !bytes.Equal(installed, content) {
+				t.Errorf("installed %d bytes (%v), want %d", len(installed), err, len(content))
+			}
+
+			os.WriteFile(archivePath, compressed[:len(compressed)/2], 0o644)
+			if err := checkArchive(context.Background(), format, archivePath); err == nil {
+				t.Error("checkArchive() accepted a truncated archive")
+			}
+		})
+	}
+}
+
+func zstdCompress(t *testing.T, content []byte) []byte {
+	encoder, err := zstd.NewWriter(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer encoder.Close()
+	return encoder.EncodeAll(content, nil)
+}
+
+func TestWriteZstdCommand(t *testing.T) {
+	content := []byte("#!/bin/sh\necho tool\n")
+	compressed := zstdCompress(t, content)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(compressed)
+	}))
+	defer server.Close()
+
+	asset := "tool-linux-amd64.zst"
+	status := &RepoStatus{Repo: &Repository{Name: "owner/tool", File: "tool"}, Status: RepoOK, Format: GetAssetFormat(asset), Asset: asset, Url: server.URL + "/" + asset}
+	targetDir := t.TempDir()
+	if err := (&Client{HTTP: server.Client()}).Install(status, targetDir); err != nil {
+		t.Fatal(err)
+	}
+	if installed, err := os.ReadFile(filepath.Join(targetDir, "tool")); err != nil || !bytes.Equal(installed, content) {
+		t.Errorf("installed %q (%v), want %q", installed, err, content)
+	}
+	if got := status.PlannedPaths(targetDir); !slices.Equal(got, []string{filepath.Join(targetDir, "tool")}) {
+		t.Errorf("PlannedPaths() = %v", got)
+	}
+}
+
+func TestSafeJoin(t *testing.T) {
+	for name, ok := range map[string]bool{
+		"tool":          true,
+		"sub/tool":      true,
+		"..":            false,
+		".":             false,
+		"../tool":       false,
+		"sub/../../x":   false,
+		"sub/../tool":   true,
+		"../dir/../..x": false,
+	} {
+		_, err := safeJoin("/opt/bin", name)
+		if (err == nil) != ok {
+			t.Errorf("safeJoin(%q) error = %v, want ok = %v", name, err, ok)
+		}
+	}
+}
+
+func TestZipSlip(t *testing.T) {
+	workDir := t.TempDir()
+	targetDir := filepath.Join(workDir, "bin")
+	if err := os.Mkdir(targetDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	archivePath := filepath.Join(workDir, "evil.zip")
+	file, err := os.Create(archivePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	zw := zip.NewWriter(file)
+	for _, name := range []string{"../../tool", "docs/.."} {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write([]byte("payload"))
+	}
+	zw.Close()
+	file.Close()
+
+	repo := &Repository{Name: "owner/tool", File: "tool", Utils: []string{"*"}}
+	extraction, err := newExtraction(&RepoStatus{Repo: repo, Mode: 0o755}, targetDir, DefaultMaxSize)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := writeZipFile(extraction, archivePath); err == nil {
+		t.Error("an entry escaping the target directory was accepted")
+	}
+	if _, err := os.Stat(filepath.Join(targetDir, "tool")); err != nil {
+		t.Errorf("tool was not flattened into the target directory: %v", err)
+	}
+	entries, _ := os.ReadDir(workDir)
+	if len(entries) != 2 {
+		t.Errorf("files were written outside of the target directory: %v", entries)
+	}
+}
+
+func TestWriteBinaryFileMaxSize(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "tool")
+	if err := writeBinaryFile(filePath, strings.NewReader("12345678"), 0o755, 8); err != nil {
+		t.Errorf("file of the maximum size was refused: %v", err)
+	}
+	if err := writeBinaryFile(filePath, strings.NewReader("123456789"), 0o755, 8); err == nil {
+		t.Error("file over the maximum size was accepted")
+	}
+	if content, err := os.ReadFile(filePath); err != nil || string(content) != "12345678" {
+		t.Errorf("oversized file replaced the previous one: %q (%v)", content, err)
+	}
+	if entries, _ := os.ReadDir(filepath.Dir(filePath)); len(entries) != 1 {
+		t.Errorf("oversized file was left behind: %v", entries)
+	}
+}
+
+func TestInstallProgress(t *testing.T) {
+	content := strings.Repeat("x", 100000)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.ServeContent(w, r, "tool", time.Time{}, strings.NewReader(content))
+	}))
+	defer server.Close()
+
+	repo := &Repository{Name: "owner/tool", File: "tool"}
+	var reported []int64
+	client := &Client{HTTP: server.Client(), Progress: func(got *Repository, downloaded int64, total int64) {
+		if got != repo || total != int64(len(content)) {
+			t.Errorf("progress of %s: %d out of %d", got.Name, downloaded, total)
+		}
+		reported = append(reported, downloaded)
+	}}
+	status := &RepoStatus{Repo: repo, Status: RepoOK, Format: BinaryFormat, Asset: "tool", Url: server.URL + "/tool"}
+	if err := client.Install(status, t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+	if len(reported) < 2 || reported[0] != 0 || reported[len(reported)-1] != int64(len(content)) || !slices.IsSorted(reported) {
+		t.Errorf("progress reported %v, want 0 up to %d", reported, len(content))
+	}
+}
+
+func TestFetchResumable(t *testing.T) {
+	content := strings.Repeat("0123456789", 10)
+	tests := []struct {
+		name         string
+		part         string
+		contentRange string
+		served       string
+		want         []string
+	}{
+		{"resumed", content[:10], "bytes 10-99/100", content[10:], []string{"bytes=10-"}},
+		{"other range", "stale part", "bytes 0-99/100", content, []string{"bytes=10-", ""}},
+		{"no range", "", "", content, []string{""}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var requested []string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				requested = append(requested, r.Header.Get("Range"))
+				if r.Header.Get("Range") == "" {
+					w.Write([]byte(content))
+					return
+				}
+				w.Header().Set("Content-Range", tt.contentRange)
+				w.WriteHeader(http.StatusPartialContent)
+				w.Write([]byte(tt.served))
+			}))
+			defer server.Close()
+
+			filePath := filepath.Join(t.TempDir(), "tool")
+			if tt.part != "" {
+				if err := os.WriteFile(filePath+".part", []byte(tt.part), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			client := &http.Client{Transport: &retryTransport{next: http.DefaultTransport, policy: retryPolicy{retries: 1, delay: time.Millisecond}}}
+			if err := fetchResumable(context.Background(), client, "", nil, nil, server.URL, filePath, nil, nil); err != nil {
+				t.Fatal(err)
+			}
+			if got, err := os.ReadFile(filePath); err != nil || string(got) != content {
+				t.Errorf("downloaded %q (%v), want %q", got, err, content)
+			}
+			if !slices.Equal(requested, tt.want) {
+				t.Errorf("requested ranges %q, want %q", requested, tt.want)
+			}
+		})
+	}
+}
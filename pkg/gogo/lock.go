@@ -0,0 +1,134 @@
+package gogo
+
+import (
+	"bytes"
+	"cmp"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+)
+
+// DefaultLockFile is the name of the lockfile, kept next to the
+// configuration file.
+const DefaultLockFile = "gogo.lock"
+
+// Lock records the asset installed for each command, per platform, so that
+// other machines can install exactly the same ones. It is kept as TOML, to be
+// committed along with the configuration.
+type Lock struct {
+	Commands []LockedCommand `toml:"commands"`
+}
+
+// LockedCommand is the asset a command was installed from on a platform.
+type LockedCommand struct {
+	File   string       `toml:"file"`
+	Repo   string       `toml:"repo"`
+	OS     string       `toml:"os"`
+	Arch   string       `toml:"arch"`
+	Tag    string       `toml:"tag,omitempty"`
+	Asset  string       `toml:"asset"`
+	Format EAssetFormat `toml:"format"`
+	Url    string       `toml:"url"`
+	// SignatureUrl is set for repositories with a signature
+	SignatureUrl string `toml:"signature_url,omitempty"`
+	// Sha256 is empty for commands built with go install
+	Sha256 string `toml:"sha256,omitempty"`
+}
+
+// LockPath returns where the lockfile of the configuration at configPath is:
+// in the configuration directory, or next to the configuration file. Of
+// several configuration paths, the first one is used.
+func LockPath(configPath string) string {
+	configPath, _, _ = strings.Cut(configPath, ",")
+	if info, err := os.Stat(configPath); err == nil && info.IsDir() {
+		return filepath.Join(configPath, DefaultLockFile)
+	}
+	return filepath.Join(filepath.Dir(configPath), DefaultLockFile)
+}
+
+// LoadLock reads the lockfile at lockPath. A missing file is an empty lock.
+func LoadLock(lockPath string) (*Lock, error) {
+	lock := &Lock{}
+	if _, err := toml.DecodeFile(lockPath, lock); err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	return lock, nil
+}
+
+// Save writes the lock to lockPath, replacing the file at once.
+func (l *Lock) Save(lockPath string) error {
+	slices.SortFunc(l.Commands, func(a, b LockedCommand) int {
+		return cmp.Or(cmp.Compare(a.File, b.File), cmp.Compare(a.OS, b.OS), cmp.Compare(a.Arch, b.Arch))
+	})
+	var content bytes.Buffer
+	content.WriteString("# Written by gogo fetch, installed again as is by gogo fetch -locked.\n\n")
+	if err := toml.NewEncoder(&content).Encode(l); err != nil {
+		return err
+	}
+	tmpFile, err := os.CreateTemp(filepath.Dir(lockPath), ".gogo_*.lock")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmpFile.Name())
+	if _, err := tmpFile.Write(content.Bytes()); err != nil {
+		tmpFile.Close()
+		return err
+	}
+	if err := tmpFile.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmpFile.Name(), lockPath)
+}
+
+// Record locks the asset a command was installed from for host's platform.
+// The command's assets for other platforms are dropped if they are of another
+// release, so that all platforms install the same one.
+func (l *Lock) Record(status *RepoStatus, host Host) {
+	l.Commands = slices.DeleteFunc(l.Commands, func(locked LockedCommand) bool {
+		return locked.File == status.Repo.File && (locked.OS == host.OS && locked.Arch == host.Arch || locked.Tag != status.Tag)
+	})
+	l.Commands = append(l.Commands, LockedCommand{
+		File:         status.Repo.File,
+		Repo:         status.Repo.Name,
+		OS:           host.OS,
+		Arch:         host.Arch,
+		Tag:          status.Tag,
+		Asset:        status.Asset,
+		Format:       status.Format,
+		Url:          status.Url,
+		SignatureUrl: status.SignatureUrl,
+		Sha256:       status.Sha256,
+	})
+}
+
+// Status returns how to install the asset locked for repo on host's
+// platform, to be installed as if it had been resolved.
+func (l *Lock) Status(repo *Repository, host Host) (RepoStatus, error) {
+	status := RepoStatus{Repo: repo, Status: RepoKO}
+	i := slices.IndexFunc(l.Commands, func(locked LockedCommand) bool {
+		return locked.File == repo.File && locked.OS == host.OS && locked.Arch == host.Arch
+	})
+	switch {
+	case i < 0 && slices.ContainsFunc(l.Commands, func(locked LockedCommand) bool { return locked.File == repo.File }):
+		return status, fmt.Errorf("%s is not locked for %s/%s", repo.File, host.OS, host.Arch)
+	case i < 0:
+		return status, fmt.Errorf("%s is not locked", repo.File)
+	case l.Commands[i].Repo != repo.Name:
+		return status, fmt.Errorf("%s is locked from %s, not %s", repo.File, l.Commands[i].Repo, repo.Name)
+	case l.Commands[i].Sha256 == "" && l.Commands[i].Format != GoInstallFormat:
+		return status, fmt.Errorf("%s is locked without its checksum", repo.File)
+	}
+	locked := l.Commands[i]
+	status.Status = RepoOK
+	status.Tag = locked.Tag
+	status.Asset = locked.Asset
+	status.Format = locked.Format
+	status.Url = locked.Url
+	status.SignatureUrl = locked.SignatureUrl
+	status.Sha256 = locked.Sha256
+	return status, nil
+}
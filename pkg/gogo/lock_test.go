@@ -0,0 +1,105 @@
+package gogo
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLock(t *testing.T) {
+	repo := &Repository{Name: "owner/tool", File: "tool"}
+	linux := Host{"linux", "amd64", "glibc"}
+	darwin := Host{"darwin", "arm64", ""}
+	lock := &Lock{}
+	lock.Record(&RepoStatus{Repo: repo, Tag: "v1.0", Asset: "tool-linux-amd64.tar.gz", Format: TargzipFormat, Url: "https://example.com/linux", Sha256: "aa"}, linux)
+	lock.Record(&RepoStatus{Repo: repo, Tag: "v1.0", Asset: "tool-darwin-arm64.zip", Format: ZipFormat, Url: "https://example.com/darwin", Sha256: "bb"}, darwin)
+
+	lockPath := filepath.Join(t.TempDir(), DefaultLockFile)
+	if err := lock.Save(lockPath); err != nil {
+		t.Fatal(err)
+	}
+	lock, err := LoadLock(lockPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	status, err := lock.Status(repo, linux)
+	if err != nil || status.Status != RepoOK || status.Asset != "tool-linux-amd64.tar.gz" || status.Format != TargzipFormat || status.Sha256 != "aa" {
+		t.Errorf("Status(linux) = %+v, %v", status, err)
+	}
+	if status, err = lock.Status(repo, darwin); err != nil || status.Url != "https://example.com/darwin" {
+		t.Errorf("Status(darwin) = %+v, %v", status, err)
+	}
+
+	tests := []struct {
+		repo *Repository
+		host Host
+		want string
+	}{
+		{repo, Host{"windows", "amd64", ""}, "tool is not locked for windows/amd64"},
+		{&Repository{Name: "owner/other", File: "other"}, linux, "other is not locked"},
+		{&Repository{Name: "fork/tool", File: "tool"}, linux, "tool is locked from owner/tool, not fork/tool"},
+	}
+	for _, tt := range tests {
+		if _, err := lock.Status(tt.repo, tt.host); err == nil || err.Error() != tt.want {
+			t.Errorf("Status(%s, %s/%s) = %v, want %s", tt.repo.Name, tt.host.OS, tt.host.Arch, err, tt.want)
+		}
+	}
+
+	// Updating on one platform unlocks the others, left on the older release
+	lock.Record(&RepoStatus{Repo: repo, Tag: "v1.1", Asset: "tool-linux-amd64.tar.gz", Format: TargzipFormat, Url: "https://example.com/linux", Sha256: "cc"}, linux)
+	if len(lock.Commands) != 1 || lock.Commands[0].Tag != "v1.1" {
+		t.Errorf("lock after update = %+v", lock.Commands)
+	}
+
+	if lock, err := LoadLock(filepath.Join(t.TempDir(), DefaultLockFile)); err != nil || len(lock.Commands) != 0 {
+		t.Errorf("LoadLock() of a missing file = %+v, %v", lock, err)
+	}
+}
+
+func TestLockPath(t *testing.T) {
+	dir := t.TempDir()
+	tests := []struct {
+		configPath string
+		want       string
+	}{
+		{dir, filepath.Join(dir, DefaultLockFile)},
+		{filepath.Join(dir, "config.toml"), filepath.Join(dir, DefaultLockFile)},
+		{dir + ",/etc/gogo/team.toml", filepath.Join(dir, DefaultLockFile)},
+	}
+	for _, tt := range tests {
+		if got := LockPath(tt.configPath); got != tt.want {
+			t.Errorf("LockPath(%s) = %s, want %s", tt.configPath, got, tt.want)
+		}
+	}
+}
+
+func TestInstallLocked(t *testing.T) {
+	content := "#!/bin/sh\n"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, content)
+	}))
+	defer server.Close()
+	sum := sha256.Sum256([]byte(content))
+	checksum := hex.EncodeToString(sum[:])
+
+	client := &Client{HTTP: server.Client()}
+	newStatus := func(sha256 string) *RepoStatus {
+		return &RepoStatus{Repo: &Repository{Name: "owner/tool", File: "tool"}, Status: RepoOK, Format: BinaryFormat, Asset: "tool", Url: server.URL + "/tool", Sha256: sha256}
+	}
+	status := newStatus("")
+	if err := client.Install(status, t.TempDir()); err != nil || status.Sha256 != checksum {
+		t.Errorf("Install() = %v, checksum %s, want %s", err, status.Sha256, checksum)
+	}
+	if err := client.Install(newStatus(checksum), t.TempDir()); err != nil {
+		t.Errorf("Install() of the locked asset = %v", err)
+	}
+	err := client.Install(newStatus(strings.Repeat("0", 64)), t.TempDir())
+	if err == nil || !strings.Contains(err.Error(), "changed since it was locked") {
+		t.Errorf("Install() of a changed asset = %v", err)
+	}
+}
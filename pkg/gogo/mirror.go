@@ -0,0 +1,60 @@
+package gogo
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"text/template"
+)
+
+// mirrorData is what mirror URL templates may refer to, e.g.
+// "https://mirror.example.com/{{.Tag}}/{{.Asset}}".
+type mirrorData struct {
+	// Asset is the name of the asset to download
+	Asset string
+	// Tag is the release's tag, empty for repositories downloaded from a URL
+	Tag string
+	// Name is the repository's name, as owner/repo
+	Name string
+}
+
+// mirrorURLs expands the repository's mirrors for the asset being installed,
+// in the order they are to be tried.
+func mirrorURLs(status *RepoStatus) ([]string, error) {
+	data := mirrorData{Asset: status.Asset, Tag: status.Tag, Name: status.Repo.Name}
+	var urls []string
+	for _, mirror := range status.Repo.Mirrors {
+		expanded, err := expandMirror(mirror, data)
+		if err != nil {
+			return nil, err
+		}
+		urls = append(urls, expanded)
+	}
+	return urls, nil
+}
+
+func expandMirror(mirror string, data mirrorData) (string, error) {
+	tmpl, err := template.New("mirror").Option("missingkey=error").Parse(mirror)
+	if err != nil {
+		return "", fmt.Errorf("invalid mirror %q: %v", mirror, err)
+	}
+	var expanded strings.Builder
+	if err := tmpl.Execute(&expanded, data); err != nil {
+		return "", fmt.Errorf("invalid mirror %q: %v", mirror, err)
+	}
+	return expanded.String(), nil
+}
+
+// checkMirrors makes sure mirrors expand to HTTP(S) URLs.
+func checkMirrors(mirrors []string) error {
+	for _, mirror := range mirrors {
+		expanded, err := expandMirror(mirror, mirrorData{Asset: "tool.tar.gz", Tag: "v1.0.0", Name: "owner/repo"})
+		if err != nil {
+			return err
+		}
+		if parsed, err := url.Parse(expanded); err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+			return fmt.Errorf("invalid mirror %q: not an HTTP(S) URL", mirror)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,97 @@
+package gogo
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestInstallFromMirror(t *testing.T) {
+	var requested []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requested = append(requested, r.URL.Path)
+		switch r.URL.Path {
+		case "/second/v1.0/tool-linux-amd64":
+			fmt.Fprint(w, "#!/bin/sh\n")
+		case "/first/tool-linux-amd64":
+			http.Error(w, "blocked", http.StatusForbidden)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	repo := &Repository{Name: "owner/tool", File: "tool", Mirrors: []string{
+		server.URL + "/first/{{.Asset}}",
+		server.URL + "/second/{{.Tag}}/{{.Asset}}",
+		server.URL + "/third/{{.Asset}}",
+	}}
+	status := &RepoStatus{Repo: repo, Status: RepoOK, Format: BinaryFormat, Asset: "tool-linux-amd64", Tag: "v1.0", Url: server.URL + "/releases/tool-linux-amd64"}
+	targetDir := t.TempDir()
+	client := &Client{HTTP: server.Client()}
+	if err := client.Install(status, targetDir); err != nil {
+		t.Fatal(err)
+	}
+	if content, err := os.ReadFile(filepath.Join(targetDir, "tool")); err != nil || string(content) != "#!/bin/sh\n" {
+		t.Errorf("installed %q (%v)", content, err)
+	}
+	want := []string{"/releases/tool-linux-amd64", "/first/tool-linux-amd64", "/second/v1.0/tool-linux-amd64"}
+	if !slices.Equal(requested, want) {
+		t.Errorf("requested %v, want %v", requested, want)
+	}
+	if len(status.Notes) != 1 {
+		t.Errorf("notes = %q, want the mirror used", status.Notes)
+	}
+
+	repo.Mirrors = repo.Mirrors[2:]
+	if err := client.Install(status, targetDir); err == nil {
+		t.Error("Install() succeeded without any source serving the asset")
+	}
+}
+
+func TestInstallCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Length", "1000")
+		fmt.Fprint(w, "#!/bin/sh\n")
+		w.(http.Flusher).Flush()
+		cancel()
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+
+	repo := &Repository{Name: "owner/tool", File: "tool"}
+	status := &RepoStatus{Repo: repo, Status: RepoOK, Format: BinaryFormat, Asset: "tool-linux-amd64", Url: server.URL + "/tool-linux-amd64"}
+	targetDir := t.TempDir()
+	client := &Client{HTTP: server.Client(), Context: ctx}
+	if err := client.Install(status, targetDir); err == nil {
+		t.Fatal("Install() succeeded after being cancelled")
+	}
+	if entries, _ := os.ReadDir(targetDir); len(entries) != 0 {
+		t.Errorf("cancelled install left %v behind", entries)
+	}
+}
+
+func TestCheckMirrors(t *testing.T) {
+	tests := []struct {
+		mirror string
+		valid  bool
+	}{
+		{"https://mirror.example.com/{{.Asset}}", true},
+		{"http://mirror.example.com/{{.Name}}/{{.Tag}}/{{.Asset}}", true},
+		{"https://mirror.example.com/{{.Version}}", false},
+		{"https://mirror.example.com/{{.Asset", false},
+		{"mirror.example.com/{{.Asset}}", false},
+		{"ftp://mirror.example.com/{{.Asset}}", false},
+	}
+	for _, tt := range tests {
+		if err := checkMirrors([]string{tt.mirror}); (err == nil) != tt.valid {
+			t.Errorf("checkMirrors(%q) = %v, want valid: %v", tt.mirror, err, tt.valid)
+		}
+	}
+}
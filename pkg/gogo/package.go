@@ -0,0 +1,207 @@
+package gogo
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// Packages are only unpacked: their files are picked the same way as from
+// any archive, and neither dpkg nor rpm is involved.
+
+// writeDebFile installs the wanted files of a Debian package, an ar archive
+// whose data.tar member holds the files.
+func writeDebFile(extraction *extraction, packagePath string) (err error) {
+	file, err := os.Open(packagePath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	reader := bufio.NewReader(file)
+	magic := make([]byte, 8)
+	if _, err := io.ReadFull(reader, magic); err != nil || string(magic) != "!<arch>\n" {
+		return fmt.Errorf("not a Debian package")
+	}
+	for {
+		name, size, err := nextArMember(reader)
+		if err != nil {
+			if err == io.EOF {
+				return fmt.Errorf("no data archive in Debian package")
+			}
+			return err
+		}
+		member := io.LimitReader(reader, size)
+		if strings.HasPrefix(name, "data.tar") {
+			data, err := decompress(extraction.ctx, bufio.NewReader(member))
+			if err != nil {
+				return fmt.Errorf("error reading %s: %v", name, err)
+			}
+			defer closeReader(data, &err)
+			return extractTar(tar.NewReader(data), extraction)
+		}
+		// Members are aligned on even offsets
+		if _, err := io.CopyN(io.Discard, reader, size+size%2); err != nil {
+			return err
+		}
+	}
+}
+
+// nextArMember reads the header of an ar archive's next member, returning
+// its name and size.
+func nextArMember(reader io.Reader) (string, int64, error) {
+	header := make([]byte, 60)
+	if _, err := io.ReadFull(reader, header); err != nil {
+		if err == io.ErrUnexpectedEOF {
+			return "", 0, fmt.Errorf("truncated ar archive")
+		}
+		return "", 0, err
+	}
+	if string(header[58:60]) != "`\n" {
+		return "", 0, fmt.Errorf("malformed ar archive")
+	}
+	size, err := strconv.ParseInt(strings.TrimSpace(string(header[48:58])), 10, 64)
+	if err != nil || size < 0 {
+		return "", 0, fmt.Errorf("malformed ar archive")
+	}
+	name := strings.TrimSuffix(strings.TrimSpace(string(header[0:16])), "/")
+	return name, size, nil
+}
+
+// writeRpmFile installs the wanted files of an RPM package: past its lead
+// and headers comes a compressed cpio archive.
+func writeRpmFile(extraction *extraction, packagePath string) (err error) {
+	file, err := os.Open(packagePath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	reader := bufio.NewReader(file)
+	lead := make([]byte, 96)
+	if _, err := io.ReadFull(reader, lead); err != nil || !bytes.Equal(lead[:4], []byte{0xed, 0xab, 0xee, 0xdb}) {
+		return fmt.Errorf("not an RPM package")
+	}
+	// The signature header is padded to a multiple of 8 bytes, the main
+	// header is not
+	if err := skipRpmHeader(reader, true); err != nil {
+		return err
+	}
+	if err := skipRpmHeader(reader, false); err != nil {
+		return err
+	}
+	payload, err := decompress(extraction.ctx, reader)
+	if err != nil {
+		return fmt.Errorf("error reading RPM payload: %v", err)
+	}
+	defer closeReader(payload, &err)
+	return extractCpio(payload, extraction)
+}
+
+func skipRpmHeader(reader io.Reader, padded bool) error {
+	intro := make([]byte, 16)
+	if _, err := io.ReadFull(reader, intro); err != nil || !bytes.Equal(intro[:3], []byte{0x8e, 0xad, 0xe8}) {
+		return fmt.Errorf("malformed RPM header")
+	}
+	count := int64(binary.BigEndian.Uint32(intro[8:12]))
+	size := int64(binary.BigEndian.Uint32(intro[12:16]))
+	length := count*16 + size
+	if padded && length%8 != 0 {
+		length += 8 - length%8
+	}
+	if _, err := io.CopyN(io.Discard, reader, length); err != nil {
+		return fmt.Errorf("malformed RPM header")
+	}
+	return nil
+}
+
+// extractCpio installs the wanted entries of a cpio archive in the "new"
+// ASCII format used by RPM. Like with tar, links are created last.
+func extractCpio(reader io.Reader, extraction *extraction) error {
+	type cpioLink struct{ name, target, filePath string }
+	var links []cpioLink
+	header := make([]byte, 110)
+	for !extraction.complete() {
+		if _, err := io.ReadFull(reader, header); err != nil {
+			return fmt.Errorf("truncated cpio archive")
+		}
+		if magic := string(header[:6]); magic != "070701" && magic != "070702" {
+			return fmt.Errorf("unsupported cpio archive")
+		}
+		field := func(i int) (int64, error) {
+			return strconv.ParseInt(string(header[6+i*8:14+i*8]), 16, 64)
+		}
+		mode, err := field(1)
+		if err != nil {
+			return fmt.Errorf("malformed cpio archive")
+		}
+		nlink, err := field(4)
+		if err != nil {
+			return fmt.Errorf("malformed cpio archive")
+		}
+		size, err := field(6)
+		if err != nil {
+			return fmt.Errorf("malformed cpio archive")
+		}
+		nameSize, err := field(11)
+		if err != nil || nameSize < 1 {
+			return fmt.Errorf("malformed cpio archive")
+		}
+		// The name and the data are both aligned on 4 bytes
+		name := make([]byte, nameSize+(4-(110+nameSize)%4)%4)
+		if _, err := io.ReadFull(reader, name); err != nil {
+			return fmt.Errorf("truncated cpio archive")
+		}
+		entryName := path.Clean(string(name[:nameSize-1]))
+		if entryName == "TRAILER!!!" {
+			break
+		}
+		data := io.LimitReader(reader, size)
+		padding := (4 - size%4) % 4
+
+		var filePath string
+		var fileMode os.FileMode
+		switch mode & 0o170000 {
+		case 0o100000:
+			if nlink > 1 && size == 0 {
+				// Hard links only come with data on their last occurrence
+				break
+			}
+			filePath, fileMode, err = extraction.destination(entryName, os.FileMode(mode&0o777))
+		case 0o120000:
+			filePath, _, err = extraction.destination(entryName, os.ModeSymlink|os.FileMode(mode&0o777))
+		}
+		if err != nil {
+			return err
+		}
+		if filePath != "" && mode&0o170000 == 0o120000 {
+			target, err := io.ReadAll(data)
+			if err != nil {
+				return err
+			}
+			links = append(links, cpioLink{entryName, string(target), filePath})
+		} else if filePath != "" {
+			if err := extraction.write(filePath, data, fileMode); err != nil {
+				return err
+			}
+			extraction.extracted[entryName] = filePath
+		}
+		if _, err := io.Copy(io.Discard, data); err != nil {
+			return err
+		}
+		if _, err := io.CopyN(io.Discard, reader, padding); err != nil {
+			return fmt.Errorf("truncated cpio archive")
+		}
+	}
+	for _, link := range links {
+		if err := extraction.link(link.name, link.target, true, link.filePath); err != nil {
+			return err
+		}
+	}
+	return nil
+}
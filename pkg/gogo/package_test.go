@@ -0,0 +1,138 @@
+package gogo
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+var packageFiles = []struct {
+	name string
+	mode int64
+	body string
+}{
+	{"./usr/share/doc/tool/README", 0o644, "read me"},
+	{"./usr/bin/tool", 0o755, "#!/bin/sh\necho tool\n"},
+	{"./usr/bin/toolctl", 0o755, "#!/bin/sh\necho toolctl\n"},
+}
+
+func gzipped(t *testing.T, content []byte) []byte {
+	var buf bytes.Buffer
+	gw := gzip.NewWriter(&buf)
+	gw.Write(content)
+	if err := gw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func debPackage(t *testing.T) []byte {
+	var data bytes.Buffer
+	tw := tar.NewWriter(&data)
+	for _, file := range packageFiles {
+		tw.WriteHeader(&tar.Header{Name: file.name, Typeflag: tar.TypeReg, Mode: file.mode, Size: int64(len(file.body))})
+		tw.Write([]byte(file.body))
+	}
+	tw.Close()
+
+	var deb bytes.Buffer
+	deb.WriteString("!<arch>\n")
+	for _, member := range []struct {
+		name    string
+		content []byte
+	}{
+		{"debian-binary", []byte("2.0\n")},
+		{"control.tar.gz", gzipped(t, []byte("odd"))},
+		{"data.tar.gz", gzipped(t, data.Bytes())},
+	} {
+		fmt.Fprintf(&deb, "%-16s%-12s%-6s%-6s%-8s%-10d`\n", member.name, "0", "0", "0", "100644", len(member.content))
+		deb.Write(member.content)
+		if len(member.content)%2 != 0 {
+			deb.WriteByte('\n')
+		}
+	}
+	return deb.Bytes()
+}
+
+func rpmPackage(t *testing.T) []byte {
+	var payload bytes.Buffer
+	entry := func(name string, mode int64, body string) {
+		fmt.Fprintf(&payload, "070701%08x%08x%08x%08x%08x%08x%08x%08x%08x%08x%08x%08x%08x",
+			0, mode, 0, 0, 1, 0, len(body), 0, 0, 0, 0, len(name)+1, 0)
+		payload.WriteString(name + "\x00")
+		for payload.Len()%4 != 0 {
+			payload.WriteByte(0)
+		}
+		payload.WriteString(body)
+		for payload.Len()%4 != 0 {
+			payload.WriteByte(0)
+		}
+	}
+	for _, file := range packageFiles {
+		entry(file.name, 0o100000|file.mode, file.body)
+	}
+	entry("./usr/bin/tool-link", 0o120777, "tool")
+	entry("TRAILER!!!", 0, "")
+
+	var rpm bytes.Buffer
+	rpm.Write([]byte{0xed, 0xab, 0xee, 0xdb})
+	rpm.Write(make([]byte, 92))
+	// A signature header with one 5-byte entry, padded, then an empty header
+	rpm.Write([]byte{0x8e, 0xad, 0xe8, 0x01, 0, 0, 0, 0, 0, 0, 0, 1, 0, 0, 0, 5})
+	rpm.Write(make([]byte, 16+5+3))
+	rpm.Write([]byte{0x8e, 0xad, 0xe8, 0x01, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0})
+	rpm.Write(gzipped(t, payload.Bytes()))
+	return rpm.Bytes()
+}
+
+func TestPackageFormats(t *testing.T) {
+	tests := []struct {
+		name    string
+		content []byte
+		write   func(*extraction, string) error
+	}{
+		{"deb", debPackage(t), writeDebFile},
+		{"rpm", rpmPackage(t), writeRpmFile},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			packagePath := filepath.Join(t.TempDir(), "tool."+tt.name)
+			if err := os.WriteFile(packagePath, tt.content, 0o644); err != nil {
+				t.Fatal(err)
+			}
+			targetDir := t.TempDir()
+			repo := &Repository{Name: "owner/tool", File: "tool", Utils: []string{"toolctl"}}
+			extraction, err := newExtraction(&RepoStatus{Repo: repo, Mode: 0o755}, targetDir, DefaultMaxSize)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := tt.write(extraction, packagePath); err != nil {
+				t.Fatal(err)
+			}
+			for _, file := range packageFiles[1:] {
+				content, err := os.ReadFile(filepath.Join(targetDir, filepath.Base(file.name)))
+				if err != nil || string(content) != file.body {
+					t.Errorf("%s = %q (%v), want %q", file.name, content, err, file.body)
+				}
+			}
+			if ExistFile(filepath.Join(targetDir, "README")) {
+				t.Error("README was installed")
+			}
+		})
+	}
+}
+
+func TestPackageAssetSelection(t *testing.T) {
+	assets := assetList("tool_1.0_amd64.deb", "tool_1.0_arm64.deb", "tool-1.0.x86_64.rpm", "tool_1.0_darwin_amd64.zip")
+	linux, _ := selectAsset(assets, Host{"linux", "arm64", "glibc"}, DefaultIgnore, DefaultPrefer, DefaultDemote, t.Logf)
+	if linux == nil || linux.Name != "tool_1.0_arm64.deb" {
+		t.Errorf("selectAsset() for linux = %v, want the arm64 package", linux)
+	}
+	if got := platformMismatch(platformName("tool_1.0_amd64.deb"), Amd64Arch, []string{"darwin"}); got != "linux" {
+		t.Errorf("platformMismatch() for darwin = %q, want linux", got)
+	}
+}
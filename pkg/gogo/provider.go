@@ -0,0 +1,168 @@
+package gogo
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Providers host repositories and their releases. Repositories are on GitHub
+// unless their provider says otherwise. Gitea covers Forgejo too.
+const (
+	ProviderGitHub = "github"
+	ProviderGitLab = "gitlab"
+	ProviderGitea  = "gitea"
+)
+
+// Where repositories of other providers than GitHub are looked for, unless
+// they give another host.
+const (
+	DefaultGitLabHost = "gitlab.com"
+	DefaultGiteaHost  = "codeberg.org"
+)
+
+// provider returns the repository's provider, GitHub by default.
+func (r *Repository) provider() string {
+	if r.Provider == "" {
+		return ProviderGitHub
+	}
+	return r.Provider
+}
+
+// CheckName makes sure the repository's name suits its provider: owner/repo,
+// or on GitLab a project path such as group/subgroup/project.
+func (r *Repository) CheckName() error {
+	if r.provider() != ProviderGitLab {
+		return ValidateName(r.Name)
+	}
+	parts := strings.Split(r.Name, "/")
+	if len(parts) < 2 || slices.ContainsFunc(parts, func(part string) bool {
+		return part == "" || strings.ContainsAny(part, " ?#:")
+	}) {
+		return fmt.Errorf("malformed project path %q: expected group/project", r.Name)
+	}
+	return nil
+}
+
+// checkProvider makes sure a repository's provider is known, and that only
+// providers other than GitHub are given a host.
+func checkProvider(repo *Repository) error {
+	switch repo.provider() {
+	case ProviderGitHub:
+		if repo.Host != "" {
+			return fmt.Errorf("host is only for other providers than github")
+		}
+	case ProviderGitLab, ProviderGitea:
+	default:
+		return fmt.Errorf("unknown provider %s (expected github, gitlab or gitea)", repo.Provider)
+	}
+	return nil
+}
+
+// hostURL returns the root URL of the repository's host: its host, with
+// https:// unless it has a scheme, or the provider's default host.
+func hostURL(host string, defaultHost string) string {
+	if host == "" {
+		host = defaultHost
+	}
+	if !strings.Contains(host, "://") {
+		host = "https://" + host
+	}
+	return strings.TrimSuffix(host, "/")
+}
+
+// releasesURL returns the API endpoint of the repository's releases.
+func (c *Client) releasesURL(repo *Repository) string {
+	switch repo.provider() {
+	case ProviderGitLab:
+		return fmt.Sprintf("%s/api/v4/projects/%s/releases", hostURL(repo.Host, DefaultGitLabHost), url.PathEscape(repo.Name))
+	case ProviderGitea:
+		return fmt.Sprintf("%s/api/v1/repos/%s/releases", hostURL(repo.Host, DefaultGiteaHost), repo.Name)
+	}
+	return fmt.Sprintf("%s/repos/%s/releases", c.APIURL, repo.Name)
+}
+
+// releaseListURL returns the API endpoint listing the repository's releases,
+// newest first, with as many per page as allowed.
+func (c *Client) releaseListURL(repo *Repository) string {
+	if repo.provider() == ProviderGitea {
+		return c.releasesURL(repo) + "?limit=50"
+	}
+	return c.releasesURL(repo) + "?per_page=100"
+}
+
+// latestReleaseURL returns the API endpoint serving the repository's latest
+// release.
+func (c *Client) latestReleaseURL(repo *Repository) string {
+	if repo.provider() == ProviderGitLab {
+		return c.releasesURL(repo) + "/permalink/latest"
+	}
+	return c.releasesURL(repo) + "/latest"
+}
+
+// taggedReleaseURL returns the API endpoint serving the repository's release
+// with the given tag.
+func (c *Client) taggedReleaseURL(repo *Repository, tag string) string {
+	if repo.provider() == ProviderGitLab {
+		return c.releasesURL(repo) + "/" + url.PathEscape(tag)
+	}
+	return c.releasesURL(repo) + "/tags/" + url.PathEscape(tag)
+}
+
+// apiToken returns the token to authenticate API requests for a repository
+// with: the GitHub token is only sent to GitHub. Other providers'
+// tokens go in the repository's headers.
+func (c *Client) apiToken(repo *Repository) string {
+	if repo != nil && repo.provider() != ProviderGitHub {
+		return ""
+	}
+	return c.Token
+}
+
+// UnmarshalJSON decodes a release as described by GitHub and Gitea, or by
+// GitLab, which lists assets as links, and tells when a release was released
+// rather than published.
+func (r *release) UnmarshalJSON(data []byte) error {
+	type plain release
+	var fields struct {
+		plain
+		// Shadows plain's, as GitLab's is an object
+		Assets     json.RawMessage `json:"assets"`
+		ReleasedAt time.Time       `json:"released_at"`
+	}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return err
+	}
+	*r = release(fields.plain)
+	if r.PublishedAt.IsZero() {
+		r.PublishedAt = fields.ReleasedAt
+	}
+	switch assets := bytes.TrimSpace(fields.Assets); {
+	case len(assets) == 0 || bytes.Equal(assets, []byte("null")):
+		return nil
+	case assets[0] == '[':
+		return json.Unmarshal(assets, &r.Assets)
+	}
+	var gitlabAssets struct {
+		Links []struct {
+			Name           string `json:"name"`
+			URL            string `json:"url"`
+			DirectAssetURL string `json:"direct_asset_url"`
+		} `json:"links"`
+	}
+	if err := json.Unmarshal(fields.Assets, &gitlabAssets); err != nil {
+		return err
+	}
+	for _, link := range gitlabAssets.Links {
+		downloadURL := link.DirectAssetURL
+		if downloadURL == "" {
+			downloadURL = link.URL
+		}
+		r.Assets = append(r.Assets, ReleaseAsset{Name: link.Name, BrowserDownloadURL: downloadURL})
+	}
+	return nil
+}
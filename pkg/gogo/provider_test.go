@@ -0,0 +1,117 @@
+package gogo
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestResolveAssetGitLab(t *testing.T) {
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "" {
+			t.Errorf("GitHub token sent to GitLab: %s", r.Header.Get("Authorization"))
+		}
+		if r.Header.Get("PRIVATE-TOKEN") != "glpat-secret" {
+			t.Errorf("request without the repository's headers")
+		}
+		switch r.URL.EscapedPath() {
+		case "/api/v4/projects/group%2Fsub%2Ftool/releases/permalink/latest", "/api/v4/projects/group%2Fsub%2Ftool/releases/v1.0":
+			fmt.Fprintf(w, `{"tag_name": "v1.0", "released_at": "2024-05-01T10:00:00Z", "assets": {"count": 3, "sources": [{"format": "zip", "url": "%[1]s/src.zip"}], "links": [
+				{"id": 1, "name": "tool-linux-amd64.tar.gz", "url": "%[1]s/-/releases/v1.0/downloads/tool-linux-amd64.tar.gz", "direct_asset_url": "%[1]s/direct/tool-linux-amd64.tar.gz"},
+				{"id": 2, "name": "tool-darwin-arm64.tar.gz", "url": "%[1]s/tool-darwin-arm64.tar.gz"}]}}`, server.URL)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+	client := &Client{HTTP: server.Client(), APIURL: "https://api.github.invalid", Token: "ghp_secret"}
+	headers := map[string]string{"PRIVATE-TOKEN": "glpat-secret"}
+
+	for _, version := range []string{"", "v1.0"} {
+		repo := &Repository{Name: "group/sub/tool", File: "tool", Provider: ProviderGitLab, Host: server.URL, Headers: headers, Version: version}
+		status, err := client.ResolveAsset(repo, Host{"linux", "amd64", "glibc"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if status.Status != RepoOK || status.Tag != "v1.0" || status.Url != server.URL+"/direct/tool-linux-amd64.tar.gz" {
+			t.Errorf("version %q: got %+v", version, status)
+		}
+		if status.PublishedAt.IsZero() {
+			t.Errorf("version %q: release date is missing", version)
+		}
+	}
+}
+
+func TestResolveAssetGitea(t *testing.T) {
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "" {
+			t.Errorf("GitHub token sent to Gitea: %s", r.Header.Get("Authorization"))
+		}
+		switch r.URL.Path {
+		case "/api/v1/repos/owner/tool/releases/latest":
+			fmt.Fprintf(w, `{"id": 2, "tag_name": "v1.0", "assets": [{"id": 7, "name": "tool_linux_amd64", "browser_download_url": "%s/owner/tool/releases/download/v1.0/tool_linux_amd64"}]}`, server.URL)
+		case "/api/v1/repos/owner/tool/releases":
+			if r.URL.Query().Get("limit") == "" {
+				t.Errorf("releases listed without a limit: %s", r.URL)
+			}
+			fmt.Fprintf(w, `[{"id": 3, "tag_name": "v1.1-rc1", "prerelease": true, "assets": [{"id": 8, "name": "tool_linux_amd64", "browser_download_url": "%s/rc/tool_linux_amd64"}]}]`, server.URL)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+	client := &Client{HTTP: server.Client(), APIURL: "https://api.github.invalid", Token: "ghp_secret"}
+
+	tests := []struct {
+		prerelease bool
+		want       string
+	}{
+		{false, server.URL + "/owner/tool/releases/download/v1.0/tool_linux_amd64"},
+		{true, server.URL + "/rc/tool_linux_amd64"},
+	}
+	for _, tt := range tests {
+		repo := &Repository{Name: "owner/tool", File: "tool", Provider: ProviderGitea, Host: server.URL, Prerelease: tt.prerelease}
+		status, err := client.ResolveAsset(repo, Host{"linux", "amd64", "glibc"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		// Downloads go to the browser URL, not GitHub's API, whatever the token
+		if status.Status != RepoOK || status.Url != tt.want {
+			t.Errorf("prerelease %v: got %+v, want %s", tt.prerelease, status, tt.want)
+		}
+	}
+	if got := client.releasesURL(&Repository{Name: "owner/tool", Provider: ProviderGitea}); got != "https://codeberg.org/api/v1/repos/owner/tool/releases" {
+		t.Errorf("releases of a Gitea repository without host at %s, want Codeberg's", got)
+	}
+}
+
+func TestCheckProvider(t *testing.T) {
+	tests := []struct {
+		repo  Repository
+		valid bool
+	}{
+		{Repository{Name: "owner/tool"}, true},
+		{Repository{Name: "owner/tool", Provider: "github"}, true},
+		{Repository{Name: "group/sub/tool", Provider: "gitlab"}, true},
+		{Repository{Name: "group/tool", Provider: "gitlab", Host: "gitlab.example.com"}, true},
+		{Repository{Name: "owner/tool", Host: "gitlab.example.com"}, false},
+		{Repository{Name: "owner/tool", Provider: "gitea"}, true},
+		{Repository{Name: "group/sub/tool", Provider: "gitea"}, false},
+		{Repository{Name: "owner/tool", Provider: "bitbucket"}, false},
+		{Repository{Name: "group/sub/tool"}, false},
+		{Repository{Name: "group//tool", Provider: "gitlab"}, false},
+		{Repository{Name: "tool", Provider: "gitlab"}, false},
+	}
+	for _, tt := range tests {
+		err := checkProvider(&tt.repo)
+		if err == nil {
+			err = tt.repo.CheckName()
+		}
+		if (err == nil) != tt.valid {
+			t.Errorf("%s on %q: %v, want valid: %v", tt.repo.Name, tt.repo.Provider, err, tt.valid)
+		}
+	}
+}
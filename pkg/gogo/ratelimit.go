@@ -0,0 +1,65 @@
+package gogo
+
+import (
+	"io"
+	"sync"
+	"time"
+)
+
+// RateLimiter caps the overall transfer rate of the downloads it is shared
+// by. A nil RateLimiter does not limit anything.
+type RateLimiter struct {
+	mu   sync.Mutex
+	rate float64
+	// Bytes that may be read right away, negative when readers are owed a wait
+	available float64
+	last      time.Time
+}
+
+// NewRateLimiter returns a limiter letting through bytesPerSecond, on
+// average, or nil, not limiting anything, if bytesPerSecond is not positive.
+func NewRateLimiter(bytesPerSecond int64) *RateLimiter {
+	if bytesPerSecond <= 0 {
+		return nil
+	}
+	return &RateLimiter{rate: float64(bytesPerSecond), last: time.Now()}
+}
+
+// Reader returns a reader reading from reader no faster than the limit
+// allows, along with every other reader of the limiter.
+func (l *RateLimiter) Reader(reader io.Reader) io.Reader {
+	if l == nil {
+		return reader
+	}
+	return &limitedReader{reader: reader, limiter: l}
+}
+
+// wait blocks until n more bytes may have been read: the limiter is a token
+// bucket holding at most a second's worth of bytes.
+func (l *RateLimiter) wait(n int) {
+	l.mu.Lock()
+	now := time.Now()
+	l.available = min(l.available+now.Sub(l.last).Seconds()*l.rate, l.rate)
+	l.last = now
+	l.available -= float64(n)
+	owed := -l.available
+	l.mu.Unlock()
+	if owed > 0 {
+		time.Sleep(time.Duration(owed / l.rate * float64(time.Second)))
+	}
+}
+
+type limitedReader struct {
+	reader  io.Reader
+	limiter *RateLimiter
+}
+
+func (r *limitedReader) Read(p []byte) (int, error) {
+	// Small reads keep the rate even at low limits
+	if len(p) > int(r.limiter.rate) {
+		p = p[:int(r.limiter.rate)]
+	}
+	n, err := r.reader.Read(p)
+	r.limiter.wait(n)
+	return n, err
+}
@@ -0,0 +1,35 @@
+package gogo
+
+import (
+	"bytes"
+	"io"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestRateLimiter(t *testing.T) {
+	reader := bytes.NewReader(nil)
+	if got := NewRateLimiter(0).Reader(reader); got != reader {
+		t.Errorf("Reader() without limit = %v, want the reader itself", got)
+	}
+
+	// Two downloads of 25KiB at 50KiB/s take a second altogether
+	limiter := NewRateLimiter(50 << 10)
+	start := time.Now()
+	var wg sync.WaitGroup
+	for i := 0; i < 2; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			n, err := io.Copy(io.Discard, limiter.Reader(bytes.NewReader(make([]byte, 25<<10))))
+			if err != nil || n != 25<<10 {
+				t.Errorf("Copy() = %d, %v", n, err)
+			}
+		}()
+	}
+	wg.Wait()
+	if elapsed := time.Since(start); elapsed < 900*time.Millisecond || elapsed > 2*time.Second {
+		t.Errorf("downloads took %v, want about a second", elapsed)
+	}
+}
@@ -0,0 +1,76 @@
+package gogo
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// RateQuota is how many GitHub API requests remain until the quota is reset.
+type RateQuota struct {
+	Limit     int
+	Remaining int
+	Reset     time.Time
+}
+
+// parseRateQuota reads the quota GitHub reports with every API response.
+func parseRateQuota(header http.Header) (RateQuota, bool) {
+	limit, err := strconv.Atoi(header.Get("X-RateLimit-Limit"))
+	if err != nil {
+		return RateQuota{}, false
+	}
+	remaining, err := strconv.Atoi(header.Get("X-RateLimit-Remaining"))
+	if err != nil {
+		return RateQuota{}, false
+	}
+	reset, err := strconv.ParseInt(header.Get("X-RateLimit-Reset"), 10, 64)
+	if err != nil {
+		return RateQuota{}, false
+	}
+	return RateQuota{Limit: limit, Remaining: remaining, Reset: time.Unix(reset, 0)}, true
+}
+
+// recordRateQuota remembers the quota reported with an API response.
+func (c *Client) recordRateQuota(resp *http.Response) {
+	quota, ok := parseRateQuota(resp.Header)
+	if !ok {
+		return
+	}
+	c.mu.Lock()
+	c.rateQuota = &quota
+	c.mu.Unlock()
+	c.logf("  - API requests left: %d/%d\n", quota.Remaining, quota.Limit)
+}
+
+// RateQuota returns the quota reported with the last API response, if any.
+func (c *Client) RateQuota() (RateQuota, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.rateQuota == nil {
+		return RateQuota{}, false
+	}
+	return *c.rateQuota, true
+}
+
+// GetRateQuota asks the API for the current quota, which does not use it up.
+func (c *Client) GetRateQuota() (RateQuota, error) {
+	var limits struct {
+		Resources struct {
+			Core struct {
+				Limit     int   `json:"limit"`
+				Remaining int   `json:"remaining"`
+				Reset     int64 `json:"reset"`
+			} `json:"core"`
+		} `json:"resources"`
+	}
+	found, err := c.getAPI(nil, c.APIURL+"/rate_limit", &limits)
+	if err != nil {
+		return RateQuota{}, err
+	}
+	if !found {
+		return RateQuota{}, fmt.Errorf("rate limits not found")
+	}
+	core := limits.Resources.Core
+	return RateQuota{Limit: core.Limit, Remaining: core.Remaining, Reset: time.Unix(core.Reset, 0)}, nil
+}
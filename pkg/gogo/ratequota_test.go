@@ -0,0 +1,44 @@
+package gogo
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestRateQuota(t *testing.T) {
+	remaining := 10
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/rate_limit" {
+			fmt.Fprint(w, `{"resources": {"core": {"limit": 5000, "remaining": 4990, "reset": 1700000000}}}`)
+			return
+		}
+		remaining--
+		w.Header().Set("X-RateLimit-Limit", "60")
+		w.Header().Set("X-RateLimit-Remaining", fmt.Sprint(remaining))
+		w.Header().Set("X-RateLimit-Reset", "1700000000")
+		fmt.Fprint(w, `{"id": 1, "assets": [{"id": 2, "name": "tool-linux-amd64"}]}`)
+	}))
+	defer server.Close()
+	client := &Client{HTTP: server.Client(), APIURL: server.URL}
+
+	if _, ok := client.RateQuota(); ok {
+		t.Errorf("RateQuota() reported a quota before any request")
+	}
+	for i := 0; i < 2; i++ {
+		if _, err := client.ResolveAsset(&Repository{Name: "owner/tool", File: "tool"}, Host{"linux", "amd64", "glibc"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want := RateQuota{Limit: 60, Remaining: 8, Reset: time.Unix(1700000000, 0)}
+	if quota, ok := client.RateQuota(); !ok || quota != want {
+		t.Errorf("RateQuota() = %v, %v, want %v", quota, ok, want)
+	}
+
+	want = RateQuota{Limit: 5000, Remaining: 4990, Reset: time.Unix(1700000000, 0)}
+	if quota, err := client.GetRateQuota(); err != nil || quota != want {
+		t.Errorf("GetRateQuota() = %v, %v, want %v", quota, err, want)
+	}
+}
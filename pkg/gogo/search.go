@@ -0,0 +1,66 @@
+package gogo
+
+import (
+	"fmt"
+	"net/url"
+	"path"
+	"strings"
+)
+
+// SearchResult is a GitHub repository found by Search, whose latest release
+// has an asset for the host.
+type SearchResult struct {
+	Name        string
+	Description string
+	Stars       int
+	Tag         string
+	Asset       string
+}
+
+// Search looks for GitHub repositories matching keywords, most starred
+// first, and keeps those whose latest release has an asset for host. At most
+// limit repositories are looked at, as each costs a request.
+func (c *Client) Search(keywords []string, host Host, limit int) ([]SearchResult, error) {
+	endpoint := fmt.Sprintf("%s/search/repositories?q=%s&sort=stars&per_page=%d", c.APIURL, url.QueryEscape(strings.Join(keywords, " ")), limit)
+	var found struct {
+		Items []struct {
+			FullName    string `json:"full_name"`
+			Description string `json:"description"`
+			Stars       int    `json:"stargazers_count"`
+			Archived    bool   `json:"archived"`
+		} `json:"items"`
+	}
+	if _, err := c.getAPI(nil, endpoint, &found); err != nil {
+		return nil, err
+	}
+	var results []SearchResult
+	for _, item := range found.Items {
+		if item.Archived {
+			continue
+		}
+		repo := &Repository{Name: item.FullName, File: path.Base(item.FullName)}
+		status, err := c.ResolveAsset(repo, host)
+		if err != nil {
+			c.logf("  - Skipping %s: %v\n", item.FullName, err)
+			continue
+		}
+		if status.Status != RepoOK {
+			c.logf("  - Skipping %s: %s\n", item.FullName, status.Message)
+			continue
+		}
+		results = append(results, SearchResult{
+			Name:        item.FullName,
+			Description: item.Description,
+			Stars:       item.Stars,
+			Tag:         status.Tag,
+			Asset:       status.Asset,
+		})
+	}
+	return results, nil
+}
+
+// Snippet returns the configuration installing the result's repository, to
+// be pasted into config.toml.
+func (r SearchResult) Snippet() string {
+	return (&Repository{Name: r.Name, File: path.Base(r.Name), Comment: r.Description}).Snippet()
+}
@@ -0,0 +1,53 @@
+package gogo
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/BurntSushi/toml"
+)
+
+func TestSearch(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/search/repositories":
+			if q := r.URL.Query().Get("q"); q != "json viewer" {
+				t.Errorf("searched for %q", q)
+			}
+			if r.Header.Get("Authorization") != "token secret" {
+				t.Errorf("search sent without the token")
+			}
+			fmt.Fprint(w, `{"items": [
+				{"full_name": "owner/jv", "description": "View \"JSON\"", "stargazers_count": 1200},
+				{"full_name": "owner/jsonlib", "description": "A library", "stargazers_count": 800},
+				{"full_name": "owner/old", "stargazers_count": 500, "archived": true}]}`)
+		case "/repos/owner/jv/releases/latest":
+			fmt.Fprint(w, `{"id": 1, "tag_name": "v2.0", "assets": [{"id": 2, "name": "jv_linux_amd64.tar.gz"}, {"id": 3, "name": "jv_darwin_arm64.tar.gz"}]}`)
+		case "/repos/owner/jsonlib/releases/latest":
+			fmt.Fprint(w, `{"id": 4, "tag_name": "v1.0", "assets": []}`)
+		default:
+			t.Errorf("unexpected request for %s", r.URL.Path)
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+	client := &Client{HTTP: server.Client(), APIURL: server.URL, Token: "secret"}
+
+	results, err := client.Search([]string{"json", "viewer"}, Host{"linux", "amd64", "glibc"}, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 1 || results[0].Name != "owner/jv" || results[0].Stars != 1200 || results[0].Tag != "v2.0" || results[0].Asset != "jv_linux_amd64.tar.gz" {
+		t.Fatalf("Search() = %+v", results)
+	}
+
+	var config Config
+	if _, err := toml.Decode(results[0].Snippet(), &config); err != nil {
+		t.Fatalf("snippet %q: %v", results[0].Snippet(), err)
+	}
+	if want := (Repository{Name: "owner/jv", File: "jv", Comment: `View "JSON"`}); len(config.Repositories) != 1 || config.Repositories[0].Name != want.Name || config.Repositories[0].File != want.File || config.Repositories[0].Comment != want.Comment {
+		t.Errorf("snippet %q configures %+v", results[0].Snippet(), config.Repositories)
+	}
+}
@@ -0,0 +1,200 @@
+package gogo
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// semver is a release's version, as found in its tag: "v1.4.2", "1.4",
+// "tool-1.4.2-rc1"... Missing parts are 0.
+type semver struct {
+	parts      [3]int
+	prerelease string
+}
+
+// parseSemver reads the version in a tag, skipping any prefix before its
+// first digit. It returns false if the tag holds no version.
+func parseSemver(tag string) (semver, bool) {
+	start := strings.IndexFunc(tag, func(r rune) bool { return r >= '0' && r <= '9' })
+	if start < 0 {
+		return semver{}, false
+	}
+	version, prerelease, _ := strings.Cut(tag[start:], "-")
+	version, _, _ = strings.Cut(version, "+")
+	fields := strings.Split(version, ".")
+	if len(fields) > 3 {
+		return semver{}, false
+	}
+	var v semver
+	for i, field := range fields {
+		n, err := strconv.Atoi(field)
+		if err != nil {
+			return semver{}, false
+		}
+		v.parts[i] = n
+	}
+	v.prerelease = prerelease
+	return v, true
+}
+
+// compare returns -1, 0 or 1 as v is lower, equal or greater than other. A
+// prerelease is lower than its release.
+func (v semver) compare(other semver) int {
+	for i := range v.parts {
+		switch {
+		case v.parts[i] < other.parts[i]:
+			return -1
+		case v.parts[i] > other.parts[i]:
+			return 1
+		}
+	}
+	switch {
+	case v.prerelease == other.prerelease:
+		return 0
+	case v.prerelease == "":
+		return 1
+	case other.prerelease == "":
+		return -1
+	}
+	return strings.Compare(v.prerelease, other.prerelease)
+}
+
+// versionBound is one condition of a constraint, e.g. ">= 1.4.0".
+type versionBound struct {
+	op      string
+	version semver
+}
+
+func (b versionBound) allows(v semver) bool {
+	c := v.compare(b.version)
+	switch b.op {
+	case ">=":
+		return c >= 0
+	case ">":
+		return c > 0
+	case "<=":
+		return c <= 0
+	case "<":
+		return c < 0
+	}
+	return c == 0
+}
+
+// versionConstraint is a set of bounds a version must all satisfy.
+type versionConstraint []versionBound
+
+func (vc versionConstraint) allows(v semver) bool {
+	for _, bound := range vc {
+		if !bound.allows(v) {
+			return false
+		}
+	}
+	return true
+}
+
+// IsVersionConstraint tells whether a repository's version is a constraint,
+// such as "~1.4", "^2", ">=1.2, <2" or "1.4.x", rather than an exact tag.
+func IsVersionConstraint(version string) bool {
+	if strings.ContainsAny(version, "~^<>=*, ") {
+		return true
+	}
+	for _, field := range strings.Split(version, ".") {
+		if field == "x" || field == "X" {
+			return true
+		}
+	}
+	return false
+}
+
+// parseConstraint reads a version constraint: bounds separated by commas or
+// spaces, each an operator (=, <, <=, >, >=, ~ or ^) and a version, which may
+// end with x or * instead of a number. ~1.4 allows 1.4.x, ^1.4 allows 1.x
+// from 1.4 on (0.4.x for ^0.4), as in npm or Cargo.
+func parseConstraint(constraint string) (versionConstraint, error) {
+	var vc versionConstraint
+	fields := strings.FieldsFunc(constraint, func(r rune) bool { return r == ',' || r == ' ' })
+	for i := 0; i < len(fields); i++ {
+		field := fields[i]
+		op := field[:len(field)-len(strings.TrimLeft(field, "~^<>="))]
+		version := field[len(op):]
+		if version == "" && i+1 < len(fields) {
+			// Written with a space, as in ">= 1.2"
+			i++
+			version = fields[i]
+		}
+		bounds, err := parseBound(op, version)
+		if err != nil {
+			return nil, fmt.Errorf("invalid version constraint %q: %v", constraint, err)
+		}
+		vc = append(vc, bounds...)
+	}
+	if len(vc) == 0 {
+		return nil, fmt.Errorf("invalid version constraint %q: empty", constraint)
+	}
+	return vc, nil
+}
+
+func parseBound(op string, version string) ([]versionBound, error) {
+	version = strings.TrimPrefix(version, "v")
+	var parts []int
+	for _, field := range strings.Split(version, ".") {
+		if field == "x" || field == "X" || field == "*" {
+			// 1.4.x is ~1.4
+			if op != "" && op != "=" {
+				return nil, fmt.Errorf("wildcard in %s%s", op, version)
+			}
+			op = "~"
+			break
+		}
+		n, err := strconv.Atoi(field)
+		if err != nil || len(parts) == 3 {
+			return nil, fmt.Errorf("%q is not a version", version)
+		}
+		parts = append(parts, n)
+	}
+	var lower semver
+	copy(lower.parts[:], parts)
+	if len(parts) == 0 {
+		// A lone wildcard allows anything
+		return []versionBound{{">=", lower}}, nil
+	}
+	upper := lower
+	switch op {
+	case "~":
+		// The last part given may change, or the minor version
+		i := min(len(parts)-1, 1)
+		upper.parts[i]++
+		clear(upper.parts[i+1:])
+	case "^":
+		// The first non-zero part may not change
+		i := 0
+		for i < len(parts)-1 && parts[i] == 0 {
+			i++
+		}
+		upper.parts[i]++
+		clear(upper.parts[i+1:])
+	case "", "=":
+		if len(parts) == 3 {
+			return []versionBound{{"=", lower}}, nil
+		}
+		upper.parts[len(parts)-1]++
+	case ">=", ">", "<=", "<":
+		return []versionBound{{op, lower}}, nil
+	default:
+		return nil, fmt.Errorf("unknown operator %s", op)
+	}
+	// Prereleases of the upper bound are below it, yet not wanted
+	upper.prerelease = "0"
+	return []versionBound{{">=", lower}, {"<", upper}}, nil
+}
+
+// CheckVersion makes sure a repository's version is a tag or a valid
+// constraint.
+func CheckVersion(version string) error {
+	if !IsVersionConstraint(version) {
+		return nil
+	}
+	_, err := parseConstraint(version)
+	return err
+}
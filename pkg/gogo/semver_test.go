@@ -0,0 +1,59 @@
+package gogo
+
+import "testing"
+
+func TestVersionConstraint(t *testing.T) {
+	tests := []struct {
+		constraint string
+		allowed    []string
+		refused    []string
+	}{
+		{"~1.4", []string{"v1.4.0", "1.4.9", "tool-v1.4.2"}, []string{"v1.5.0", "v1.3.9", "v1.4.0-rc1", "v1.5.0-rc1"}},
+		{"~1.4.2", []string{"v1.4.2", "v1.4.10"}, []string{"v1.4.1", "v1.5.0"}},
+		{"~1", []string{"v1.0.0", "v1.9.3"}, []string{"v2.0.0", "v0.9.0"}},
+		{"^1.4", []string{"v1.4.0", "v1.99.0"}, []string{"v2.0.0", "v1.3.0", "v2.0.0-beta"}},
+		{"^0.4", []string{"v0.4.0", "v0.4.7"}, []string{"v0.5.0", "v0.3.9"}},
+		{"1.4.x", []string{"v1.4.0", "v1.4.3"}, []string{"v1.5.0"}},
+		{">=1.2, <2", []string{"v1.2.0", "v1.9.9"}, []string{"v1.1.9", "v2.0.0"}},
+		{">= 1.2 < 2", []string{"v1.2.0"}, []string{"v2.1.0"}},
+		{"=1.2.3", []string{"1.2.3"}, []string{"1.2.4"}},
+	}
+	for _, tt := range tests {
+		constraint, err := parseConstraint(tt.constraint)
+		if err != nil {
+			t.Errorf("parseConstraint(%q): %v", tt.constraint, err)
+			continue
+		}
+		for _, tag := range tt.allowed {
+			if version, ok := parseSemver(tag); !ok || !constraint.allows(version) {
+				t.Errorf("%q refuses %s", tt.constraint, tag)
+			}
+		}
+		for _, tag := range tt.refused {
+			if version, ok := parseSemver(tag); ok && constraint.allows(version) {
+				t.Errorf("%q allows %s", tt.constraint, tag)
+			}
+		}
+	}
+}
+
+func TestCheckVersion(t *testing.T) {
+	tests := []struct {
+		version string
+		valid   bool
+	}{
+		{"v1.4.2", true},
+		{"nightly", true},
+		{"~1.4", true},
+		{">=1.2, <2", true},
+		{"~foo", false},
+		{"=>1.2", false},
+		{">=1.x", false},
+		{">=", false},
+	}
+	for _, tt := range tests {
+		if err := CheckVersion(tt.version); (err == nil) != tt.valid {
+			t.Errorf("CheckVersion(%q) = %v, want valid: %v", tt.version, err, tt.valid)
+		}
+	}
+}
@@ -0,0 +1,112 @@
+package gogo
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/jedisct1/go-minisign"
+)
+
+// SignatureSuffixes lists, per verification method, the suffix appended to
+// an asset's name to find its signature.
+var SignatureSuffixes = map[string]string{
+	"minisign": ".minisig",
+	"cosign":   ".sig",
+}
+
+// maxSignatureSize bounds signature files, which hold a few lines at most.
+const maxSignatureSize = 64 << 10
+
+func findSignatureAsset(assets []ReleaseAsset, assetName string, method string) (*ReleaseAsset, error) {
+	suffix, ok := SignatureSuffixes[method]
+	if !ok {
+		return nil, fmt.Errorf("unknown signature method %s (expected minisign or cosign)", method)
+	}
+	for _, asset := range assets {
+		if asset.Name == assetName+suffix {
+			return &asset, nil
+		}
+	}
+	return nil, fmt.Errorf("missing %s signature %s%s", method, assetName, suffix)
+}
+
+func fetchSignature(ctx context.Context, client *http.Client, token string, header http.Header, url string, signaturePath string) error {
+	if url == "" {
+		return fmt.Errorf("missing signature")
+	}
+	req, err := newDownloadRequest(ctx, url, token, header)
+	if err != nil {
+		return err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("non-OK HTTP status fetching signature: %s", resp.Status)
+	}
+	out, err := os.Create(signaturePath)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+	_, err = io.Copy(out, io.LimitReader(resp.Body, maxSignatureSize))
+	return err
+}
+
+func verifySignature(ctx context.Context, repo *Repository, assetPath string, signaturePath string) error {
+	if repo.PubKey == "" {
+		return fmt.Errorf("no pubkey configured for %s", repo.Name)
+	}
+	keyPath, err := ExpandPath(repo.PubKey)
+	if err != nil {
+		return err
+	}
+	isKeyFile := ExistFile(keyPath)
+
+	switch repo.Signature {
+	case "minisign":
+		var publicKey minisign.PublicKey
+		if isKeyFile {
+			publicKey, err = minisign.NewPublicKeyFromFile(keyPath)
+		} else {
+			publicKey, err = minisign.NewPublicKey(repo.PubKey)
+		}
+		if err != nil {
+			return fmt.Errorf("invalid minisign public key: %v", err)
+		}
+		signature, err := minisign.NewSignatureFromFile(signaturePath)
+		if err != nil {
+			return fmt.Errorf("invalid minisign signature: %v", err)
+		}
+		if _, err := publicKey.VerifyFromFile(assetPath, signature); err != nil {
+			return err
+		}
+		return nil
+	case "cosign":
+		cosign, err := exec.LookPath("cosign")
+		if err != nil {
+			return fmt.Errorf("cosign not found: %v", err)
+		}
+		if !isKeyFile {
+			// cosign wants a key file; the key was provided inline
+			keyPath = filepath.Join(filepath.Dir(signaturePath), "cosign.pub")
+			if err := os.WriteFile(keyPath, []byte(repo.PubKey), 0o600); err != nil {
+				return err
+			}
+		}
+		cmd := exec.CommandContext(ctx, cosign, "verify-blob", "--key", keyPath, "--signature", signaturePath, assetPath)
+		if output, err := cmd.CombinedOutput(); err != nil {
+			return fmt.Errorf("%v: %s", err, strings.TrimSpace(string(output)))
+		}
+		return nil
+	}
+	return fmt.Errorf("unknown signature method %s", repo.Signature)
+}
@@ -0,0 +1,153 @@
+package gogo
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+)
+
+// State remembers what was installed from each repository, by repository
+// name. It is kept as JSON, so that it is easy to audit, or edit.
+type State struct {
+	Repositories map[string]RepoState `json:"repositories"`
+}
+
+// RepoState describes the last successful installation of a repository.
+type RepoState struct {
+	File         string       `json:"file"`
+	Tag          string       `json:"tag"`
+	Asset        string       `json:"asset"`
+	Url          string       `json:"url"`
+	SignatureUrl string       `json:"signature_url,omitempty"`
+	ChecksumUrl  string       `json:"checksum_url,omitempty"`
+	Format       EAssetFormat `json:"format"`
+	PublishedAt  time.Time    `json:"published_at"`
+	InstalledAt  time.Time    `json:"installed_at"`
+	// Host and Selection are those the asset was selected for and with,
+	// without which it is selected again
+	Host      string `json:"host,omitempty"`
+	Selection string `json:"selection,omitempty"`
+	// Files lists every path installed from the repository, so that it can
+	// be uninstalled
+	Files []string `json:"files,omitempty"`
+}
+
+// NewState returns an empty state.
+func NewState() *State {
+	return &State{Repositories: map[string]RepoState{}}
+}
+
+// StatePath returns where the state is kept by default: in
+// $XDG_STATE_HOME/gogo, or ~/.local/state/gogo.
+func StatePath() (string, error) {
+	dir := os.Getenv("XDG_STATE_HOME")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		dir = filepath.Join(home, ".local", "state")
+	}
+	return filepath.Join(dir, "gogo", "state.json"), nil
+}
+
+// LoadState reads the state kept at statePath. A missing file is an empty
+// state.
+func LoadState(statePath string) (*State, error) {
+	state := NewState()
+	content, err := os.ReadFile(statePath)
+	if os.IsNotExist(err) {
+		return state, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(content, state); err != nil {
+		return nil, err
+	}
+	if state.Repositories == nil {
+		state.Repositories = map[string]RepoState{}
+	}
+	return state, nil
+}
+
+// Save writes the state to statePath. The file is replaced at once, so that
+// an interrupted save cannot lose the previous state.
+func (s *State) Save(statePath string) error {
+	if err := os.MkdirAll(filepath.Dir(statePath), 0o755); err != nil {
+		return err
+	}
+	content, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmpFile, err := os.CreateTemp(filepath.Dir(statePath), "state_*.json")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmpFile.Name())
+	if _, err := tmpFile.Write(content); err != nil {
+		tmpFile.Close()
+		return err
+	}
+	if err := tmpFile.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmpFile.Name(), statePath)
+}
+
+// Record remembers a successful installation. Files installed by previous
+// ones are still listed, as updating leaves behind those a release dropped.
+func (s *State) Record(status *RepoStatus) {
+	files := append(slices.Clone(s.Repositories[status.Repo.Name].Files), status.Installed...)
+	slices.Sort(files)
+	host := ""
+	if status.Selection != "" {
+		host = status.Host.String()
+	}
+	s.Repositories[status.Repo.Name] = RepoState{
+		File:         status.Repo.File,
+		Tag:          status.Tag,
+		Asset:        status.Asset,
+		Url:          status.Url,
+		SignatureUrl: status.SignatureUrl,
+		ChecksumUrl:  status.ChecksumUrl,
+		Format:       status.Format,
+		PublishedAt:  status.PublishedAt,
+		InstalledAt:  time.Now().UTC(),
+		Host:         host,
+		Selection:    status.Selection,
+		Files:        slices.Compact(files),
+	}
+}
+
+// installedTag returns the tag of the release last installed from repo, if
+// known.
+func (s *State) installedTag(repo *Repository) string {
+	if s == nil {
+		return ""
+	}
+	return s.Repositories[repo.Name].Tag
+}
+
+// cachedAsset returns the asset installed from the repository's release tag,
+// if it can be installed again as is: it was selected for the same host,
+// with the same settings.
+func (s *State) cachedAsset(repo *Repository, tag string, host Host, selection string) (RepoState, bool) {
+	if s == nil || tag == "" {
+		return RepoState{}, false
+	}
+	cached, ok := s.Repositories[repo.Name]
+	if !ok || cached.Tag != tag || cached.Asset == "" || cached.Format == GoInstallFormat {
+		return RepoState{}, false
+	}
+	if cached.Host != host.String() || cached.Selection != selection {
+		return RepoState{}, false
+	}
+	if repo.Signature != "" && cached.SignatureUrl == "" {
+		return RepoState{}, false
+	}
+	return cached, true
+}
@@ -0,0 +1,87 @@
+package gogo
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+)
+
+func TestStateRoundTrip(t *testing.T) {
+	statePath := filepath.Join(t.TempDir(), "gogo", "state.json")
+	state, err := LoadState(statePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	repo := &Repository{Name: "owner/tool", File: "tool"}
+	state.Record(&RepoStatus{Repo: repo, Tag: "v1.0.0", Asset: "tool.tar.gz", Url: "https://example.com/tool.tar.gz", Format: TargzipFormat})
+	if err := state.Save(statePath); err != nil {
+		t.Fatal(err)
+	}
+
+	state, err = LoadState(statePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := state.Repositories["owner/tool"]
+	if got.Tag != "v1.0.0" || got.Asset != "tool.tar.gz" || got.Format != TargzipFormat {
+		t.Errorf("state after reload = %+v", got)
+	}
+}
+
+func TestResolveAssetReusesState(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"id": 1, "tag_name": "v2", "assets": [{"id": 2, "name": "tool-linux-amd64.tar.gz", "browser_download_url": "https://example.com/new"}]}`)
+	}))
+	defer server.Close()
+	repo := &Repository{Name: "owner/tool", File: "tool"}
+	state := NewState()
+	client := &Client{HTTP: server.Client(), APIURL: server.URL, State: state}
+	host := Host{"linux", "amd64", "glibc"}
+	cached := RepoState{Tag: "v2", Asset: "tool.zip", Url: "https://example.com/cached", Format: ZipFormat, Host: host.String(), Selection: client.selection(repo, host)}
+	state.Repositories[repo.Name] = cached
+
+	status, err := client.ResolveAsset(repo, host)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status.Status != RepoOK || status.Asset != "tool.zip" || status.Format != ZipFormat {
+		t.Errorf("cached asset was not reused: %+v", status)
+	}
+
+	client.Reselect = true
+	if status, _ = client.ResolveAsset(repo, host); status.Asset != "tool-linux-amd64.tar.gz" {
+		t.Errorf("asset was not selected again: %+v", status)
+	}
+
+	client.Reselect = false
+	for _, tt := range []struct {
+		name   string
+		host   Host
+		client *Client
+		repo   *Repository
+	}{
+		{"another OS", Host{"darwin", "amd64", "glibc"}, client, repo},
+		{"another architecture", Host{"linux", "arm64", "glibc"}, client, repo},
+		{"another libc", Host{"linux", "amd64", "musl"}, client, repo},
+		{"other preferences", host, &Client{HTTP: server.Client(), APIURL: server.URL, State: state, Prefer: []string{".zip"}}, repo},
+		{"another pattern", host, client, &Repository{Name: "owner/tool", File: "tool", AssetPattern: "tool-*"}},
+	} {
+		if status, _ = tt.client.ResolveAsset(tt.repo, tt.host); status.Asset == "tool.zip" {
+			t.Errorf("asset selected for %s was reused", tt.name)
+		}
+	}
+	state.Record(&RepoStatus{Repo: repo, Tag: "v2", Asset: "tool.zip", Format: ZipFormat, Host: host, Selection: client.selection(repo, host)})
+	if status, _ = client.ResolveAsset(repo, host); status.Asset != "tool.zip" {
+		t.Errorf("recorded asset was not reused: %+v", status)
+	}
+
+	state.Repositories[repo.Name] = RepoState{Tag: "v1", Asset: "tool.zip", Format: ZipFormat, Host: cached.Host, Selection: cached.Selection}
+	if status, _ = client.ResolveAsset(repo, host); status.Asset != "tool-linux-amd64.tar.gz" {
+		t.Errorf("asset of an older release was reused: %+v", status)
+	}
+	if status.Tag != "v2" || status.InstalledTag != "v1" {
+		t.Errorf("tags = %q, installed %q, want v2, installed v1", status.Tag, status.InstalledTag)
+	}
+}
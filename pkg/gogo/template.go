@@ -0,0 +1,114 @@
+package gogo
+
+// ConfigTemplate is a configuration documenting every setting, to start from.
+const ConfigTemplate = `# gogo configuration
+#
+# Settings may be split across several .toml files in this directory: they
+# are read in lexical order, later files overriding earlier ones, while their
+# repositories add up.
+
+[auth]
+# GitHub token, raising the API quota from 60 to 5000 requests per hour and
+# giving access to private repositories. GOGO_GITHUB_TOKEN, GITHUB_TOKEN and
+# the gh CLI's token are used first, so that it need not be stored here.
+# token = "github_pat_..."
+
+[paths]
+# Where commands are installed; ~ is expanded
+targetdir = "~/.local/bin"
+# Permissions of installed commands
+# mode = "0755"
+# How many versions of each command to keep, for gogo rollback (0: only the
+# installed one, without versioning)
+# keep = 3
+
+[platform]
+# Preferred libc for Linux assets, glibc or musl (default: detected)
+# libc = "musl"
+# Asset formats, most preferred first, to choose between equally good assets:
+# binary, tar, tar.gz, tar.xz, tar.bz2, tar.zst, zst, zip, deb, rpm
+# prefer = ["binary", "tar.gz", "zip"]
+# Extensions of assets never to install (default: checksums and signatures)
+# ignore = [".sha256", ".sig", ".asc"]
+# Qualifiers making an asset lose to an otherwise equal one (default: debug, dbg)
+# demote = ["debug", "static"]
+
+# Other names assets give architectures or OSes, ranking below the usual
+# ones, e.g. for macOS assets built for both Intel and Apple silicon
+# [[os_alias]]
+# os = "darwin"
+# names = ["universal", "apple"]
+# [[arch_alias]]
+# arch = "arm64"
+# names = ["universal"]
+
+[network]
+# Proxy, rather than the HTTP_PROXY, HTTPS_PROXY and NO_PROXY variables
+# proxy = "http://proxy.example.com:3128"
+# Headers sent with every request
+# headers = { "X-Tenant" = "acme" }
+# How many times failed requests are tried again, waiting longer each time
+# (default: 3, -1 for never)
+# retries = 5
+# How long connecting and waiting for a response may take (default: 30s)
+# timeout = "1m"
+# GitHub API to use, that of a GitHub Enterprise Server here
+# api_url = "https://github.example.com/api/v3"
+
+[filter]
+# Tags list and fetch are limited to when no -tags is given
+# default_tags = ["daily"]
+
+# Other repositories publishing lists of commands for gogo refresh, besides
+# gogo's own. Their files are prefixed with the repository's name.
+# [[catalogs]]
+# repo = "myorg/gogo-catalog"
+# asset = "catalog.tgz"  # default: config.tgz
+
+# One block per repository to install commands from
+
+[[repositories]]
+# GitHub repository, as owner/repo or as a URL
+name = "jesseduffield/lazygit"
+# Command to install from the release's asset
+file = "lazygit"
+# Shown by gogo list, and used to filter commands
+comment = "Simple terminal UI for git commands"
+tags = ["git", "daily"]
+
+[[repositories]]
+name = "sharkdp/fd"
+file = "fd"
+comment = "Simple, fast and user-friendly alternative to find"
+tags = ["files"]
+# Other files to copy next to the command, or glob patterns
+utils = ["fd.1"]
+# Where to copy them instead, relative to the target directory
+utils_dest = { "fd.1" = "../share/man/man1" }
+# Shells to install completions for: bash, zsh or fish, optionally followed
+# by the completion file's pattern, e.g. "zsh:contrib/completion/_fd"
+completions = ["bash", "zsh", "fish"]
+
+# Every other setting of a repository, with an example value:
+#
+# provider = "gitlab"                           # where the repository is: github (default), gitlab or gitea
+# host = "gitlab.example.com"                   # another instance than gitlab.com or codeberg.org
+# url = "https://example.com/tool-linux-amd64"  # download a file, rather than a release asset
+# files = ["tool-server"]                       # other commands in the same asset
+# command = "toold"                             # the command's name, in the asset and once installed, if not file
+# go_install = "example.com/tool@latest"        # built with go install when no asset fits
+# signature = "minisign"                        # or "cosign", to verify assets
+# pubkey = "RWQ..."                             # the signing key, or a path to it
+# mode = "0750"                                 # permissions, overriding paths.mode
+# asset_pattern = "*linux64*"                  # only select assets matching this glob, or /regexp/
+# prefer = ["tar.gz"]                           # overriding platform.prefer
+# ignore = [".deb"]                             # overriding platform.ignore
+# demote = ["musl"]                             # overriding platform.demote
+# appimage = true                               # install an AppImage when no other asset fits
+# prerelease = true                             # install prereleases too
+# version = "~1.4"                              # a release tag, or the highest release matching a constraint
+# verify_cmd = "--help"                         # arguments for fetch -verify (default: --version)
+# headers = { "X-Api-Key" = "..." }             # headers sent with this repository's requests
+# mirrors = ["https://mirror.example.com/{{.Tag}}/{{.Asset}}"]  # tried in order when downloading fails
+# disabled = true                               # only installed when fetched by name
+`
@@ -0,0 +1,36 @@
+package gogo
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/BurntSushi/toml"
+)
+
+func TestConfigTemplate(t *testing.T) {
+	var config Config
+	if _, err := toml.Decode(ConfigTemplate, &config); err != nil {
+		t.Fatal(err)
+	}
+	if problems := config.Validate(); problems != nil {
+		t.Errorf("Validate() = %q, want no problems", problems)
+	}
+	if len(config.Repositories) != 2 {
+		t.Errorf("template has %d repositories, want 2", len(config.Repositories))
+	}
+
+	// Every setting is documented
+	for _, setting := range []any{Auth{}, Paths{}, Platform{}, Network{}, Filter{}, Catalog{}, ArchAlias{}, OSAlias{}, Repository{}} {
+		settingType := reflect.TypeOf(setting)
+		for i := 0; i < settingType.NumField(); i++ {
+			key := settingType.Field(i).Tag.Get("toml")
+			if key == "-" {
+				continue
+			}
+			if !strings.Contains(ConfigTemplate, key+" = ") {
+				t.Errorf("template does not document %s.%s", settingType.Name(), key)
+			}
+		}
+	}
+}
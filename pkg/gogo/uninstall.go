@@ -0,0 +1,41 @@
+package gogo
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// Uninstall removes what was installed from a repository to targetDir: the
+// files the state lists for it or, for installations recorded before the
+// state did, those it would install. Kept versions are removed too. The
+// repository is forgotten, and the paths removed returned.
+func (s *State) Uninstall(repo *Repository, targetDir string) ([]string, error) {
+	files := s.Repositories[repo.Name].Files
+	if len(files) == 0 {
+		status := RepoStatus{Repo: repo}
+		for _, filePath := range status.PlannedPaths(targetDir) {
+			if !isGlob(filePath) {
+				files = append(files, filePath)
+			}
+		}
+	}
+	var removed []string
+	for _, filePath := range files {
+		if _, err := os.Lstat(filePath); os.IsNotExist(err) {
+			continue
+		}
+		if err := os.Remove(filePath); err != nil {
+			return removed, err
+		}
+		removed = append(removed, filePath)
+	}
+	versionsDir := filepath.Join(targetDir, VersionsDir, repo.File)
+	if _, err := os.Stat(versionsDir); err == nil && repo.File != "" {
+		if err := os.RemoveAll(versionsDir); err != nil {
+			return removed, err
+		}
+		removed = append(removed, versionsDir)
+	}
+	delete(s.Repositories, repo.Name)
+	return removed, nil
+}
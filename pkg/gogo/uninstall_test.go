@@ -0,0 +1,76 @@
+package gogo
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestUninstall(t *testing.T) {
+	var archive bytes.Buffer
+	gzipWriter := gzip.NewWriter(&archive)
+	tw := tar.NewWriter(gzipWriter)
+	content := []byte("#!/bin/sh\n")
+	for _, header := range []*tar.Header{
+		{Name: "tool/tool", Typeflag: tar.TypeReg, Mode: 0o755, Size: int64(len(content))},
+		{Name: "tool/toolctl", Typeflag: tar.TypeSymlink, Linkname: "tool"},
+		{Name: "tool/tool.1", Typeflag: tar.TypeReg, Mode: 0o644, Size: int64(len(content))},
+	} {
+		tw.WriteHeader(header)
+		if header.Typeflag == tar.TypeReg {
+			tw.Write(content)
+		}
+	}
+	tw.Close()
+	gzipWriter.Close()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(archive.Bytes())
+	}))
+	defer server.Close()
+
+	targetDir := t.TempDir()
+	unrelated := filepath.Join(targetDir, "other")
+	os.WriteFile(unrelated, content, 0o755)
+	repo := &Repository{Name: "owner/tool", File: "tool", Files: []string{"toolctl"}, Utils: []string{"tool.1"}, UtilsDest: map[string]string{"tool.1": "man"}}
+	status := &RepoStatus{Repo: repo, Status: RepoOK, Format: TargzipFormat, Asset: "tool.tar.gz", Url: server.URL + "/tool.tar.gz"}
+	client := &Client{HTTP: server.Client()}
+	if err := client.Install(status, targetDir); err != nil {
+		t.Fatal(err)
+	}
+	state := NewState()
+	state.Record(status)
+	if files := state.Repositories[repo.Name].Files; len(files) != 3 {
+		t.Fatalf("recorded files = %q, want 3", files)
+	}
+
+	removed, err := state.Uninstall(repo, targetDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(removed) != 3 {
+		t.Errorf("removed %q, want 3 files", removed)
+	}
+	for _, name := range []string{"tool", "toolctl", filepath.Join("man", "tool.1")} {
+		if _, err := os.Lstat(filepath.Join(targetDir, name)); !os.IsNotExist(err) {
+			t.Errorf("%s was left behind", name)
+		}
+	}
+	if _, err := os.Stat(unrelated); err != nil {
+		t.Errorf("unrelated file was removed: %v", err)
+	}
+	if _, ok := state.Repositories[repo.Name]; ok {
+		t.Error("uninstalled repository is still recorded")
+	}
+
+	// Without a manifest, the files the repository would install are removed
+	os.WriteFile(filepath.Join(targetDir, "tool"), content, 0o755)
+	os.MkdirAll(filepath.Join(targetDir, VersionsDir, "tool", "v1"), 0o755)
+	if removed, err := state.Uninstall(repo, targetDir); err != nil || len(removed) != 2 {
+		t.Errorf("Uninstall() without a manifest removed %q (%v), want the command and its versions", removed, err)
+	}
+}
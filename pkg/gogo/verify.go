@@ -0,0 +1,40 @@
+package gogo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// DefaultVerifyCmd is what commands are run with to verify them, unless their
+// repository sets verify_cmd.
+const DefaultVerifyCmd = "--version"
+
+// VerifyTimeout is how long a command being verified may run before it is
+// stopped. Having run that long, it is considered fine.
+var VerifyTimeout = 10 * time.Second
+
+// Verify runs an installed command with harmless arguments, to make sure it
+// can run on this host. Only failing to start it is an error, whatever it
+// exits with: a command built for another platform does not even start.
+func Verify(filePath string, repo *Repository) error {
+	verifyCmd := repo.VerifyCmd
+	if verifyCmd == "" {
+		verifyCmd = DefaultVerifyCmd
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), VerifyTimeout)
+	defer cancel()
+	err := exec.CommandContext(ctx, filePath, strings.Fields(verifyCmd)...).Run()
+	var exitErr *exec.ExitError
+	if err == nil || errors.As(err, &exitErr) {
+		return nil
+	}
+	if errors.Is(err, syscall.ENOEXEC) {
+		return fmt.Errorf("%s cannot run here (exec format error), it was probably built for another platform", repo.MainCommand())
+	}
+	return fmt.Errorf("%s cannot run here: %v", repo.MainCommand(), err)
+}
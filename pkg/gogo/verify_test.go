@@ -0,0 +1,46 @@
+package gogo
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestVerify(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses shell scripts")
+	}
+	dir := t.TempDir()
+	VerifyTimeout = 200 * time.Millisecond
+	defer func() { VerifyTimeout = 10 * time.Second }()
+	tests := []struct {
+		name      string
+		content   string
+		verifyCmd string
+		wantErr   string
+	}{
+		{"runs", "#!/bin/sh\n[ \"$1\" = --version ]\n", "", ""},
+		{"non-zero exit", "#!/bin/sh\nexit 3\n", "--help", ""},
+		{"stopped after a while", "#!/bin/sh\nsleep 5\n", "", ""},
+		{"wrong format", "\x7fELF\x02\x01\x01garbage", "", "exec format error"},
+		{"missing interpreter", "#!/nonexistent/sh\n", "", "cannot run here"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filePath := filepath.Join(dir, strings.ReplaceAll(tt.name, " ", "_"))
+			if err := os.WriteFile(filePath, []byte(tt.content), 0o755); err != nil {
+				t.Fatal(err)
+			}
+			err := Verify(filePath, &Repository{File: "tool", VerifyCmd: tt.verifyCmd})
+			if tt.wantErr == "" && err != nil {
+				t.Errorf("Verify() = %v, want no error", err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Errorf("Verify() = %v, want an error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
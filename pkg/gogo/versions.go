@@ -0,0 +1,189 @@
+package gogo
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+)
+
+// VersionsDir is where, within the target directory, versions of commands are
+// kept when installing with InstallVersion.
+const VersionsDir = ".gogo"
+
+// InstallVersion installs a repository like Install does, except that its
+// commands are kept in TargetDir/.gogo/<file>/<tag>/, the target directory
+// only linking to the active version. Only the last keep versions are kept.
+func (c *Client) InstallVersion(status *RepoStatus, targetDir string, keep int) error {
+	repo := status.Repo
+	if repo.UtilsOnly {
+		// Utils are not versioned
+		return c.Install(status, targetDir)
+	}
+	version := versionName(status.Tag)
+	versionDir := filepath.Join(targetDir, VersionsDir, repo.File, version)
+
+	// Installing through the links would overwrite the active version
+	previous := map[string]string{}
+	for _, binary := range repo.Binaries() {
+		linkPath := filepath.Join(targetDir, binary)
+		if link, err := os.Readlink(linkPath); err == nil && strings.HasPrefix(link, VersionsDir+string(filepath.Separator)) {
+			previous[linkPath] = link
+			if err := os.Remove(linkPath); err != nil {
+				return err
+			}
+		}
+	}
+	if err := c.Install(status, targetDir); err != nil {
+		for linkPath, link := range previous {
+			os.Remove(linkPath)
+			os.Symlink(link, linkPath)
+		}
+		return err
+	}
+
+	if err := os.RemoveAll(versionDir); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(versionDir, 0o755); err != nil {
+		return err
+	}
+	for _, binary := range repo.Binaries() {
+		filePath := filepath.Join(targetDir, binary)
+		if _, err := os.Lstat(filePath); os.IsNotExist(err) {
+			continue
+		}
+		if err := os.Rename(filePath, filepath.Join(versionDir, binary)); err != nil {
+			return err
+		}
+	}
+	now := time.Now()
+	if err := os.Chtimes(versionDir, now, now); err != nil {
+		return err
+	}
+	if err := activateVersion(targetDir, repo, version); err != nil {
+		return err
+	}
+	return pruneVersions(targetDir, repo, version, keep)
+}
+
+// Rollback makes the version installed before the active one active again,
+// returning its name.
+func Rollback(targetDir string, repo *Repository) (string, error) {
+	versions, err := ListVersions(targetDir, repo)
+	if err != nil {
+		return "", err
+	}
+	active := ActiveVersion(targetDir, repo)
+	i := slices.Index(versions, active)
+	if i < 0 {
+		return "", fmt.Errorf("%s is not installed as a version", repo.File)
+	}
+	if i == 0 {
+		return "", fmt.Errorf("no version of %s older than %s", repo.File, active)
+	}
+	return versions[i-1], activateVersion(targetDir, repo, versions[i-1])
+}
+
+// ListVersions lists the versions kept for a repository, oldest first.
+func ListVersions(targetDir string, repo *Repository) ([]string, error) {
+	entries, err := os.ReadDir(filepath.Join(targetDir, VersionsDir, repo.File))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	type version struct {
+		name        string
+		installedAt time.Time
+	}
+	var versions []version
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return nil, err
+		}
+		versions = append(versions, version{entry.Name(), info.ModTime()})
+	}
+	sort.Slice(versions, func(i, j int) bool {
+		if versions[i].installedAt.Equal(versions[j].installedAt) {
+			return versions[i].name < versions[j].name
+		}
+		return versions[i].installedAt.Before(versions[j].installedAt)
+	})
+	names := make([]string, len(versions))
+	for i, version := range versions {
+		names[i] = version.name
+	}
+	return names, nil
+}
+
+// ActiveVersion returns the version the repository's command links to, or
+// an empty string if it is not installed as a version.
+func ActiveVersion(targetDir string, repo *Repository) string {
+	link, err := os.Readlink(filepath.Join(targetDir, repo.MainBinary()))
+	if err != nil {
+		return ""
+	}
+	rest, found := strings.CutPrefix(filepath.ToSlash(link), VersionsDir+"/"+repo.File+"/")
+	if !found {
+		return ""
+	}
+	version, _, _ := strings.Cut(rest, "/")
+	return version
+}
+
+// activateVersion links the repository's commands to the given version.
+func activateVersion(targetDir string, repo *Repository, version string) error {
+	for _, binary := range repo.Binaries() {
+		link := filepath.Join(VersionsDir, repo.File, version, binary)
+		if _, err := os.Lstat(filepath.Join(targetDir, link)); os.IsNotExist(err) {
+			continue
+		}
+		linkPath := filepath.Join(targetDir, binary)
+		if err := os.Remove(linkPath); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		if err := os.Symlink(link, linkPath); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// pruneVersions removes all but the last keep versions, never the active one.
+func pruneVersions(targetDir string, repo *Repository, active string, keep int) error {
+	versions, err := ListVersions(targetDir, repo)
+	if err != nil {
+		return err
+	}
+	for i, version := range versions {
+		if i >= len(versions)-keep || version == active {
+			continue
+		}
+		if err := os.RemoveAll(filepath.Join(targetDir, VersionsDir, repo.File, version)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// versionName turns a release tag into a directory name. Files downloaded
+// from a URL have no tag, and are named after their installation time.
+func versionName(tag string) string {
+	if tag == "" {
+		return time.Now().Format("20060102-150405")
+	}
+	name := strings.NewReplacer("/", "_", "\\", "_").Replace(tag)
+	if name == "." || name == ".." {
+		name = "_" + name
+	}
+	return name
+}
@@ -0,0 +1,51 @@
+package gogo
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestInstallVersion(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("tool " + r.URL.Path))
+	}))
+	defer server.Close()
+	client := &Client{HTTP: server.Client()}
+	targetDir := t.TempDir()
+	repo := &Repository{Name: "owner/tool", File: "tool"}
+
+	installed := time.Now().Add(-time.Hour)
+	for _, tag := range []string{"v1", "v2", "v3"} {
+		status := &RepoStatus{Repo: repo, Status: RepoOK, Format: BinaryFormat, Url: server.URL + "/" + tag, Tag: tag, Mode: 0o755}
+		if err := client.InstallVersion(status, targetDir, 2); err != nil {
+			t.Fatal(err)
+		}
+		// Versions are ordered by installation time, which must differ
+		installed = installed.Add(time.Minute)
+		os.Chtimes(filepath.Join(targetDir, VersionsDir, "tool", tag), installed, installed)
+	}
+
+	versions, err := ListVersions(targetDir, repo)
+	if err != nil || !slices.Equal(versions, []string{"v2", "v3"}) {
+		t.Fatalf("ListVersions() = %q (%v), want v2 and v3", versions, err)
+	}
+	if content, _ := os.ReadFile(filepath.Join(targetDir, "tool")); string(content) != "tool /v3" {
+		t.Errorf("tool runs %q, want v3", content)
+	}
+
+	version, err := Rollback(targetDir, repo)
+	if err != nil || version != "v2" {
+		t.Fatalf("Rollback() = %q (%v), want v2", version, err)
+	}
+	if content, _ := os.ReadFile(filepath.Join(targetDir, "tool")); string(content) != "tool /v2" {
+		t.Errorf("tool runs %q after rollback, want v2", content)
+	}
+	if _, err := Rollback(targetDir, repo); err == nil {
+		t.Error("Rollback() past the oldest version succeeded")
+	}
+}
@@ -0,0 +1,72 @@
+package gogo
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// WorkDirPrefix starts the name of the temporary directories assets are
+// downloaded and extracted in.
+const WorkDirPrefix = "gogo_work_"
+
+// newWorkDir creates a work directory in the system's temporary directory,
+// $TMPDIR or /tmp on Unix and %TEMP% on Windows, where CleanWorkDirs looks.
+func newWorkDir() (string, error) {
+	return os.MkdirTemp(os.TempDir(), WorkDirPrefix+"*")
+}
+
+// CleanWorkDirs removes the temporary directories left behind by runs that
+// were interrupted, provided they are older than olderThan, so as not to
+// pull the rug from under a running gogo. It returns how many were removed,
+// and how many bytes that reclaimed.
+func CleanWorkDirs(olderThan time.Duration) (int, int64, error) {
+	entries, err := os.ReadDir(os.TempDir())
+	if err != nil {
+		return 0, 0, err
+	}
+	removed := 0
+	var reclaimed int64
+	for _, entry := range entries {
+		if !entry.IsDir() || !isWorkDir(entry.Name()) {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil || time.Since(info.ModTime()) < olderThan {
+			continue
+		}
+		dir := filepath.Join(os.TempDir(), entry.Name())
+		size := dirSize(dir)
+		if err := os.RemoveAll(dir); err != nil {
+			return removed, reclaimed, err
+		}
+		removed++
+		reclaimed += size
+	}
+	return removed, reclaimed, nil
+}
+
+// isWorkDir tells whether name is that of a directory made by newWorkDir,
+// whose random part is made of digits.
+func isWorkDir(name string) bool {
+	suffix, found := strings.CutPrefix(name, WorkDirPrefix)
+	if !found || suffix == "" {
+		return false
+	}
+	return strings.Trim(suffix, "0123456789") == ""
+}
+
+func dirSize(dir string) int64 {
+	var size int64
+	filepath.WalkDir(dir, func(_ string, entry fs.DirEntry, err error) error {
+		if err == nil && entry.Type().IsRegular() {
+			if info, err := entry.Info(); err == nil {
+				size += info.Size()
+			}
+		}
+		return nil
+	})
+	return size
+}
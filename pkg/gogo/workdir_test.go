@@ -0,0 +1,36 @@
+package gogo
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestCleanWorkDirs(t *testing.T) {
+	t.Setenv("TMPDIR", t.TempDir())
+	old := time.Now().Add(-48 * time.Hour)
+	for _, name := range []string{"gogo_work_123", "gogo_work_456", "gogo_work_other", "unrelated_789"} {
+		dir := filepath.Join(os.TempDir(), name)
+		if err := os.Mkdir(dir, 0o755); err != nil {
+			t.Fatal(err)
+		}
+		os.WriteFile(filepath.Join(dir, "asset"), make([]byte, 100), 0o644)
+		if name != "gogo_work_456" {
+			os.Chtimes(dir, old, old)
+		}
+	}
+
+	removed, reclaimed, err := CleanWorkDirs(24 * time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if removed != 1 || reclaimed != 100 {
+		t.Errorf("CleanWorkDirs() = %d, %d, want 1, 100", removed, reclaimed)
+	}
+	for name, kept := range map[string]bool{"gogo_work_123": false, "gogo_work_456": true, "gogo_work_other": true, "unrelated_789": true} {
+		if _, err := os.Stat(filepath.Join(os.TempDir(), name)); (err == nil) != kept {
+			t.Errorf("%s kept: %v, want %v", name, err == nil, kept)
+		}
+	}
+}
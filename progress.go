@@ -0,0 +1,159 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fusion/gogo/pkg/gogo"
+)
+
+// downloadProgress shows how far downloads are: as a bar per file along with
+// the overall throughput on a terminal, or as a line every few seconds
+// otherwise.
+type downloadProgress struct {
+	mu          sync.Mutex
+	live        bool
+	downloads   []*download
+	started     time.Time
+	lastPrinted time.Time
+	done        chan struct{}
+	stopped     chan struct{}
+}
+
+type download struct {
+	file       string
+	downloaded int64
+	// total is -1 if unknown
+	total int64
+	// resumed is what was downloaded before, left out of the throughput
+	resumed  int64
+	finished bool
+}
+
+const progressBarWidth = 30
+
+// newDownloadProgress starts showing download progress, until stop is called.
+func newDownloadProgress(live bool) *downloadProgress {
+	p := &downloadProgress{live: live, started: time.Now(), lastPrinted: time.Now()}
+	if live {
+		p.done = make(chan struct{})
+		p.stopped = make(chan struct{})
+		stdout.SetLive(p.render)
+		go func() {
+			defer close(p.stopped)
+			ticker := time.NewTicker(200 * time.Millisecond)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-p.done:
+					return
+				case <-ticker.C:
+					stdout.Redraw()
+				}
+			}
+		}()
+	}
+	return p
+}
+
+// update is told how much of a repository's asset was downloaded, as a
+// gogo.Client's Progress.
+func (p *downloadProgress) update(repo *gogo.Repository, downloaded int64, total int64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	d := p.find(repo.File)
+	if d == nil {
+		d = &download{file: repo.File, resumed: downloaded}
+		p.downloads = append(p.downloads, d)
+	}
+	d.downloaded, d.total = downloaded, total
+	if !p.live && time.Since(p.lastPrinted) >= 5*time.Second {
+		p.lastPrinted = time.Now()
+		stdout.Interject([]byte(fmt.Sprintf("  ... downloading %s\n", p.summary())))
+	}
+}
+
+// finish removes a repository's download from those shown.
+func (p *downloadProgress) finish(file string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if d := p.find(file); d != nil {
+		d.finished = true
+	}
+}
+
+func (p *downloadProgress) stop() {
+	if p.live {
+		close(p.done)
+		<-p.stopped
+		stdout.SetLive(nil)
+	}
+}
+
+func (p *downloadProgress) find(file string) *download {
+	for _, d := range p.downloads {
+		if d.file == file {
+			return d
+		}
+	}
+	return nil
+}
+
+// throughput returns how many bytes per second were downloaded overall.
+func (p *downloadProgress) throughput() float64 {
+	var downloaded int64
+	for _, d := range p.downloads {
+		downloaded += d.downloaded - d.resumed
+	}
+	return float64(downloaded) / max(time.Since(p.started).Seconds(), 0.001)
+}
+
+// summary describes the downloads in progress on a single line.
+func (p *downloadProgress) summary() string {
+	var parts []string
+	for _, d := range p.downloads {
+		if d.finished {
+			continue
+		}
+		if d.total > 0 {
+			parts = append(parts, fmt.Sprintf("%s %d%% of %s", d.file, d.downloaded*100/d.total, gogo.HumanSize(uint64(d.total))))
+		} else {
+			parts = append(parts, fmt.Sprintf("%s %s", d.file, gogo.HumanSize(uint64(d.downloaded))))
+		}
+	}
+	return fmt.Sprintf("%s (%s/s)", strings.Join(parts, ", "), gogo.HumanSize(uint64(p.throughput())))
+}
+
+// render draws a bar per download in progress, and the overall throughput.
+func (p *downloadProgress) render() string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	var lines strings.Builder
+	width := 0
+	active := 0
+	for _, d := range p.downloads {
+		if !d.finished {
+			width = max(width, len(d.file))
+			active++
+		}
+	}
+	if active == 0 {
+		return ""
+	}
+	for _, d := range p.downloads {
+		if d.finished {
+			continue
+		}
+		if d.total <= 0 {
+			fmt.Fprintf(&lines, "  %-*s %s\n", width, d.file, gogo.HumanSize(uint64(d.downloaded)))
+			continue
+		}
+		filled := int(min(d.downloaded, d.total) * progressBarWidth / d.total)
+		bar := okStyle.Render(strings.Repeat("█", filled)) + dimStyle.Render(strings.Repeat("░", progressBarWidth-filled))
+		fmt.Fprintf(&lines, "  %-*s %s %3d%% %s / %s\n", width, d.file, bar, d.downloaded*100/d.total, gogo.HumanSize(uint64(d.downloaded)), gogo.HumanSize(uint64(d.total)))
+	}
+	fmt.Fprintf(&lines, "  %d downloading, %s/s", active, gogo.HumanSize(uint64(p.throughput())))
+	return lines.String()
+}